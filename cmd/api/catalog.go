@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	catalogmodel "librarymvc/internal/catalog/models"
+)
+
+const catalogUsage = `usage:
+  api catalog import [-dry-run] [-book-type code] file
+  api catalog export [-format marcxml|marc] [-o file]`
+
+// runCatalog imports or exports the catalog in MARC 21 from the command
+// line, against the same storage the server uses.
+func runCatalog(catalogSvc catalogmodel.CatalogService, args []string) error {
+	if len(args) == 0 {
+		return errors.New(catalogUsage)
+	}
+
+	switch args[0] {
+	case "import":
+		flags := flag.NewFlagSet("catalog import", flag.ContinueOnError)
+		dryRun := flags.Bool("dry-run", false, "report what would change without saving")
+		bookType := flags.String("book-type", catalogmodel.DefaultBookType, "loan policy of the books created")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return errors.New(catalogUsage)
+		}
+
+		data, err := os.ReadFile(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		report, err := catalogSvc.Import(data, catalogmodel.ImportOptions{DryRun: *dryRun, BookType: *bookType})
+		if err != nil {
+			return err
+		}
+		printImportReport(os.Stdout, report)
+		return nil
+	case "export":
+		flags := flag.NewFlagSet("catalog export", flag.ContinueOnError)
+		format := flags.String("format", "marcxml", "marcxml or marc (ISO 2709)")
+		output := flags.String("o", "", "file to write; standard output by default")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		data, err := catalogSvc.Export(*format)
+		if err != nil {
+			return err
+		}
+		if *output == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return os.WriteFile(*output, data, 0o644)
+	default:
+		return errors.New(catalogUsage)
+	}
+}
+
+func printImportReport(w io.Writer, report *catalogmodel.ImportReport) {
+	for _, record := range report.Records {
+		fmt.Fprintf(w, "record %d: %s", record.Record, record.Action)
+		if record.BookID != 0 {
+			fmt.Fprintf(w, " #%d", record.BookID)
+		}
+		fmt.Fprintf(w, " %q", record.Title)
+		if record.Reason != "" {
+			fmt.Fprintf(w, ": %s", record.Reason)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "%s: %d created, %d updated, %d skipped", report.Format, report.Created, report.Updated, report.Skipped)
+	if report.DryRun {
+		fmt.Fprint(w, " (dry run, nothing saved)")
+	}
+	fmt.Fprintln(w)
+}
@@ -1,83 +1,420 @@
 package main
 
 import (
+	"crypto/rand"
+	"database/sql"
 	"log"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"librarymvc/internal/apperror"
+	"librarymvc/internal/clock"
+	"librarymvc/internal/database"
+	"librarymvc/internal/money"
+	"librarymvc/internal/scheduler"
+	"librarymvc/internal/unitofwork"
+
+	accountcontroller "librarymvc/internal/accounts/controllers"
+	accountmodel "librarymvc/internal/accounts/models"
+	accountrepository "librarymvc/internal/accounts/repositories"
+	accountservice "librarymvc/internal/accounts/services"
+
+	authcontroller "librarymvc/internal/auth/controllers"
+	authmodel "librarymvc/internal/auth/models"
+	authrepository "librarymvc/internal/auth/repositories"
+	authservice "librarymvc/internal/auth/services"
+
+	calendarcontroller "librarymvc/internal/calendar/controllers"
+	calendarmodel "librarymvc/internal/calendar/models"
+	calendarrepository "librarymvc/internal/calendar/repositories"
+	calendarservice "librarymvc/internal/calendar/services"
+
+	catalogcontroller "librarymvc/internal/catalog/controllers"
+	catalogservice "librarymvc/internal/catalog/services"
+
 	bookcontroller "librarymvc/internal/books/controllers"
+	bookmodel "librarymvc/internal/books/models"
 	bookrepository "librarymvc/internal/books/repositories"
 	bookservice "librarymvc/internal/books/services"
 
+	copycontroller "librarymvc/internal/copies/controllers"
+	copymodel "librarymvc/internal/copies/models"
+	copyrepository "librarymvc/internal/copies/repositories"
+	copyservice "librarymvc/internal/copies/services"
+
 	usercontroller "librarymvc/internal/users/controllers"
+	usermodel "librarymvc/internal/users/models"
 	userrepository "librarymvc/internal/users/repositories"
 	userservice "librarymvc/internal/users/services"
 
 	loancontroller "librarymvc/internal/loans/controllers"
+	loanmodel "librarymvc/internal/loans/models"
 	loanrepository "librarymvc/internal/loans/repositories"
 	loanservice "librarymvc/internal/loans/services"
 
+	policycontroller "librarymvc/internal/policies/controllers"
+	policymodel "librarymvc/internal/policies/models"
+	policyrepository "librarymvc/internal/policies/repositories"
+	policyservice "librarymvc/internal/policies/services"
+
+	holdcontroller "librarymvc/internal/holds/controllers"
+	holdmodel "librarymvc/internal/holds/models"
+	holdrepository "librarymvc/internal/holds/repositories"
+	holdservice "librarymvc/internal/holds/services"
+
+	searchcontroller "librarymvc/internal/search/controllers"
+	searchrepository "librarymvc/internal/search/repositories"
+	searchservice "librarymvc/internal/search/services"
+
 	webcontroller "librarymvc/web/controller"
 )
 
 func main() {
-	router := gin.Default()
-
 	// Initialize repositories
-	bookRepo := bookrepository.NewBookRepository()
-	userRepo := userrepository.NewUserRepository()
-	loanRepo := loanrepository.NewLoanRepository()
+	// STORAGE_DRIVER=memory keeps everything in memory (useful for tests);
+	// the default is a SQLite file at DATABASE_PATH.
+	var (
+		bookRepo     bookmodel.BookRepository
+		authorRepo   bookmodel.AuthorRepository
+		subjectRepo  bookmodel.SubjectRepository
+		copyRepo     copymodel.CopyRepository
+		userRepo     usermodel.UserRepository
+		loanRepo     loanmodel.LoanRepository
+		policyRepo   policymodel.LoanPolicyRepository
+		holdRepo     holdmodel.HoldRepository
+		txRepo       accountmodel.TransactionRepository
+		calendarRepo calendarmodel.CalendarRepository
+		credRepo     authmodel.CredentialRepository
+		sessionRepo  authmodel.SessionRepository
+		tokenRepo    authmodel.TokenRepository
+		uow          unitofwork.UnitOfWork
+	)
+	clk := clock.New()
+
+	switch getEnv("STORAGE_DRIVER", "sqlite") {
+	case "memory":
+		bookRepo = bookrepository.NewBookRepository()
+		authorRepo = bookrepository.NewAuthorRepository()
+		subjectRepo = bookrepository.NewSubjectRepository()
+		copyRepo = copyrepository.NewCopyRepository(clk)
+		userRepo = userrepository.NewUserRepository()
+		loanRepo = loanrepository.NewLoanRepository()
+		policyRepo = policyrepository.NewLoanPolicyRepository()
+		holdRepo = holdrepository.NewHoldRepository()
+		txRepo = accountrepository.NewTransactionRepository()
+		calendarRepo = calendarrepository.NewCalendarRepository()
+		credRepo = authrepository.NewCredentialRepository()
+		sessionRepo = authrepository.NewSessionRepository()
+		tokenRepo = authrepository.NewTokenRepository()
+		uow = unitofwork.NewMemoryUnitOfWork(unitofwork.Repositories{
+			Books:        bookRepo,
+			Authors:      authorRepo,
+			Subjects:     subjectRepo,
+			Copies:       copyRepo,
+			Users:        userRepo,
+			Loans:        loanRepo,
+			Policies:     policyRepo,
+			Holds:        holdRepo,
+			Transactions: txRepo,
+			Calendar:     calendarRepo,
+		})
+	case "sqlite":
+		db, err := database.Open(getEnv("DATABASE_PATH", "library.db"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer closeDB(db)
+
+		bookRepo = bookrepository.NewSQLiteBookRepository(db)
+		authorRepo = bookrepository.NewSQLiteAuthorRepository(db)
+		subjectRepo = bookrepository.NewSQLiteSubjectRepository(db)
+		copyRepo = copyrepository.NewSQLiteCopyRepository(db, clk)
+		userRepo = userrepository.NewSQLiteUserRepository(db)
+		loanRepo = loanrepository.NewSQLiteLoanRepository(db)
+		policyRepo = policyrepository.NewSQLiteLoanPolicyRepository(db)
+		holdRepo = holdrepository.NewSQLiteHoldRepository(db)
+		txRepo = accountrepository.NewSQLiteTransactionRepository(db)
+		calendarRepo = calendarrepository.NewSQLiteCalendarRepository(db)
+		credRepo = authrepository.NewSQLiteCredentialRepository(db)
+		sessionRepo = authrepository.NewSQLiteSessionRepository(db)
+		tokenRepo = authrepository.NewSQLiteTokenRepository(db)
+		uow = unitofwork.NewSQLiteUnitOfWork(db, clk)
+	default:
+		log.Fatalf("unknown STORAGE_DRIVER %q (expected sqlite or memory)", os.Getenv("STORAGE_DRIVER"))
+	}
 
 	// Initialize services
-	bookSvc := bookservice.NewBookService(bookRepo)
-	userSvc := userservice.NewUserService(userRepo)
-	loanSvc := loanservice.NewLoanService(loanRepo, bookSvc, userSvc)
+	policySvc := policyservice.NewLoanPolicyService(policyRepo, bookRepo, clk)
+	if err := policySvc.EnsureDefaultPolicies(); err != nil {
+		log.Fatal(err)
+	}
+
+	calendarSvc := calendarservice.NewCalendarService(calendarRepo, clk)
+	if err := calendarSvc.EnsureDefaultHours(); err != nil {
+		log.Fatal(err)
+	}
+
+	// The search index lives in memory with either driver and is rebuilt
+	// from the catalog on every start.
+	searchIndex := searchrepository.NewSearchIndex()
+	bookSvc := bookservice.NewBookService(bookRepo, authorRepo, subjectRepo, copyRepo, policyRepo, searchIndex, uow, clk)
+	searchSvc := searchservice.NewSearchService(searchIndex, bookRepo, bookSvc)
+	if err := searchSvc.Rebuild(); err != nil {
+		log.Fatal(err)
+	}
+	copySvc := copyservice.NewCopyService(copyRepo, bookRepo, uow, clk)
+	userSvc := userservice.NewUserService(userRepo, clk)
+	loanLimit, err := strconv.Atoi(getEnv("LOAN_LIMIT", strconv.Itoa(loanmodel.DefaultLoanLimit)))
+	if err != nil {
+		log.Fatalf("invalid LOAN_LIMIT: %v", err)
+	}
+	fineThreshold, err := money.Parse(getEnv("FINE_THRESHOLD", loanmodel.DefaultFineThreshold.String()))
+	if err != nil {
+		log.Fatalf("invalid FINE_THRESHOLD: %v", err)
+	}
+	loanSvc := loanservice.NewLoanService(loanRepo, uow, loanLimit, fineThreshold, clk)
+	holdSvc := holdservice.NewHoldService(holdRepo, uow, clk)
+	accountSvc := accountservice.NewAccountService(txRepo, uow, clk)
+	catalogSvc := catalogservice.NewCatalogService(bookSvc)
+
+	// Web sessions last SESSION_TTL and API tokens API_TOKEN_TTL. Users start
+	// as patrons; ADMIN_EMAIL and ADMIN_PASSWORD name the one administrator
+	// who can hand out the other roles.
+	sessionTTL, err := time.ParseDuration(getEnv("SESSION_TTL", "12h"))
+	if err != nil {
+		log.Fatalf("invalid SESSION_TTL: %v", err)
+	}
+	tokenTTL, err := time.ParseDuration(getEnv("API_TOKEN_TTL", "720h"))
+	if err != nil {
+		log.Fatalf("invalid API_TOKEN_TTL: %v", err)
+	}
+	authSvc := authservice.NewAuthService(userRepo, credRepo, sessionRepo, tokenRepo, sessionTTL, tokenTTL, clk)
+	if email, password := os.Getenv("ADMIN_EMAIL"), os.Getenv("ADMIN_PASSWORD"); email != "" && password != "" {
+		if err := authSvc.EnsureAdmin(email, password); err != nil {
+			log.Fatalf("creating admin %s: %v", email, err)
+		}
+	}
+
+	// "api catalog import|export" runs the MARC import or export instead of
+	// the server
+	if len(os.Args) > 1 && os.Args[1] == "catalog" {
+		if err := runCatalog(catalogSvc, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Background jobs: overdue loans, hold pickup deadlines, lapsed
+	// memberships and expired sessions.
+	// SCHEDULER_INTERVAL=0 disables them.
+	interval, err := time.ParseDuration(getEnv("SCHEDULER_INTERVAL", "15m"))
+	if err != nil {
+		log.Fatalf("invalid SCHEDULER_INTERVAL: %v", err)
+	}
+	if interval > 0 {
+		jobs := scheduler.NewScheduler(interval)
+		jobs.Add("mark-overdue-loans", func() error {
+			marked, err := loanSvc.MarkOverdueLoans()
+			if marked > 0 {
+				log.Printf("scheduler: %d loans marked overdue", marked)
+			}
+			return err
+		})
+		jobs.Add("process-holds", func() error {
+			_, err := holdSvc.ProcessHolds()
+			return err
+		})
+		jobs.Add("expire-memberships", func() error {
+			expired, err := userSvc.ExpireMemberships()
+			if expired > 0 {
+				log.Printf("scheduler: %d memberships expired", expired)
+			}
+			return err
+		})
+		jobs.Add("purge-expired-sessions", func() error {
+			_, err := authSvc.PurgeExpired()
+			return err
+		})
+		stopJobs := jobs.Start()
+		defer stopJobs()
+	}
+
+	// SESSION_SECRET signs the web forms' CSRF tokens and encrypts the flash
+	// messages. Without it a random one is made, so forms left open and
+	// messages pending when the server restarts are lost.
+	secret := []byte(os.Getenv("SESSION_SECRET"))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatal(err)
+		}
+	} else if len(secret) < 32 {
+		log.Fatal("SESSION_SECRET must have at least 32 characters")
+	}
+
+	router := gin.Default()
 
 	// Initialize Web controller
-	webController := webcontroller.NewWebController(bookSvc, userSvc, loanSvc)
+	webController := webcontroller.NewWebController(bookSvc, userSvc, loanSvc, copySvc, policySvc, holdSvc, accountSvc, calendarSvc, searchSvc, authSvc, secret, clk)
 
 	// Register Web routes first (they have priority)
 	webController.RegisterRoutes(router)
 
 	// Initialize API controllers
 	booksController := bookcontroller.NewBooksController(bookSvc)
+	copiesController := copycontroller.NewCopyController(copySvc)
 	usersController := usercontroller.NewUserController(userSvc)
 	loansController := loancontroller.NewLoanController(loanSvc)
+	policiesController := policycontroller.NewLoanPolicyController(policySvc)
+	holdsController := holdcontroller.NewHoldController(holdSvc)
+	accountsController := accountcontroller.NewAccountController(accountSvc)
+	calendarController := calendarcontroller.NewCalendarController(calendarSvc)
+	searchController := searchcontroller.NewSearchController(searchSvc)
+	catalogController := catalogcontroller.NewCatalogController(catalogSvc)
+	authController := authcontroller.NewAuthController(authSvc)
 
 	// Register API routes with /api prefix
-	api := router.Group("/api")
+	// API errors are reported by the error middleware as a JSON envelope.
+	// Clients authenticate with a bearer token; anyone may read the catalog,
+	// but changes need a token and the role permission noted on the route.
+	can := authcontroller.Require
+	selfOr := authcontroller.RequireSelfOr
+	signedIn := authcontroller.RequireUser()
+	apiPublic := router.Group("/api", apperror.Middleware())
+	apiPublic.POST("/auth/tokens", authController.CreateToken)
+
+	api := router.Group("/api", apperror.Middleware(), authcontroller.BearerAuth(authSvc), authcontroller.RequireUserForChanges())
+	apiAuth := api.Group("/auth", signedIn)
+	{
+		apiAuth.GET("/me", authController.Me)
+		apiAuth.GET("/tokens", authController.GetTokens)
+		apiAuth.DELETE("/tokens/:id", authController.RevokeToken)
+	}
+
 	apiBooks := api.Group("/books")
 	{
 		apiBooks.GET("/", booksController.GetAllBooks)
+		apiBooks.GET("/search", searchController.SearchBooks)
+		apiBooks.GET("/isbn/:isbn", booksController.GetBookByISBN)
+		apiBooks.POST("/:id/merge", can(authmodel.ManageCatalog), booksController.MergeBooks)
 		apiBooks.GET("/:id", booksController.GetBook)
-		apiBooks.POST("", booksController.CreateBook)
-		apiBooks.PUT("/:id", booksController.UpdateBook)
-		apiBooks.DELETE("/:id", booksController.DeleteBook)
+		apiBooks.POST("", can(authmodel.ManageCatalog), booksController.CreateBook)
+		apiBooks.PUT("/:id", can(authmodel.ManageCatalog), booksController.UpdateBook)
+		apiBooks.DELETE("/:id", can(authmodel.DeleteBooks), booksController.DeleteBook)
+		apiBooks.GET("/:id/copies", copiesController.GetBookCopies)
+		apiBooks.GET("/:id/holds", can(authmodel.ViewCirculation), holdsController.GetBookHolds)
+	}
+
+	apiAuthors := api.Group("/authors")
+	{
+		apiAuthors.GET("", booksController.GetAllAuthors)
+		apiAuthors.GET("/:id", booksController.GetAuthor)
+		apiAuthors.GET("/:id/books", booksController.GetAuthorBooks)
+	}
+
+	apiSubjects := api.Group("/subjects")
+	{
+		apiSubjects.GET("", booksController.GetAllSubjects)
+		apiSubjects.GET("/:id", booksController.GetSubject)
+		apiSubjects.GET("/:id/books", booksController.GetSubjectBooks)
+	}
+
+	apiCopies := api.Group("/copies")
+	{
+		apiCopies.GET("", copiesController.GetAllCopies)
+		apiCopies.GET("/:id", copiesController.GetCopy)
+		apiCopies.GET("/barcode/:barcode", copiesController.GetCopyByBarcode)
+		apiCopies.POST("", can(authmodel.ManageCatalog), copiesController.CreateCopy)
+		apiCopies.PUT("/:id", can(authmodel.ManageCatalog), copiesController.UpdateCopy)
+		apiCopies.DELETE("/:id", can(authmodel.ManageCatalog), copiesController.DeleteCopy)
 	}
 
 	apiUsers := api.Group("/users")
 	{
-		apiUsers.GET("/", usersController.GetAllUsers)
-		apiUsers.GET("/:id", usersController.GetUser)
-		apiUsers.POST("", usersController.CreateUser)
-		apiUsers.PUT("/:id", usersController.UpdateUser)
-		apiUsers.DELETE("/:id", usersController.DeleteUser)
+		apiUsers.GET("/", can(authmodel.ViewUsers), usersController.GetAllUsers)
+		apiUsers.GET("/categories", signedIn, usersController.GetCategories)
+		apiUsers.GET("/:id", selfOr("id", authmodel.ViewUsers), usersController.GetUser)
+		apiUsers.POST("", can(authmodel.ManageUsers), usersController.CreateUser)
+		apiUsers.PUT("/:id", can(authmodel.ManageUsers), usersController.UpdateUser)
+		apiUsers.DELETE("/:id", can(authmodel.DeleteUsers), usersController.DeleteUser)
+		apiUsers.POST("/:id/membership/renew", can(authmodel.ManageUsers), usersController.RenewMembership)
+		apiUsers.POST("/:id/membership/suspend", can(authmodel.ManageUsers), usersController.SuspendMembership)
+		apiUsers.POST("/:id/membership/reinstate", can(authmodel.ManageUsers), usersController.ReinstateMembership)
+		apiUsers.PUT("/:id/password", selfOr("id", authmodel.SetPasswords), authController.SetPassword)
+		apiUsers.GET("/:id/account", selfOr("id", authmodel.ViewCirculation), accountsController.GetAccount)
+		apiUsers.POST("/:id/account/charges", can(authmodel.ManageAccounts), accountsController.Charge)
+		apiUsers.POST("/:id/account/payments", can(authmodel.ManageAccounts), accountsController.Pay)
+		apiUsers.POST("/:id/account/waivers", can(authmodel.ManageAccounts), accountsController.Waive)
 	}
 
 	apiLoans := api.Group("/loans")
 	{
-		apiLoans.POST("", loansController.CreateLoan)
-		apiLoans.GET("/:id", loansController.GetLoan)
-		apiLoans.GET("", loansController.GetAllLoans)
-		apiLoans.PUT("/:id/return", loansController.ReturnBook)
+		apiLoans.POST("", can(authmodel.Circulate), loansController.CreateLoan)
+		apiLoans.GET("/:id", signedIn, loansController.GetLoan)
+		apiLoans.GET("", can(authmodel.ViewCirculation), loansController.GetAllLoans)
+		apiLoans.PUT("/:id/return", can(authmodel.Circulate), loansController.ReturnBook)
+		apiLoans.PUT("/:id/renew", can(authmodel.Circulate), loansController.RenewLoan)
+	}
+
+	apiPolicies := api.Group("/policies")
+	{
+		apiPolicies.GET("", policiesController.GetAllPolicies)
+		apiPolicies.GET("/:id", policiesController.GetPolicy)
+		apiPolicies.POST("", can(authmodel.ManagePolicies), policiesController.CreatePolicy)
+		apiPolicies.PUT("/:id", can(authmodel.ManagePolicies), policiesController.UpdatePolicy)
+		apiPolicies.DELETE("/:id", can(authmodel.ManagePolicies), policiesController.DeletePolicy)
 	}
 
 	apiLoansUsers := api.Group("/loans/users")
 	{
-		apiLoansUsers.GET("/:userId/loans", loansController.GetUserLoans)
+		apiLoansUsers.GET("/:userId/loans", selfOr("userId", authmodel.ViewCirculation), loansController.GetUserLoans)
+	}
+
+	apiHolds := api.Group("/holds")
+	{
+		apiHolds.POST("", can(authmodel.PlaceHolds), holdsController.PlaceHold)
+		apiHolds.GET("", can(authmodel.ViewCirculation), holdsController.GetAllHolds)
+		apiHolds.GET("/:id", signedIn, holdsController.GetHold)
+		apiHolds.PUT("/:id/cancel", signedIn, holdsController.CancelHold)
+		apiHolds.POST("/process", can(authmodel.Circulate), holdsController.ProcessHolds)
+	}
+
+	apiHoldsUsers := api.Group("/holds/users")
+	{
+		apiHoldsUsers.GET("/:userId/holds", selfOr("userId", authmodel.ViewCirculation), holdsController.GetUserHolds)
+	}
+
+	apiCatalog := api.Group("/catalog")
+	{
+		apiCatalog.POST("/import", can(authmodel.ManageCatalog), catalogController.Import)
+		apiCatalog.GET("/export", catalogController.Export)
+	}
+
+	apiCalendar := api.Group("/calendar")
+	{
+		apiCalendar.GET("", calendarController.GetCalendar)
+		apiCalendar.PUT("/hours/:weekday", can(authmodel.ManageCalendar), calendarController.UpdateOpeningHours)
+		apiCalendar.POST("/closures", can(authmodel.ManageCalendar), calendarController.CreateClosure)
+		apiCalendar.DELETE("/closures/:id", can(authmodel.ManageCalendar), calendarController.DeleteClosure)
 	}
 
 	if err := router.Run(); err != nil {
 		log.Fatal(err)
 	}
 }
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func closeDB(db *sql.DB) {
+	if err := db.Close(); err != nil {
+		log.Println("error closing database:", err)
+	}
+}
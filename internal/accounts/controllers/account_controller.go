@@ -0,0 +1,95 @@
+package accounts
+
+import (
+	"librarymvc/internal/accounts/models"
+	"librarymvc/internal/apperror"
+	"librarymvc/internal/money"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountController struct {
+	accountService models.AccountService
+}
+
+func NewAccountController(accountService models.AccountService) *AccountController {
+	return &AccountController{accountService: accountService}
+}
+
+func (a *AccountController) RegisterRoutes(r *gin.Engine) {
+	users := r.Group("/users")
+	{
+		users.GET("/:id/account", a.GetAccount)
+		users.POST("/:id/account/charges", a.Charge)
+		users.POST("/:id/account/payments", a.Pay)
+		users.POST("/:id/account/waivers", a.Waive)
+	}
+}
+
+type transactionRequest struct {
+	Reason string      `json:"reason"` // somente cobranças: overdue, lost, damaged, other
+	Amount money.Money `json:"amount"`
+	Note   string      `json:"note"`
+}
+
+func (a *AccountController) GetAccount(ctx *gin.Context) {
+	userID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("user"))
+		return
+	}
+
+	account, err := a.accountService.GetAccount(userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+func (a *AccountController) Charge(ctx *gin.Context) {
+	a.post(ctx, func(userID int64, request transactionRequest) (*models.Transaction, error) {
+		return a.accountService.Charge(userID, request.Reason, request.Amount, request.Note)
+	})
+}
+
+func (a *AccountController) Pay(ctx *gin.Context) {
+	a.post(ctx, func(userID int64, request transactionRequest) (*models.Transaction, error) {
+		return a.accountService.Pay(userID, request.Amount, request.Note)
+	})
+}
+
+func (a *AccountController) Waive(ctx *gin.Context) {
+	a.post(ctx, func(userID int64, request transactionRequest) (*models.Transaction, error) {
+		return a.accountService.Waive(userID, request.Amount, request.Note)
+	})
+}
+
+// post binds the request shared by charges, payments and waivers.
+func (a *AccountController) post(
+	ctx *gin.Context,
+	record func(userID int64, request transactionRequest) (*models.Transaction, error),
+) {
+	userID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("user"))
+		return
+	}
+
+	var request transactionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	transaction, err := record(userID, request)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, transaction)
+}
@@ -0,0 +1,13 @@
+package models
+
+import "librarymvc/internal/money"
+
+type AccountService interface {
+	GetAccount(userID int64) (*Account, error)
+	GetBalance(userID int64) (money.Money, error)
+	// Charge posts a fee to the member's account; reason is overdue, lost,
+	// damaged or other.
+	Charge(userID int64, reason string, amount money.Money, note string) (*Transaction, error)
+	Pay(userID int64, amount money.Money, note string) (*Transaction, error)
+	Waive(userID int64, amount money.Money, note string) (*Transaction, error)
+}
@@ -0,0 +1,9 @@
+package models
+
+import "librarymvc/internal/apperror"
+
+var (
+	ErrAmountNotPositive    = apperror.NewValidation("amount_not_positive", "amount must be positive")
+	ErrInvalidChargeReason  = apperror.NewValidation("invalid_charge_reason", "invalid charge reason")
+	ErrAmountExceedsBalance = apperror.NewValidation("amount_exceeds_balance", "amount exceeds outstanding balance")
+)
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"librarymvc/internal/money"
+	"time"
+)
+
+// Transaction is one entry in a member's fines ledger. Amount is always
+// positive; Type decides whether it adds to or settles the balance.
+type Transaction struct {
+	ID        int64       `json:"ID"`
+	UserID    int64       `json:"userID"`
+	LoanID    int64       `json:"loanID"` // Empréstimo que originou a cobrança (0 se avulsa)
+	Type      string      `json:"type"`   // charge, payment, waiver
+	Reason    string      `json:"reason"` // overdue, lost, damaged, other (somente cobranças)
+	Amount    money.Money `json:"amount"`
+	Note      string      `json:"note"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// Signed is the transaction's effect on the balance: charges raise what the
+// member owes, payments and waivers lower it.
+func (t *Transaction) Signed() money.Money {
+	if t.Type == "charge" {
+		return t.Amount
+	}
+	return -t.Amount
+}
+
+// Account is a member's ledger with the balance they owe.
+type Account struct {
+	UserID       int64          `json:"userID"`
+	Balance      money.Money    `json:"balance"`
+	Transactions []*Transaction `json:"transactions"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "librarymvc/internal/money"
+
+type TransactionRepository interface {
+	CreateTransaction(transaction *Transaction) error
+	// GetUserTransactions returns the user's ledger, oldest first.
+	GetUserTransactions(userID int64) ([]*Transaction, error)
+	// GetUserBalance returns what the user owes: charges minus payments and waivers.
+	GetUserBalance(userID int64) (money.Money, error)
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"librarymvc/internal/accounts/models"
+	"librarymvc/internal/money"
+	"sort"
+	"sync"
+)
+
+type TransactionRepository struct {
+	transactions map[int64]*models.Transaction
+	mu           sync.RWMutex
+	nextID       int64
+}
+
+func NewTransactionRepository() models.TransactionRepository {
+	return &TransactionRepository{
+		transactions: make(map[int64]*models.Transaction),
+		nextID:       1,
+	}
+}
+
+func (t *TransactionRepository) CreateTransaction(transaction *models.Transaction) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transaction.ID = t.nextID
+	t.transactions[transaction.ID] = transaction
+	t.nextID++
+
+	return nil
+}
+
+func (t *TransactionRepository) GetUserTransactions(userID int64) ([]*models.Transaction, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	transactions := make([]*models.Transaction, 0)
+	for _, transaction := range t.transactions {
+		if transaction.UserID == userID {
+			transactions = append(transactions, transaction)
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool { return transactions[i].ID < transactions[j].ID })
+
+	return transactions, nil
+}
+
+func (t *TransactionRepository) GetUserBalance(userID int64) (money.Money, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var balance money.Money
+	for _, transaction := range t.transactions {
+		if transaction.UserID == userID {
+			balance += transaction.Signed()
+		}
+	}
+
+	return balance, nil
+}
+
+// Snapshot copies the current state and returns a function that restores it.
+// It is used by the in-memory unit of work to roll back failed transactions.
+func (t *TransactionRepository) Snapshot() func() {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	transactions := make(map[int64]models.Transaction, len(t.transactions))
+	for id, transaction := range t.transactions {
+		transactions[id] = *transaction
+	}
+	nextID := t.nextID
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		t.transactions = make(map[int64]*models.Transaction, len(transactions))
+		for id, transaction := range transactions {
+			transaction := transaction
+			t.transactions[id] = &transaction
+		}
+		t.nextID = nextID
+	}
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"librarymvc/internal/accounts/models"
+	"librarymvc/internal/database"
+	"librarymvc/internal/money"
+)
+
+type SQLiteTransactionRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteTransactionRepository(db database.DBTX) models.TransactionRepository {
+	return &SQLiteTransactionRepository{db: db}
+}
+
+const transactionColumns = `id, user_id, loan_id, type, reason, amount, note, created_at`
+
+func scanTransaction(row interface{ Scan(...any) error }) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := row.Scan(
+		&transaction.ID,
+		&transaction.UserID,
+		&transaction.LoanID,
+		&transaction.Type,
+		&transaction.Reason,
+		&transaction.Amount,
+		&transaction.Note,
+		&transaction.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (t *SQLiteTransactionRepository) CreateTransaction(transaction *models.Transaction) error {
+	result, err := t.db.Exec(
+		`INSERT INTO account_transactions (user_id, loan_id, type, reason, amount, note, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		transaction.UserID, transaction.LoanID, transaction.Type, transaction.Reason,
+		transaction.Amount, transaction.Note, transaction.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	transaction.ID = id
+
+	return nil
+}
+
+func (t *SQLiteTransactionRepository) GetUserTransactions(userID int64) ([]*models.Transaction, error) {
+	rows, err := t.db.Query(
+		`SELECT `+transactionColumns+` FROM account_transactions WHERE user_id = ? ORDER BY id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]*models.Transaction, 0)
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
+func (t *SQLiteTransactionRepository) GetUserBalance(userID int64) (money.Money, error) {
+	var balance money.Money
+	err := t.db.QueryRow(
+		`SELECT COALESCE(SUM(CASE type WHEN 'charge' THEN amount ELSE -amount END), 0)
+		 FROM account_transactions WHERE user_id = ?`,
+		userID,
+	).Scan(&balance)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"librarymvc/internal/accounts/models"
+	"librarymvc/internal/clock"
+	"librarymvc/internal/money"
+	"librarymvc/internal/unitofwork"
+	"strings"
+)
+
+type AccountService struct {
+	transactionRepository models.TransactionRepository
+	unitOfWork            unitofwork.UnitOfWork
+	clock                 clock.Clock
+}
+
+func NewAccountService(
+	transactionRepository models.TransactionRepository,
+	unitOfWork unitofwork.UnitOfWork,
+	clock clock.Clock,
+) models.AccountService {
+	return &AccountService{
+		transactionRepository: transactionRepository,
+		unitOfWork:            unitOfWork,
+		clock:                 clock,
+	}
+}
+
+func (a *AccountService) GetAccount(userID int64) (*models.Account, error) {
+	var account *models.Account
+
+	err := a.unitOfWork.Read(func(repos *unitofwork.Repositories) error {
+		if _, err := repos.Users.GetUser(userID); err != nil {
+			return err
+		}
+
+		transactions, err := repos.Transactions.GetUserTransactions(userID)
+		if err != nil {
+			return err
+		}
+
+		balance, err := repos.Transactions.GetUserBalance(userID)
+		if err != nil {
+			return err
+		}
+
+		account = &models.Account{
+			UserID:       userID,
+			Balance:      balance,
+			Transactions: transactions,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (a *AccountService) GetBalance(userID int64) (money.Money, error) {
+	return a.transactionRepository.GetUserBalance(userID)
+}
+
+func (a *AccountService) Charge(userID int64, reason string, amount money.Money, note string) (*models.Transaction, error) {
+	switch reason {
+	case "overdue", "lost", "damaged", "other":
+	default:
+		return nil, models.ErrInvalidChargeReason
+	}
+
+	return a.post(&models.Transaction{
+		UserID: userID,
+		Type:   "charge",
+		Reason: reason,
+		Amount: amount,
+		Note:   note,
+	})
+}
+
+func (a *AccountService) Pay(userID int64, amount money.Money, note string) (*models.Transaction, error) {
+	return a.post(&models.Transaction{
+		UserID: userID,
+		Type:   "payment",
+		Amount: amount,
+		Note:   note,
+	})
+}
+
+func (a *AccountService) Waive(userID int64, amount money.Money, note string) (*models.Transaction, error) {
+	return a.post(&models.Transaction{
+		UserID: userID,
+		Type:   "waiver",
+		Amount: amount,
+		Note:   note,
+	})
+}
+
+// post records a transaction for an existing member. Payments and waivers
+// can only settle what is owed, never leave the member in credit.
+func (a *AccountService) post(transaction *models.Transaction) (*models.Transaction, error) {
+	if transaction.Amount <= 0 {
+		return nil, models.ErrAmountNotPositive
+	}
+	transaction.Note = strings.TrimSpace(transaction.Note)
+
+	err := a.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		if _, err := repos.Users.GetUser(transaction.UserID); err != nil {
+			return err
+		}
+
+		if transaction.Type != "charge" {
+			balance, err := repos.Transactions.GetUserBalance(transaction.UserID)
+			if err != nil {
+				return err
+			}
+			if transaction.Amount > balance {
+				return models.ErrAmountExceedsBalance
+			}
+		}
+
+		transaction.CreatedAt = a.clock.Now()
+		return repos.Transactions.CreateTransaction(transaction)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
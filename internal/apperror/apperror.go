@@ -0,0 +1,94 @@
+package apperror
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Kind classifies an error by how it is reported to API clients.
+type Kind int
+
+const (
+	// Internal is any failure the client cannot fix (500).
+	Internal Kind = iota
+	// BadRequest is a malformed request, e.g. an unparsable body or ID (400).
+	BadRequest
+	// Validation is well-formed input that breaks a rule (422).
+	Validation
+	// NotFound is a missing resource (404).
+	NotFound
+	// Conflict is an operation the current state of a resource does not
+	// allow, e.g. a duplicate or a copy that is already on loan (409).
+	Conflict
+	// Forbidden is an operation the member is not allowed to do (403).
+	Forbidden
+	// Unauthorized is a request without valid credentials (401).
+	Unauthorized
+)
+
+// Error is a domain error with a machine-readable code. Models packages
+// declare them as sentinels; errors.Is matches by code, so a sentinel
+// given a more specific message with WithMessage still matches.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NewBadRequest(code, message string) *Error   { return New(BadRequest, code, message) }
+func NewValidation(code, message string) *Error   { return New(Validation, code, message) }
+func NewNotFound(code, message string) *Error     { return New(NotFound, code, message) }
+func NewConflict(code, message string) *Error     { return New(Conflict, code, message) }
+func NewForbidden(code, message string) *Error    { return New(Forbidden, code, message) }
+func NewUnauthorized(code, message string) *Error { return New(Unauthorized, code, message) }
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns the same error with a more specific message.
+func (e *Error) WithMessage(message string) *Error {
+	return &Error{Kind: e.Kind, Code: e.Code, Message: message}
+}
+
+// KindOf is the kind of the first Error in err's chain, or Internal.
+func KindOf(err error) Kind {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	return Internal
+}
+
+// ErrInvalidRequestBody is returned when the body cannot be bound.
+var ErrInvalidRequestBody = NewBadRequest("invalid_request_body", "Invalid request body")
+
+// ErrValidationFailed is returned when a bound body breaks its binding rules.
+var ErrValidationFailed = NewValidation("validation_failed", "validation failed")
+
+// InvalidBody classifies a binding error: a body that failed its binding
+// rules is a validation error, anything else (bad JSON, wrong types) is a
+// bad request. The detail is kept in the message.
+func InvalidBody(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return ErrValidationFailed.WithMessage("validation failed: " + err.Error())
+	}
+	return ErrInvalidRequestBody.WithMessage("Invalid request body: " + err.Error())
+}
+
+// InvalidID is returned when a path parameter is not a valid ID, e.g.
+// InvalidID("book") for "Invalid book ID".
+func InvalidID(resource string) *Error {
+	return NewBadRequest("invalid_id", "Invalid "+resource+" ID")
+}
@@ -0,0 +1,60 @@
+package apperror
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// detailer is implemented by typed errors that carry extra fields for the
+// client, such as the limit that was reached.
+type detailer interface {
+	Details() map[string]any
+}
+
+var statuses = map[Kind]int{
+	Internal:     http.StatusInternalServerError,
+	BadRequest:   http.StatusBadRequest,
+	Validation:   http.StatusUnprocessableEntity,
+	NotFound:     http.StatusNotFound,
+	Conflict:     http.StatusConflict,
+	Forbidden:    http.StatusForbidden,
+	Unauthorized: http.StatusUnauthorized,
+}
+
+// Middleware reports the last error a handler attached with ctx.Error as
+//
+//	{"error": {"code": "book_not_found", "message": "book not found"}}
+//
+// with the status of its kind. Errors without a kind are logged and
+// answered with a generic 500 so internals don't leak to clients.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+		err := ctx.Errors.Last().Err
+
+		body := gin.H{}
+		var appErr *Error
+		if errors.As(err, &appErr) {
+			body["code"] = appErr.Code
+			body["message"] = err.Error()
+		} else {
+			log.Printf("%s %s: %v", ctx.Request.Method, ctx.Request.URL.Path, err)
+			body["code"] = "internal_error"
+			body["message"] = "internal server error"
+		}
+
+		var withDetails detailer
+		if errors.As(err, &withDetails) {
+			body["details"] = withDetails.Details()
+		}
+
+		ctx.JSON(statuses[KindOf(err)], gin.H{"error": body})
+	}
+}
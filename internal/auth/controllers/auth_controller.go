@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"librarymvc/internal/apperror"
+	"librarymvc/internal/auth/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthController struct {
+	authService models.AuthService
+}
+
+func NewAuthController(authService models.AuthService) *AuthController {
+	return &AuthController{authService: authService}
+}
+
+type tokenRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Name     string `json:"name"` // identifica o cliente, ex.: "script de inventário"
+}
+
+type passwordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// CreateToken exchanges an email and password for an API token. The token
+// is only ever shown in this response.
+func (a *AuthController) CreateToken(ctx *gin.Context) {
+	var request tokenRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	token, err := a.authService.CreateToken(request.Email, request.Password, request.Name)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, token)
+}
+
+// GetTokens lists the API tokens of the authenticated user.
+func (a *AuthController) GetTokens(ctx *gin.Context) {
+	tokens, err := a.authService.GetUserTokens(CurrentUser(ctx).ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// RevokeToken deletes one of the authenticated user's API tokens.
+func (a *AuthController) RevokeToken(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("token"))
+		return
+	}
+
+	if err := a.authService.RevokeToken(CurrentUser(ctx).ID, id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, nil)
+}
+
+// Me is the authenticated user.
+func (a *AuthController) Me(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, CurrentUser(ctx))
+}
+
+func (a *AuthController) SetPassword(ctx *gin.Context) {
+	userID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("user"))
+		return
+	}
+
+	var request passwordRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	if err := a.authService.SetPassword(userID, request.Password); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"librarymvc/internal/auth/models"
+	userModel "librarymvc/internal/users/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserKey is where the middleware keeps the authenticated user in
+// the Gin context.
+const currentUserKey = "currentUser"
+
+// SetCurrentUser records the user the request is made by.
+func SetCurrentUser(ctx *gin.Context, user *userModel.User) {
+	ctx.Set(currentUserKey, user)
+}
+
+// CurrentUser is the user the request is made by, or nil when the request
+// is anonymous.
+func CurrentUser(ctx *gin.Context) *userModel.User {
+	user, _ := ctx.Get(currentUserKey)
+	current, _ := user.(*userModel.User)
+	return current
+}
+
+// BearerAuth authenticates API requests sent with
+// "Authorization: Bearer <token>". Requests without the header go on
+// anonymously; a token that is unknown or expired is rejected.
+func BearerAuth(authService models.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		if header == "" {
+			ctx.Next()
+			return
+		}
+
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			ctx.Error(models.ErrInvalidToken)
+			ctx.Abort()
+			return
+		}
+
+		user, err := authService.TokenUser(strings.TrimSpace(token))
+		if err != nil {
+			ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+
+		SetCurrentUser(ctx, user)
+		ctx.Next()
+	}
+}
+
+// RequireUser rejects anonymous requests.
+func RequireUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if CurrentUser(ctx) == nil {
+			ctx.Error(models.ErrUnauthenticated)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequireUserForChanges rejects anonymous requests that change something,
+// leaving reads open.
+func RequireUserForChanges() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !SafeMethod(ctx.Request.Method) && CurrentUser(ctx) == nil {
+			ctx.Error(models.ErrUnauthenticated)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// Check tells whether the user making the request has the permission:
+// nil if so, ErrUnauthenticated for anonymous requests and ErrForbidden
+// when their role does not grant it.
+func Check(ctx *gin.Context, permission models.Permission) error {
+	user := CurrentUser(ctx)
+	if user == nil {
+		return models.ErrUnauthenticated
+	}
+	if !models.Can(user.Role, permission) {
+		return models.ErrForbidden
+	}
+	return nil
+}
+
+// CheckSelfOr is Check for a record of the user with userID: users may
+// always act on their own records.
+func CheckSelfOr(ctx *gin.Context, userID int64, permission models.Permission) error {
+	if user := CurrentUser(ctx); user != nil && user.ID == userID {
+		return nil
+	}
+	return Check(ctx, permission)
+}
+
+// Require lets through the users whose role grants the permission.
+func Require(permission models.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := Check(ctx, permission); err != nil {
+			ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequireSelfOr lets users through to their own records, whose user ID is
+// the route parameter param, and to everyone else's with the permission.
+func RequireSelfOr(param string, permission models.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := CheckSelfOr(ctx, ParamID(ctx, param), permission); err != nil {
+			ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// ParamID is the ID in the route parameter param, or 0 when it is not a
+// number.
+func ParamID(ctx *gin.Context, param string) int64 {
+	id, _ := strconv.ParseInt(ctx.Param(param), 10, 64)
+	return id
+}
+
+// SafeMethod reports whether requests with the method only read.
+func SafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"librarymvc/internal/apperror"
+	"librarymvc/internal/auth/models"
+	"librarymvc/internal/auth/services"
+	"librarymvc/internal/testenv"
+	userModel "librarymvc/internal/users/models"
+)
+
+// tokenFor creates a user with the role and returns an API token of theirs.
+func tokenFor(t *testing.T, storage *testenv.Storage, authService models.AuthService, role string) string {
+	t.Helper()
+	now := storage.Clock.Now()
+	user := &userModel.User{
+		Name:      "Usuária " + role,
+		Email:     role + "@lib.org",
+		Role:      role,
+		Category:  userModel.DefaultCategory,
+		Status:    userModel.StatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := storage.Repos.Users.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.SetPassword(user.ID, "senha-de-teste"); err != nil {
+		t.Fatalf("SetPassword() = %v", err)
+	}
+	token, err := authService.CreateToken(user.Email, "senha-de-teste", "teste")
+	if err != nil {
+		t.Fatalf("CreateToken() = %v", err)
+	}
+	return token.Token
+}
+
+func TestRolesOnAPIRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		authService := services.NewAuthService(storage.Repos.Users, storage.Credentials, storage.Sessions, storage.Tokens, time.Hour, time.Hour, storage.Clock)
+		tokens := map[string]string{
+			userModel.RolePatron:    tokenFor(t, storage, authService, userModel.RolePatron),
+			userModel.RoleLibrarian: tokenFor(t, storage, authService, userModel.RoleLibrarian),
+		}
+
+		ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+		router := gin.New()
+		api := router.Group("/api", apperror.Middleware(), BearerAuth(authService), RequireUserForChanges())
+		api.GET("/loans", Require(models.ViewCirculation), ok)
+		api.POST("/loans", Require(models.Circulate), ok)
+		api.POST("/holds", Require(models.PlaceHolds), ok)
+
+		tests := []struct {
+			name   string
+			role   string
+			method string
+			path   string
+			status int
+		}{
+			{"anonymous reading loans", "", http.MethodGet, "/api/loans", http.StatusUnauthorized},
+			{"patron reading everyone's loans", userModel.RolePatron, http.MethodGet, "/api/loans", http.StatusForbidden},
+			{"patron checking out", userModel.RolePatron, http.MethodPost, "/api/loans", http.StatusForbidden},
+			{"patron placing a hold", userModel.RolePatron, http.MethodPost, "/api/holds", http.StatusOK},
+			{"librarian reading loans", userModel.RoleLibrarian, http.MethodGet, "/api/loans", http.StatusOK},
+			{"librarian checking out", userModel.RoleLibrarian, http.MethodPost, "/api/loans", http.StatusOK},
+		}
+		for _, test := range tests {
+			request := httptest.NewRequest(test.method, test.path, nil)
+			if test.role != "" {
+				request.Header.Set("Authorization", "Bearer "+tokens[test.role])
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != test.status {
+				t.Errorf("%s: status = %d, want %d", test.name, recorder.Code, test.status)
+			}
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// APIToken lets a program call the API on behalf of a user, sent as
+// "Authorization: Bearer <token>". Like sessions, only a hash is stored.
+type APIToken struct {
+	ID         int64      `json:"ID"`
+	UserID     int64      `json:"userID"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"` // devolvido uma única vez, na criação
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+// Expired reports whether the token has run out at now.
+func (t *APIToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// CredentialRepository keeps the password hashes of the users who can log in.
+type CredentialRepository interface {
+	SetPasswordHash(userID int64, hash string, updatedAt time.Time) error
+	// GetPasswordHash returns "" for a user without a password.
+	GetPasswordHash(userID int64) (string, error)
+}
+
+type SessionRepository interface {
+	CreateSession(session *Session) error
+	GetSessionByHash(tokenHash string) (*Session, error)
+	DeleteSession(id int64) error
+	DeleteUserSessions(userID int64) error
+	// DeleteExpiredSessions removes the sessions expired at now and
+	// returns how many there were.
+	DeleteExpiredSessions(now time.Time) (int, error)
+}
+
+type TokenRepository interface {
+	CreateToken(token *APIToken) error
+	GetTokenByHash(tokenHash string) (*APIToken, error)
+	GetUserTokens(userID int64) ([]*APIToken, error)
+	// TouchToken records that the token was used at usedAt.
+	TouchToken(id int64, usedAt time.Time) error
+	DeleteToken(id int64) error
+	DeleteExpiredTokens(now time.Time) (int, error)
+}
@@ -0,0 +1,27 @@
+package models
+
+import userModel "librarymvc/internal/users/models"
+
+type AuthService interface {
+	// Login checks the email and password and opens a web session.
+	Login(email, password string) (*Session, error)
+	Logout(token string) error
+	// SessionUser is the user of the open session with the token.
+	SessionUser(token string) (*userModel.User, error)
+	// CreateToken checks the email and password and issues an API token.
+	CreateToken(email, password, name string) (*APIToken, error)
+	// TokenUser is the user an unexpired API token belongs to.
+	TokenUser(token string) (*userModel.User, error)
+	GetUserTokens(userID int64) ([]*APIToken, error)
+	// RevokeToken deletes one of the user's API tokens.
+	RevokeToken(userID, tokenID int64) error
+	// SetPassword sets the user's password and ends their open sessions.
+	SetPassword(userID int64, password string) error
+	HasPassword(userID int64) (bool, error)
+	// EnsureAdmin makes the user with the email an administrator and gives
+	// them the password if they have none yet, creating the user if needed,
+	// so that someone can always log in and manage the library.
+	EnsureAdmin(email, password string) error
+	// PurgeExpired deletes expired sessions and API tokens.
+	PurgeExpired() (int, error)
+}
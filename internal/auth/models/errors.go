@@ -0,0 +1,20 @@
+package models
+
+import "librarymvc/internal/apperror"
+
+const (
+	MinPasswordLength = 8
+	// MaxPasswordLength is the most bcrypt takes into account.
+	MaxPasswordLength = 72
+)
+
+var (
+	ErrInvalidCredentials = apperror.NewUnauthorized("invalid_credentials", "invalid email or password")
+	ErrUnauthenticated    = apperror.NewUnauthorized("unauthenticated", "authentication required")
+	ErrInvalidToken       = apperror.NewUnauthorized("invalid_token", "invalid or expired token")
+	ErrForbidden          = apperror.NewForbidden("forbidden", "your role does not allow this")
+	ErrSessionNotFound    = apperror.NewNotFound("session_not_found", "session not found")
+	ErrTokenNotFound      = apperror.NewNotFound("token_not_found", "token not found")
+	ErrPasswordTooShort   = apperror.NewValidation("password_too_short", "password must have at least 8 characters")
+	ErrPasswordTooLong    = apperror.NewValidation("password_too_long", "password must have at most 72 bytes")
+)
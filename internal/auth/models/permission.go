@@ -0,0 +1,66 @@
+package models
+
+import (
+	userModel "librarymvc/internal/users/models"
+	"slices"
+)
+
+// Permission is something a role allows. Reading the catalog (books,
+// copies, authors, subjects, policies and the calendar) needs none.
+type Permission string
+
+const (
+	// ManageCatalog covers creating, editing and merging books, their
+	// copies and MARC imports.
+	ManageCatalog Permission = "catalog.manage"
+	DeleteBooks   Permission = "books.delete"
+	// ViewUsers covers every user's record; anyone may see their own.
+	ViewUsers   Permission = "users.view"
+	ManageUsers Permission = "users.manage"
+	DeleteUsers Permission = "users.delete"
+	// ManageRoles is needed to give a user any role but patron.
+	ManageRoles Permission = "users.roles"
+	// SetPasswords covers other users' passwords; anyone may set their own.
+	SetPasswords Permission = "users.passwords"
+	// ViewCirculation covers everyone's loans, holds and accounts; anyone
+	// may see their own.
+	ViewCirculation Permission = "circulation.view"
+	// Circulate covers checking out, returning and renewing loans and
+	// handling everyone's holds.
+	Circulate Permission = "circulation.manage"
+	// PlaceHolds lets a user place holds for themselves.
+	PlaceHolds Permission = "holds.place"
+	// RenewOwnLoans lets a user renew their own loans from the portal.
+	RenewOwnLoans  Permission = "loans.renew_own"
+	ManageAccounts Permission = "accounts.manage"
+	ManagePolicies Permission = "policies.manage"
+	ManageCalendar Permission = "calendar.manage"
+)
+
+// Permissions lists every permission.
+var Permissions = []Permission{
+	ManageCatalog, DeleteBooks,
+	ViewUsers, ManageUsers, DeleteUsers, ManageRoles, SetPasswords,
+	ViewCirculation, Circulate, PlaceHolds, RenewOwnLoans, ManageAccounts,
+	ManagePolicies, ManageCalendar,
+}
+
+// rolePermissions is the permissions matrix: what each role may do.
+var rolePermissions = map[string][]Permission{
+	userModel.RolePatron: {
+		PlaceHolds, RenewOwnLoans,
+	},
+	userModel.RoleLibrarian: {
+		PlaceHolds, RenewOwnLoans,
+		ManageCatalog,
+		ViewUsers, ManageUsers,
+		ViewCirculation, Circulate, ManageAccounts,
+	},
+	userModel.RoleAdmin: Permissions,
+}
+
+// Can reports whether the role grants the permission. Unknown roles grant
+// nothing.
+func Can(role string, permission Permission) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
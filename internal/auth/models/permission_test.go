@@ -0,0 +1,45 @@
+package models
+
+import (
+	"slices"
+	"testing"
+
+	userModel "librarymvc/internal/users/models"
+)
+
+func TestCan(t *testing.T) {
+	roles := []string{userModel.RolePatron, userModel.RoleLibrarian, userModel.RoleAdmin, "", "visitor"}
+
+	// Which of the roles above may do each thing
+	tests := []struct {
+		permission Permission
+		granted    []string
+	}{
+		{ManageCatalog, []string{userModel.RoleLibrarian, userModel.RoleAdmin}},
+		{DeleteBooks, []string{userModel.RoleAdmin}},
+		{ViewUsers, []string{userModel.RoleLibrarian, userModel.RoleAdmin}},
+		{ManageUsers, []string{userModel.RoleLibrarian, userModel.RoleAdmin}},
+		{DeleteUsers, []string{userModel.RoleAdmin}},
+		{ManageRoles, []string{userModel.RoleAdmin}},
+		{SetPasswords, []string{userModel.RoleAdmin}},
+		{ViewCirculation, []string{userModel.RoleLibrarian, userModel.RoleAdmin}},
+		{Circulate, []string{userModel.RoleLibrarian, userModel.RoleAdmin}},
+		{PlaceHolds, []string{userModel.RolePatron, userModel.RoleLibrarian, userModel.RoleAdmin}},
+		{RenewOwnLoans, []string{userModel.RolePatron, userModel.RoleLibrarian, userModel.RoleAdmin}},
+		{ManageAccounts, []string{userModel.RoleLibrarian, userModel.RoleAdmin}},
+		{ManagePolicies, []string{userModel.RoleAdmin}},
+		{ManageCalendar, []string{userModel.RoleAdmin}},
+	}
+	if len(tests) != len(Permissions) {
+		t.Fatalf("the table covers %d permissions, want all %d", len(tests), len(Permissions))
+	}
+
+	for _, test := range tests {
+		for _, role := range roles {
+			want := slices.Contains(test.granted, role)
+			if got := Can(role, test.permission); got != want {
+				t.Errorf("Can(%q, %s) = %v, want %v", role, test.permission, got, want)
+			}
+		}
+	}
+}
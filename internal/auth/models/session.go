@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Session is a login to the web pages. Only a hash of its token is stored;
+// the token itself lives in the browser's cookie.
+type Session struct {
+	ID        int64
+	UserID    int64
+	Token     string // set only when the session is opened
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the session has run out at now.
+func (s *Session) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"librarymvc/internal/auth/models"
+	"sync"
+	"time"
+)
+
+type CredentialRepository struct {
+	hashes map[int64]string
+	mu     sync.RWMutex
+}
+
+func NewCredentialRepository() models.CredentialRepository {
+	return &CredentialRepository{hashes: make(map[int64]string)}
+}
+
+func (c *CredentialRepository) SetPasswordHash(userID int64, hash string, updatedAt time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hashes[userID] = hash
+	return nil
+}
+
+func (c *CredentialRepository) GetPasswordHash(userID int64) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.hashes[userID], nil
+}
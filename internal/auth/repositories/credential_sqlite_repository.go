@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"librarymvc/internal/auth/models"
+	"librarymvc/internal/database"
+	"time"
+)
+
+type SQLiteCredentialRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteCredentialRepository(db database.DBTX) models.CredentialRepository {
+	return &SQLiteCredentialRepository{db: db}
+}
+
+func (c *SQLiteCredentialRepository) SetPasswordHash(userID int64, hash string, updatedAt time.Time) error {
+	_, err := c.db.Exec(
+		`INSERT INTO credentials (user_id, password_hash, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT (user_id) DO UPDATE SET password_hash = excluded.password_hash, updated_at = excluded.updated_at`,
+		userID, hash, updatedAt,
+	)
+	return err
+}
+
+func (c *SQLiteCredentialRepository) GetPasswordHash(userID int64) (string, error) {
+	var hash string
+	err := c.db.QueryRow(`SELECT password_hash FROM credentials WHERE user_id = ?`, userID).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return hash, err
+}
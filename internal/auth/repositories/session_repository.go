@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"librarymvc/internal/auth/models"
+	"sync"
+	"time"
+)
+
+type SessionRepository struct {
+	sessions map[int64]*models.Session
+	mu       sync.RWMutex
+	nextID   int64
+}
+
+func NewSessionRepository() models.SessionRepository {
+	return &SessionRepository{
+		sessions: make(map[int64]*models.Session),
+		nextID:   1,
+	}
+}
+
+func (s *SessionRepository) CreateSession(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session.ID = s.nextID
+	s.sessions[session.ID] = session
+	s.nextID++
+
+	return nil
+}
+
+func (s *SessionRepository) GetSessionByHash(tokenHash string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, session := range s.sessions {
+		if session.TokenHash == tokenHash {
+			return session, nil
+		}
+	}
+
+	return nil, models.ErrSessionNotFound
+}
+
+func (s *SessionRepository) DeleteSession(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *SessionRepository) DeleteUserSessions(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (s *SessionRepository) DeleteExpiredSessions(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for id, session := range s.sessions {
+		if session.Expired(now) {
+			delete(s.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"librarymvc/internal/auth/models"
+	"librarymvc/internal/database"
+	"time"
+)
+
+type SQLiteSessionRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteSessionRepository(db database.DBTX) models.SessionRepository {
+	return &SQLiteSessionRepository{db: db}
+}
+
+func (s *SQLiteSessionRepository) CreateSession(session *models.Session) error {
+	result, err := s.db.Exec(
+		`INSERT INTO sessions (user_id, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		session.UserID, session.TokenHash, session.CreatedAt, session.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	session.ID = id
+
+	return nil
+}
+
+func (s *SQLiteSessionRepository) GetSessionByHash(tokenHash string) (*models.Session, error) {
+	var session models.Session
+	err := s.db.QueryRow(
+		`SELECT id, user_id, token_hash, created_at, expires_at FROM sessions WHERE token_hash = ?`,
+		tokenHash,
+	).Scan(&session.ID, &session.UserID, &session.TokenHash, &session.CreatedAt, &session.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *SQLiteSessionRepository) DeleteSession(id int64) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE id = ?`, id)
+	return err
+}
+
+func (s *SQLiteSessionRepository) DeleteUserSessions(userID int64) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE user_id = ?`, userID)
+	return err
+}
+
+// DeleteExpiredSessions compares the expiry times in Go, since they are
+// stored as text.
+func (s *SQLiteSessionRepository) DeleteExpiredSessions(now time.Time) (int, error) {
+	rows, err := s.db.Query(`SELECT id, expires_at FROM sessions`)
+	if err != nil {
+		return 0, err
+	}
+
+	var expired []int64
+	for rows.Next() {
+		var (
+			id        int64
+			expiresAt time.Time
+		)
+		if err := rows.Scan(&id, &expiresAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if !now.Before(expiresAt) {
+			expired = append(expired, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range expired {
+		if err := s.DeleteSession(id); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
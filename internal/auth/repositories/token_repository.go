@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"cmp"
+	"librarymvc/internal/auth/models"
+	"slices"
+	"sync"
+	"time"
+)
+
+type TokenRepository struct {
+	tokens map[int64]*models.APIToken
+	mu     sync.RWMutex
+	nextID int64
+}
+
+func NewTokenRepository() models.TokenRepository {
+	return &TokenRepository{
+		tokens: make(map[int64]*models.APIToken),
+		nextID: 1,
+	}
+}
+
+func (t *TokenRepository) CreateToken(token *models.APIToken) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	token.ID = t.nextID
+	stored := *token
+	stored.Token = ""
+	t.tokens[token.ID] = &stored
+	t.nextID++
+
+	return nil
+}
+
+func (t *TokenRepository) GetTokenByHash(tokenHash string) (*models.APIToken, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, token := range t.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+
+	return nil, models.ErrTokenNotFound
+}
+
+func (t *TokenRepository) GetUserTokens(userID int64) ([]*models.APIToken, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tokens := make([]*models.APIToken, 0)
+	for _, token := range t.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	slices.SortFunc(tokens, func(x, y *models.APIToken) int { return cmp.Compare(x.ID, y.ID) })
+
+	return tokens, nil
+}
+
+func (t *TokenRepository) TouchToken(id int64, usedAt time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	token, exists := t.tokens[id]
+	if !exists {
+		return models.ErrTokenNotFound
+	}
+
+	token.LastUsedAt = &usedAt
+	return nil
+}
+
+func (t *TokenRepository) DeleteToken(id int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.tokens[id]; !exists {
+		return models.ErrTokenNotFound
+	}
+
+	delete(t.tokens, id)
+	return nil
+}
+
+func (t *TokenRepository) DeleteExpiredTokens(now time.Time) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	deleted := 0
+	for id, token := range t.tokens {
+		if token.Expired(now) {
+			delete(t.tokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
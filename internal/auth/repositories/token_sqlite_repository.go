@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"librarymvc/internal/auth/models"
+	"librarymvc/internal/database"
+	"time"
+)
+
+type SQLiteTokenRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteTokenRepository(db database.DBTX) models.TokenRepository {
+	return &SQLiteTokenRepository{db: db}
+}
+
+const tokenColumns = `id, user_id, name, token_hash, created_at, expires_at, last_used_at`
+
+func scanToken(row interface{ Scan(...any) error }) (*models.APIToken, error) {
+	var (
+		token      models.APIToken
+		lastUsedAt sql.NullTime
+	)
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&lastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	return &token, nil
+}
+
+func (t *SQLiteTokenRepository) CreateToken(token *models.APIToken) error {
+	result, err := t.db.Exec(
+		`INSERT INTO api_tokens (user_id, name, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		token.UserID, token.Name, token.TokenHash, token.CreatedAt, token.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = id
+
+	return nil
+}
+
+func (t *SQLiteTokenRepository) GetTokenByHash(tokenHash string) (*models.APIToken, error) {
+	token, err := scanToken(t.db.QueryRow(`SELECT `+tokenColumns+` FROM api_tokens WHERE token_hash = ?`, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (t *SQLiteTokenRepository) queryTokens(query string, args ...any) ([]*models.APIToken, error) {
+	rows, err := t.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]*models.APIToken, 0)
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (t *SQLiteTokenRepository) GetUserTokens(userID int64) ([]*models.APIToken, error) {
+	return t.queryTokens(`SELECT `+tokenColumns+` FROM api_tokens WHERE user_id = ? ORDER BY id`, userID)
+}
+
+func (t *SQLiteTokenRepository) TouchToken(id int64, usedAt time.Time) error {
+	_, err := t.db.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, usedAt, id)
+	return err
+}
+
+func (t *SQLiteTokenRepository) DeleteToken(id int64) error {
+	result, err := t.db.Exec(`DELETE FROM api_tokens WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpiredTokens compares the expiry times in Go, since they are
+// stored as text.
+func (t *SQLiteTokenRepository) DeleteExpiredTokens(now time.Time) (int, error) {
+	tokens, err := t.queryTokens(`SELECT ` + tokenColumns + ` FROM api_tokens`)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, token := range tokens {
+		if !token.Expired(now) {
+			continue
+		}
+		if err := t.DeleteToken(token.ID); err != nil {
+			return 0, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"librarymvc/internal/auth/models"
+	"librarymvc/internal/clock"
+	userModel "librarymvc/internal/users/models"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// apiTokenPrefix marks API tokens so they are easy to spot in logs and
+// secret scanners.
+const apiTokenPrefix = "lib_"
+
+// dummyHash is compared against when no user matches a login, so that an
+// unknown email takes as long to reject as a wrong password.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("librarymvc"), bcrypt.DefaultCost)
+
+type AuthService struct {
+	userRepo       userModel.UserRepository
+	credentialRepo models.CredentialRepository
+	sessionRepo    models.SessionRepository
+	tokenRepo      models.TokenRepository
+	sessionTTL     time.Duration
+	tokenTTL       time.Duration
+	clock          clock.Clock
+}
+
+func NewAuthService(
+	userRepo userModel.UserRepository,
+	credentialRepo models.CredentialRepository,
+	sessionRepo models.SessionRepository,
+	tokenRepo models.TokenRepository,
+	sessionTTL time.Duration,
+	tokenTTL time.Duration,
+	clock clock.Clock,
+) models.AuthService {
+	return &AuthService{
+		userRepo:       userRepo,
+		credentialRepo: credentialRepo,
+		sessionRepo:    sessionRepo,
+		tokenRepo:      tokenRepo,
+		sessionTTL:     sessionTTL,
+		tokenTTL:       tokenTTL,
+		clock:          clock,
+	}
+}
+
+// newToken returns a random token and the hash stored in its place.
+func newToken(prefix string) (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := prefix + base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticate finds the user the email and password belong to. Emails are
+// not unique, so every user with the email and a password is tried.
+func (a *AuthService) authenticate(email, password string) (*userModel.User, error) {
+	users, err := a.userRepo.GetUsersByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		hash, err := a.credentialRepo.GetPasswordHash(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if hash == "" {
+			continue
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return user, nil
+		}
+	}
+
+	if len(users) == 0 {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+	}
+	return nil, models.ErrInvalidCredentials
+}
+
+func (a *AuthService) Login(email, password string) (*models.Session, error) {
+	user, err := a.authenticate(email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	token, tokenHash, err := newToken("")
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.clock.Now()
+	session := &models.Session{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(a.sessionTTL),
+	}
+	if err := a.sessionRepo.CreateSession(session); err != nil {
+		return nil, err
+	}
+	session.Token = token
+
+	return session, nil
+}
+
+func (a *AuthService) Logout(token string) error {
+	session, err := a.sessionRepo.GetSessionByHash(hashToken(token))
+	if errors.Is(err, models.ErrSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return a.sessionRepo.DeleteSession(session.ID)
+}
+
+func (a *AuthService) SessionUser(token string) (*userModel.User, error) {
+	session, err := a.sessionRepo.GetSessionByHash(hashToken(token))
+	if errors.Is(err, models.ErrSessionNotFound) {
+		return nil, models.ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.Expired(a.clock.Now()) {
+		return nil, models.ErrUnauthenticated
+	}
+
+	return a.userOf(session.UserID, models.ErrUnauthenticated)
+}
+
+// userOf loads the user a session or token belongs to, answering notFound
+// when the user was deleted in the meantime.
+func (a *AuthService) userOf(userID int64, notFound error) (*userModel.User, error) {
+	user, err := a.userRepo.GetUser(userID)
+	if errors.Is(err, userModel.ErrUserNotFound) {
+		return nil, notFound
+	}
+	return user, err
+}
+
+func (a *AuthService) CreateToken(email, password, name string) (*models.APIToken, error) {
+	user, err := a.authenticate(email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	token, tokenHash, err := newToken(apiTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.clock.Now()
+	apiToken := &models.APIToken{
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(name),
+		TokenHash: tokenHash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(a.tokenTTL),
+	}
+	if err := a.tokenRepo.CreateToken(apiToken); err != nil {
+		return nil, err
+	}
+	apiToken.Token = token
+
+	return apiToken, nil
+}
+
+func (a *AuthService) TokenUser(token string) (*userModel.User, error) {
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return nil, models.ErrInvalidToken
+	}
+
+	apiToken, err := a.tokenRepo.GetTokenByHash(hashToken(token))
+	if errors.Is(err, models.ErrTokenNotFound) {
+		return nil, models.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.clock.Now()
+	if apiToken.Expired(now) {
+		return nil, models.ErrInvalidToken
+	}
+
+	user, err := a.userOf(apiToken.UserID, models.ErrInvalidToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.tokenRepo.TouchToken(apiToken.ID, now); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (a *AuthService) GetUserTokens(userID int64) ([]*models.APIToken, error) {
+	return a.tokenRepo.GetUserTokens(userID)
+}
+
+func (a *AuthService) RevokeToken(userID, tokenID int64) error {
+	tokens, err := a.tokenRepo.GetUserTokens(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if token.ID == tokenID {
+			return a.tokenRepo.DeleteToken(tokenID)
+		}
+	}
+	return models.ErrTokenNotFound
+}
+
+func (a *AuthService) SetPassword(userID int64, password string) error {
+	if len(password) < models.MinPasswordLength {
+		return models.ErrPasswordTooShort
+	}
+	if len(password) > models.MaxPasswordLength {
+		return models.ErrPasswordTooLong
+	}
+
+	if _, err := a.userRepo.GetUser(userID); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	if err := a.credentialRepo.SetPasswordHash(userID, string(hash), a.clock.Now()); err != nil {
+		return err
+	}
+	return a.sessionRepo.DeleteUserSessions(userID)
+}
+
+func (a *AuthService) HasPassword(userID int64) (bool, error) {
+	hash, err := a.credentialRepo.GetPasswordHash(userID)
+	if err != nil {
+		return false, err
+	}
+	return hash != "", nil
+}
+
+func (a *AuthService) EnsureAdmin(email, password string) error {
+	users, err := a.userRepo.GetUsersByEmail(email)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		hasPassword, err := a.HasPassword(user.ID)
+		if err != nil {
+			return err
+		}
+		if hasPassword {
+			return a.makeAdmin(user)
+		}
+	}
+
+	if len(users) > 0 {
+		if err := a.makeAdmin(users[0]); err != nil {
+			return err
+		}
+		return a.SetPassword(users[0].ID, password)
+	}
+
+	now := a.clock.Now()
+	user := &userModel.User{
+		Name:        "Administrador da Biblioteca",
+		Email:       email,
+		Role:        userModel.RoleAdmin,
+		Category:    userModel.DefaultCategory,
+		Status:      userModel.StatusActive,
+		MemberSince: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := a.userRepo.CreateUser(user); err != nil {
+		return err
+	}
+	user.CardNumber = fmt.Sprintf("%06d", user.ID)
+	if err := a.userRepo.UpdateUser(user.ID, user); err != nil {
+		return err
+	}
+	return a.SetPassword(user.ID, password)
+}
+
+func (a *AuthService) makeAdmin(user *userModel.User) error {
+	if user.Role == userModel.RoleAdmin {
+		return nil
+	}
+
+	updated := *user
+	updated.Role = userModel.RoleAdmin
+	updated.UpdatedAt = a.clock.Now()
+	return a.userRepo.UpdateUser(user.ID, &updated)
+}
+
+func (a *AuthService) PurgeExpired() (int, error) {
+	now := a.clock.Now()
+
+	sessions, err := a.sessionRepo.DeleteExpiredSessions(now)
+	if err != nil {
+		return 0, err
+	}
+
+	tokens, err := a.tokenRepo.DeleteExpiredTokens(now)
+	if err != nil {
+		return sessions, err
+	}
+
+	return sessions + tokens, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"librarymvc/internal/testenv"
+	userModel "librarymvc/internal/users/models"
+)
+
+func TestEnsureAdminPromotesOnlyThatAccount(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		auth := NewAuthService(storage.Repos.Users, storage.Credentials, storage.Sessions, storage.Tokens, time.Hour, time.Hour, storage.Clock)
+
+		// Two members who can already log in
+		now := storage.Clock.Now()
+		var users []*userModel.User
+		for _, email := range []string{"diretora@lib.org", "leitora@lib.org"} {
+			user := &userModel.User{
+				Name:      "Usuária com Senha",
+				Email:     email,
+				Role:      userModel.RolePatron,
+				Category:  userModel.DefaultCategory,
+				Status:    userModel.StatusActive,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := storage.Repos.Users.CreateUser(user); err != nil {
+				t.Fatal(err)
+			}
+			if err := auth.SetPassword(user.ID, "senha-antiga"); err != nil {
+				t.Fatal(err)
+			}
+			users = append(users, user)
+		}
+
+		if err := auth.EnsureAdmin("diretora@lib.org", "senha-nova"); err != nil {
+			t.Fatalf("EnsureAdmin() = %v", err)
+		}
+		for i, want := range []string{userModel.RoleAdmin, userModel.RolePatron} {
+			stored, err := storage.Repos.Users.GetUser(users[i].ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Role != want {
+				t.Errorf("%s is %q, want %q", stored.Email, stored.Role, want)
+			}
+		}
+		// An existing password is kept
+		if _, err := auth.Login("diretora@lib.org", "senha-antiga"); err != nil {
+			t.Errorf("Login() with the old password = %v", err)
+		}
+
+		// On a fresh install the administrator is created
+		if err := auth.EnsureAdmin("admin@lib.org", "senha-nova"); err != nil {
+			t.Fatalf("EnsureAdmin(new) = %v", err)
+		}
+		created, err := storage.Repos.Users.GetUsersByEmail("admin@lib.org")
+		if err != nil || len(created) != 1 || created[0].Role != userModel.RoleAdmin {
+			t.Fatalf("GetUsersByEmail() = %v, %v; want one admin", created, err)
+		}
+		if _, err := auth.Login("admin@lib.org", "senha-nova"); err != nil {
+			t.Errorf("Login() as the new admin = %v", err)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package books
+
+import (
+	"librarymvc/internal/apperror"
+	"librarymvc/internal/books/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (b *BooksController) GetAllAuthors(ctx *gin.Context) {
+	authors, err := b.bookService.GetAllAuthors()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, authors)
+}
+
+func (b *BooksController) GetAuthor(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("author"))
+		return
+	}
+
+	author, err := b.bookService.GetAuthor(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, author)
+}
+
+// GetAuthorBooks lists the author's books, paged like GetAllBooks.
+func (b *BooksController) GetAuthorBooks(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("author"))
+		return
+	}
+
+	if _, err := b.bookService.GetAuthor(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	b.listBooks(ctx, models.BookFilter{AuthorID: id})
+}
+
+func (b *BooksController) GetAllSubjects(ctx *gin.Context) {
+	subjects, err := b.bookService.GetAllSubjects()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subjects)
+}
+
+func (b *BooksController) GetSubject(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("subject"))
+		return
+	}
+
+	subject, err := b.bookService.GetSubject(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subject)
+}
+
+// GetSubjectBooks lists the books catalogued under the subject, paged like
+// GetAllBooks.
+func (b *BooksController) GetSubjectBooks(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("subject"))
+		return
+	}
+
+	if _, err := b.bookService.GetSubject(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	b.listBooks(ctx, models.BookFilter{SubjectID: id})
+}
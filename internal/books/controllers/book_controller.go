@@ -1,9 +1,12 @@
 package books
 
 import (
+	"librarymvc/internal/apperror"
 	"librarymvc/internal/books/models"
+	"librarymvc/internal/paging"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,23 +26,39 @@ func (b *BooksController) RegisterRoutes(r *gin.Engine) {
 	{
 		users.GET("/", b.GetAllBooks)
 		users.GET("/:id", b.GetBook)
+		users.GET("/isbn/:isbn", b.GetBookByISBN)
+		users.POST("/:id/merge", b.MergeBooks)
 		users.POST("", b.CreateBook)
 		users.PUT("/:id", b.UpdateBook)
 		users.DELETE("/:id", b.DeleteBook)
 	}
+
+	authors := r.Group("/authors")
+	{
+		authors.GET("", b.GetAllAuthors)
+		authors.GET("/:id", b.GetAuthor)
+		authors.GET("/:id/books", b.GetAuthorBooks)
+	}
+
+	subjects := r.Group("/subjects")
+	{
+		subjects.GET("", b.GetAllSubjects)
+		subjects.GET("/:id", b.GetSubject)
+		subjects.GET("/:id/books", b.GetSubjectBooks)
+	}
 }
 
 func (b *BooksController) CreateBook(ctx *gin.Context) {
 	var book models.Book
 
 	if err := ctx.ShouldBindJSON(&book); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		ctx.Error(apperror.InvalidBody(err))
 		return
 	}
 
 	err := b.bookService.CreateBook(&book)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -49,23 +68,98 @@ func (b *BooksController) CreateBook(ctx *gin.Context) {
 func (b *BooksController) GetBook(ctx *gin.Context) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		ctx.Error(apperror.InvalidID("book"))
 		return
 	}
 
 	book, err := b.bookService.GetBook(id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, book)
 }
 
+func (b *BooksController) GetBookByISBN(ctx *gin.Context) {
+	book, err := b.bookService.GetBookByISBN(ctx.Param("isbn"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, book)
+}
+
+type mergeRequest struct {
+	DuplicateID int64 `json:"duplicateID" binding:"required"`
+}
+
+// MergeBooks folds the book given as duplicateID into the book in the path.
+func (b *BooksController) MergeBooks(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("book"))
+		return
+	}
+
+	var request mergeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	book, err := b.bookService.MergeBooks(id, request.DuplicateID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, book)
+}
+
+// GetAllBooks lists the books a page at a time. Besides page, limit and
+// sort it filters by q (title or author), author, authorID, subjectID and
+// bookType.
 func (b *BooksController) GetAllBooks(ctx *gin.Context) {
-	books, err := b.bookService.GetAllBooks()
+	filter := models.BookFilter{
+		Query:    strings.TrimSpace(ctx.Query("q")),
+		Author:   strings.TrimSpace(ctx.Query("author")),
+		BookType: strings.TrimSpace(ctx.Query("bookType")),
+	}
+
+	ids := []struct {
+		name string
+		id   *int64
+	}{
+		{"authorID", &filter.AuthorID},
+		{"subjectID", &filter.SubjectID},
+	}
+	for _, param := range ids {
+		if value := ctx.Query(param.name); value != "" {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				ctx.Error(apperror.InvalidID(strings.TrimSuffix(param.name, "ID")))
+				return
+			}
+			*param.id = id
+		}
+	}
+
+	b.listBooks(ctx, filter)
+}
+
+// listBooks writes the page of books matching filter asked for in the query.
+func (b *BooksController) listBooks(ctx *gin.Context, filter models.BookFilter) {
+	page, err := paging.FromQuery(ctx.Request.URL.Query())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	books, err := b.bookService.ListBooks(filter, page)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -75,19 +169,19 @@ func (b *BooksController) GetAllBooks(ctx *gin.Context) {
 func (b *BooksController) UpdateBook(ctx *gin.Context) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		ctx.Error(apperror.InvalidID("user"))
 		return
 	}
 
 	var book models.Book
 	if err := ctx.ShouldBindJSON(&book); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		ctx.Error(apperror.InvalidBody(err))
 		return
 	}
 
 	err = b.bookService.UpdateBook(id, &book)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -97,13 +191,13 @@ func (b *BooksController) UpdateBook(ctx *gin.Context) {
 func (b *BooksController) DeleteBook(ctx *gin.Context) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book ID"})
+		ctx.Error(apperror.InvalidID("book"))
 		return
 	}
 
 	err = b.bookService.DeleteBook(id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Author is a person or body credited for books. Authors are created the
+// first time a book names them and matched by name afterwards.
+type Author struct {
+	ID        int64     `json:"ID"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UnmarshalJSON also accepts an author given just by name.
+func (a *Author) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*a = Author{Name: name}
+		return nil
+	}
+
+	type author Author
+	return json.Unmarshal(data, (*author)(a))
+}
+
+// Subject is a topic books are catalogued under, matched by name like
+// authors.
+type Subject struct {
+	ID        int64     `json:"ID"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UnmarshalJSON also accepts a subject given just by name.
+func (s *Subject) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*s = Subject{Name: name}
+		return nil
+	}
+
+	type subject Subject
+	return json.Unmarshal(data, (*subject)(s))
+}
@@ -0,0 +1,21 @@
+package models
+
+// AuthorRepository finds authors by name case-insensitively.
+type AuthorRepository interface {
+	// FindOrCreateAuthor fills in the author with that name, creating it if
+	// there is none yet.
+	FindOrCreateAuthor(author *Author) error
+	GetAuthor(id int64) (*Author, error)
+	// GetAllAuthors returns the authors in name order.
+	GetAllAuthors() ([]*Author, error)
+}
+
+// SubjectRepository finds subjects by name case-insensitively.
+type SubjectRepository interface {
+	// FindOrCreateSubject fills in the subject with that name, creating it
+	// if there is none yet.
+	FindOrCreateSubject(subject *Subject) error
+	GetSubject(id int64) (*Subject, error)
+	// GetAllSubjects returns the subjects in name order.
+	GetAllSubjects() ([]*Subject, error)
+}
@@ -2,13 +2,37 @@ package models
 
 import "time"
 
+// Book is a title in the catalog. Author is derived from Authors, their
+// names joined by "; "; a book sent with only Author gets the authors
+// listed in it.
 type Book struct {
-	ID           int64     `json:"ID"`
-	Title        string    `json:"title" binding:"required,min=5"`
-	Author       string    `json:"author" binding:"required,min=5"`
-	Quantity     int       `json:"quantity" binding:"required,min=0"`
-	BookType     string    `json:"bookType" binding:"required,oneof=emprestavel referencia"` // emprestavel or referencia
-	LoanDuration int       `json:"loanDuration"`                                             // 6, 12, or 30 days (only for emprestavel)
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID              int64      `json:"ID"`
+	Title           string     `json:"title" binding:"required,min=5"`
+	Author          string     `json:"author"`
+	Authors         []*Author  `json:"authors"`
+	Subjects        []*Subject `json:"subjects"`
+	ISBN            string     `json:"isbn"`                            // ISBN-13 sem separadores; aceita ISBN-10 na entrada
+	ISBN10          string     `json:"isbn10,omitempty"`                // derived: ISBN-10, for ISBNs in the 978 range
+	Publisher       string     `json:"publisher"`                       // editora
+	PublicationYear int        `json:"publicationYear" binding:"min=0"` // ano de publicação; 0 quando desconhecido
+	Edition         string     `json:"edition"`                         // ex.: "2ª edição"
+	Language        string     `json:"language"`                        // código do idioma, ex.: "pt"
+	Pages           int        `json:"pages" binding:"min=0"`
+	Quantity        int        `json:"quantity" binding:"min=0"`     // derived: available copies (on create: copies to register)
+	BookType        string     `json:"bookType" binding:"required"`  // código da política de empréstimo (tipo de material)
+	LoanDuration    int        `json:"loanDuration" binding:"min=0"` // prazo em dias; 0 usa o prazo da política
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
 }
+
+// BookFilter narrows a book listing; empty fields match every book.
+type BookFilter struct {
+	Query     string // parte do título ou do autor
+	Author    string // parte do nome do autor
+	BookType  string
+	AuthorID  int64
+	SubjectID int64
+}
+
+// BookSortFields are the fields a book listing can be sorted by.
+var BookSortFields = []string{"id", "title", "author", "isbn", "publisher", "publicationYear", "bookType", "loanDuration", "createdAt", "updatedAt"}
@@ -1,9 +1,16 @@
 package models
 
+import "librarymvc/internal/paging"
+
+// BookRepository fails with ErrDuplicateISBN when a book would take the
+// ISBN of another.
 type BookRepository interface {
 	CreateBook(book *Book) error
 	GetBook(id int64) (*Book, error)
+	// GetBookByISBN looks a book up by its ISBN-13.
+	GetBookByISBN(isbn string) (*Book, error)
 	GetAllBooks() ([]*Book, error)
+	ListBooks(filter BookFilter, page paging.Request) (*paging.Page[*Book], error)
 	UpdateBook(id int64, book *Book) error
 	DeleteBook(id int64) error
-}
\ No newline at end of file
+}
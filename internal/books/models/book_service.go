@@ -1,9 +1,22 @@
 package models
 
+import "librarymvc/internal/paging"
+
 type BookService interface {
 	CreateBook(book *Book) error
 	GetBook(id int64) (*Book, error)
+	// GetBookByISBN accepts an ISBN-10 or ISBN-13, with or without hyphens.
+	GetBookByISBN(isbn string) (*Book, error)
 	GetAllBooks() ([]*Book, error)
+	ListBooks(filter BookFilter, page paging.Request) (*paging.Page[*Book], error)
 	UpdateBook(id int64, book *Book) error
 	DeleteBook(id int64) error
+	GetAuthor(id int64) (*Author, error)
+	GetAllAuthors() ([]*Author, error)
+	GetSubject(id int64) (*Subject, error)
+	GetAllSubjects() ([]*Subject, error)
+	// MergeBooks folds a duplicate record into the book kept: its copies,
+	// loans, holds, authors and subjects move over and the duplicate is
+	// deleted. The two must share the ISBN or the title.
+	MergeBooks(keepID, duplicateID int64) (*Book, error)
 }
@@ -0,0 +1,23 @@
+package models
+
+import "librarymvc/internal/apperror"
+
+var (
+	ErrBookNotFound        = apperror.NewNotFound("book_not_found", "book not found")
+	ErrTitleRequired       = apperror.NewValidation("title_required", "title is required")
+	ErrAuthorRequired      = apperror.NewValidation("author_required", "at least one author is required")
+	ErrBookTypeRequired    = apperror.NewValidation("book_type_required", "book type is required")
+	ErrUnknownBookType     = apperror.NewValidation("unknown_book_type", "unknown book type")
+	ErrNegativeQuantity    = apperror.NewValidation("negative_quantity", "quantity cannot be negative")
+	ErrNegativeLoanPeriod  = apperror.NewValidation("negative_loan_duration", "loan duration cannot be negative")
+	ErrBookHasCopiesOnLoan = apperror.NewConflict("book_has_copies_on_loan", "book has copies on loan")
+	ErrBookHasActiveHolds  = apperror.NewConflict("book_has_active_holds", "book has active holds")
+	ErrInvalidISBN         = apperror.NewValidation("invalid_isbn", "invalid ISBN")
+	ErrDuplicateISBN       = apperror.NewConflict("duplicate_isbn", "another book already has this ISBN")
+	ErrMergeSameBook       = apperror.NewValidation("merge_same_book", "cannot merge a book into itself")
+	ErrMergeDifferentWorks = apperror.NewValidation("merge_different_works", "books to merge must share the ISBN or the title")
+	ErrNegativePages       = apperror.NewValidation("negative_pages", "page count cannot be negative")
+	ErrInvalidYear         = apperror.NewValidation("invalid_publication_year", "publication year is out of range")
+	ErrAuthorNotFound      = apperror.NewNotFound("author_not_found", "author not found")
+	ErrSubjectNotFound     = apperror.NewNotFound("subject_not_found", "subject not found")
+)
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"cmp"
+	"librarymvc/internal/books/models"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type AuthorRepository struct {
+	authors map[int64]*models.Author
+	mu      sync.RWMutex
+	nextID  int64
+}
+
+func NewAuthorRepository() models.AuthorRepository {
+	return &AuthorRepository{
+		authors: make(map[int64]*models.Author),
+		nextID:  1,
+	}
+}
+
+func (a *AuthorRepository) FindOrCreateAuthor(author *models.Author) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, existing := range a.authors {
+		if strings.EqualFold(existing.Name, author.Name) {
+			*author = *existing
+			return nil
+		}
+	}
+
+	author.ID = a.nextID
+	a.nextID++
+	stored := *author
+	a.authors[author.ID] = &stored
+
+	return nil
+}
+
+func (a *AuthorRepository) GetAuthor(id int64) (*models.Author, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	author, exists := a.authors[id]
+	if !exists {
+		return nil, models.ErrAuthorNotFound
+	}
+
+	return author, nil
+}
+
+func (a *AuthorRepository) GetAllAuthors() ([]*models.Author, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	authors := make([]*models.Author, 0, len(a.authors))
+	for _, author := range a.authors {
+		authors = append(authors, author)
+	}
+	slices.SortFunc(authors, func(x, y *models.Author) int {
+		return cmp.Compare(strings.ToLower(x.Name), strings.ToLower(y.Name))
+	})
+
+	return authors, nil
+}
+
+// Snapshot copies the current state and returns a function that restores it.
+// It is used by the in-memory unit of work to roll back failed transactions.
+func (a *AuthorRepository) Snapshot() func() {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	authors := make(map[int64]models.Author, len(a.authors))
+	for id, author := range a.authors {
+		authors[id] = *author
+	}
+	nextID := a.nextID
+
+	return func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		a.authors = make(map[int64]*models.Author, len(authors))
+		for id, author := range authors {
+			author := author
+			a.authors[id] = &author
+		}
+		a.nextID = nextID
+	}
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"librarymvc/internal/books/models"
+	"librarymvc/internal/database"
+)
+
+type SQLiteAuthorRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteAuthorRepository(db database.DBTX) models.AuthorRepository {
+	return &SQLiteAuthorRepository{db: db}
+}
+
+const authorColumns = `id, name, created_at`
+
+func scanAuthor(row interface{ Scan(...any) error }) (*models.Author, error) {
+	var author models.Author
+	if err := row.Scan(&author.ID, &author.Name, &author.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// FindOrCreateAuthor relies on the name column comparing case-insensitively.
+func (a *SQLiteAuthorRepository) FindOrCreateAuthor(author *models.Author) error {
+	existing, err := scanAuthor(a.db.QueryRow(`SELECT `+authorColumns+` FROM authors WHERE name = ?`, author.Name))
+	if err == nil {
+		*author = *existing
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	result, err := a.db.Exec(`INSERT INTO authors (name, created_at) VALUES (?, ?)`, author.Name, author.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	author.ID = id
+
+	return nil
+}
+
+func (a *SQLiteAuthorRepository) GetAuthor(id int64) (*models.Author, error) {
+	author, err := scanAuthor(a.db.QueryRow(`SELECT `+authorColumns+` FROM authors WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrAuthorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return author, nil
+}
+
+func (a *SQLiteAuthorRepository) GetAllAuthors() ([]*models.Author, error) {
+	rows, err := a.db.Query(`SELECT ` + authorColumns + ` FROM authors ORDER BY name, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors := make([]*models.Author, 0)
+	for rows.Next() {
+		author, err := scanAuthor(rows)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+
+	return authors, rows.Err()
+}
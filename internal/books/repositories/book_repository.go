@@ -1,8 +1,11 @@
 package repositories
 
 import (
-	"errors"
+	"cmp"
 	"librarymvc/internal/books/models"
+	"librarymvc/internal/paging"
+	"slices"
+	"strings"
 	"sync"
 )
 
@@ -19,10 +22,28 @@ func NewBookRepository() models.BookRepository {
 	}
 }
 
+// isbnTaken reports whether a book other than id has the ISBN. The caller
+// must hold the lock.
+func (b *BookRepository) isbnTaken(isbn string, id int64) bool {
+	if isbn == "" {
+		return false
+	}
+	for _, book := range b.books {
+		if book.ISBN == isbn && book.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *BookRepository) CreateBook(book *models.Book) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if b.isbnTaken(book.ISBN, 0) {
+		return models.ErrDuplicateISBN
+	}
+
 	book.ID = b.nextID
 	b.nextID++
 	b.books[book.ID] = book
@@ -36,22 +57,85 @@ func (b *BookRepository) GetBook(id int64) (*models.Book, error) {
 
 	book, exists := b.books[id]
 	if !exists {
-		return nil, errors.New("book not found")
+		return nil, models.ErrBookNotFound
 	}
 
 	return book, nil
 }
 
+func (b *BookRepository) GetBookByISBN(isbn string) (*models.Book, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, book := range b.books {
+		if book.ISBN == isbn {
+			return book, nil
+		}
+	}
+
+	return nil, models.ErrBookNotFound
+}
+
 func (b *BookRepository) GetAllBooks() ([]*models.Book, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
+	return b.filter(func(*models.Book) bool { return true }), nil
+}
+
+// filter returns the books matching keep in ID order. The caller must hold
+// the lock.
+func (b *BookRepository) filter(keep func(book *models.Book) bool) []*models.Book {
 	books := make([]*models.Book, 0, len(b.books))
 	for _, book := range b.books {
-		books = append(books, book)
+		if keep(book) {
+			books = append(books, book)
+		}
 	}
 
-	return books, nil
+	slices.SortFunc(books, func(x, y *models.Book) int { return cmp.Compare(x.ID, y.ID) })
+	return books
+}
+
+var bookSorts = map[string]func(x, y *models.Book) int{
+	"title":  func(x, y *models.Book) int { return cmp.Compare(strings.ToLower(x.Title), strings.ToLower(y.Title)) },
+	"author": func(x, y *models.Book) int { return cmp.Compare(strings.ToLower(x.Author), strings.ToLower(y.Author)) },
+	"isbn":   func(x, y *models.Book) int { return cmp.Compare(x.ISBN, y.ISBN) },
+	"publisher": func(x, y *models.Book) int {
+		return cmp.Compare(strings.ToLower(x.Publisher), strings.ToLower(y.Publisher))
+	},
+	"publicationYear": func(x, y *models.Book) int { return cmp.Compare(x.PublicationYear, y.PublicationYear) },
+	"bookType":        func(x, y *models.Book) int { return cmp.Compare(x.BookType, y.BookType) },
+	"loanDuration":    func(x, y *models.Book) int { return cmp.Compare(x.LoanDuration, y.LoanDuration) },
+	"createdAt":       func(x, y *models.Book) int { return x.CreatedAt.Compare(y.CreatedAt) },
+	"updatedAt":       func(x, y *models.Book) int { return x.UpdatedAt.Compare(y.UpdatedAt) },
+}
+
+func (b *BookRepository) ListBooks(filter models.BookFilter, page paging.Request) (*paging.Page[*models.Book], error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	query := strings.ToLower(filter.Query)
+	author := strings.ToLower(filter.Author)
+	books := b.filter(func(book *models.Book) bool {
+		if query != "" &&
+			!strings.Contains(strings.ToLower(book.Title), query) &&
+			!strings.Contains(strings.ToLower(book.Author), query) {
+			return false
+		}
+		if author != "" && !strings.Contains(strings.ToLower(book.Author), author) {
+			return false
+		}
+		if filter.AuthorID != 0 && !slices.ContainsFunc(book.Authors, func(a *models.Author) bool { return a.ID == filter.AuthorID }) {
+			return false
+		}
+		if filter.SubjectID != 0 && !slices.ContainsFunc(book.Subjects, func(s *models.Subject) bool { return s.ID == filter.SubjectID }) {
+			return false
+		}
+		return filter.BookType == "" || book.BookType == filter.BookType
+	})
+
+	return paging.Slice(books, page, bookSorts), nil
 }
 
 func (b *BookRepository) UpdateBook(id int64, book *models.Book) error {
@@ -60,7 +144,10 @@ func (b *BookRepository) UpdateBook(id int64, book *models.Book) error {
 
 	_, exists := b.books[id]
 	if !exists {
-		return errors.New("book not found")
+		return models.ErrBookNotFound
+	}
+	if b.isbnTaken(book.ISBN, id) {
+		return models.ErrDuplicateISBN
 	}
 
 	book.ID = id
@@ -74,9 +161,34 @@ func (b *BookRepository) DeleteBook(id int64) error {
 
 	_, exists := b.books[id]
 	if !exists {
-		return errors.New("book not found")
+		return models.ErrBookNotFound
 	}
 
 	delete(b.books, id)
 	return nil
 }
+
+// Snapshot copies the current state and returns a function that restores it.
+// It is used by the in-memory unit of work to roll back failed transactions.
+func (b *BookRepository) Snapshot() func() {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	books := make(map[int64]models.Book, len(b.books))
+	for id, book := range b.books {
+		books[id] = *book
+	}
+	nextID := b.nextID
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.books = make(map[int64]*models.Book, len(books))
+		for id, book := range books {
+			book := book
+			b.books[id] = &book
+		}
+		b.nextID = nextID
+	}
+}
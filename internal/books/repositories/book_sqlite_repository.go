@@ -0,0 +1,321 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"librarymvc/internal/books/models"
+	"librarymvc/internal/database"
+	"librarymvc/internal/paging"
+	"strings"
+)
+
+type SQLiteBookRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteBookRepository(db database.DBTX) models.BookRepository {
+	return &SQLiteBookRepository{db: db}
+}
+
+const bookColumns = `id, title, author, COALESCE(isbn, ''), publisher, publication_year, edition, language, pages,
+	book_type, loan_duration, created_at, updated_at`
+
+func scanBook(row interface{ Scan(...any) error }) (*models.Book, error) {
+	var book models.Book
+	err := row.Scan(
+		&book.ID,
+		&book.Title,
+		&book.Author,
+		&book.ISBN,
+		&book.Publisher,
+		&book.PublicationYear,
+		&book.Edition,
+		&book.Language,
+		&book.Pages,
+		&book.BookType,
+		&book.LoanDuration,
+		&book.CreatedAt,
+		&book.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// isUniqueViolation reports whether err is SQLite refusing a duplicate ISBN.
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func (b *SQLiteBookRepository) CreateBook(book *models.Book) error {
+	result, err := b.db.Exec(
+		`INSERT INTO books (title, author, isbn, publisher, publication_year, edition, language, pages,
+		                    book_type, loan_duration, created_at, updated_at)
+		 VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		book.Title, book.Author, book.ISBN, book.Publisher, book.PublicationYear, book.Edition, book.Language, book.Pages,
+		book.BookType, book.LoanDuration, book.CreatedAt, book.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return models.ErrDuplicateISBN
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	book.ID = id
+
+	return b.saveLinks(book)
+}
+
+// saveLinks replaces the authors and subjects linked to the book; they must
+// already exist.
+func (b *SQLiteBookRepository) saveLinks(book *models.Book) error {
+	if _, err := b.db.Exec(`DELETE FROM book_authors WHERE book_id = ?`, book.ID); err != nil {
+		return err
+	}
+	for position, author := range book.Authors {
+		_, err := b.db.Exec(
+			`INSERT INTO book_authors (book_id, author_id, position) VALUES (?, ?, ?)`,
+			book.ID, author.ID, position,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := b.db.Exec(`DELETE FROM book_subjects WHERE book_id = ?`, book.ID); err != nil {
+		return err
+	}
+	for position, subject := range book.Subjects {
+		_, err := b.db.Exec(
+			`INSERT INTO book_subjects (book_id, subject_id, position) VALUES (?, ?, ?)`,
+			book.ID, subject.ID, position,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadLinks fills in the authors and subjects of the books.
+func (b *SQLiteBookRepository) loadLinks(books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.Book, len(books))
+	ids := make([]any, 0, len(books))
+	for _, book := range books {
+		book.Authors = make([]*models.Author, 0)
+		book.Subjects = make([]*models.Subject, 0)
+		byID[book.ID] = book
+		ids = append(ids, book.ID)
+	}
+	in := `(?` + strings.Repeat(`, ?`, len(ids)-1) + `)`
+
+	rows, err := b.db.Query(
+		`SELECT ba.book_id, a.id, a.name, a.created_at
+		 FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+		 WHERE ba.book_id IN `+in+` ORDER BY ba.book_id, ba.position`,
+		ids...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bookID int64
+		var author models.Author
+		if err := rows.Scan(&bookID, &author.ID, &author.Name, &author.CreatedAt); err != nil {
+			return err
+		}
+		byID[bookID].Authors = append(byID[bookID].Authors, &author)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = b.db.Query(
+		`SELECT bs.book_id, s.id, s.name, s.created_at
+		 FROM book_subjects bs JOIN subjects s ON s.id = bs.subject_id
+		 WHERE bs.book_id IN `+in+` ORDER BY bs.book_id, bs.position`,
+		ids...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bookID int64
+		var subject models.Subject
+		if err := rows.Scan(&bookID, &subject.ID, &subject.Name, &subject.CreatedAt); err != nil {
+			return err
+		}
+		byID[bookID].Subjects = append(byID[bookID].Subjects, &subject)
+	}
+
+	return rows.Err()
+}
+
+func (b *SQLiteBookRepository) GetBook(id int64) (*models.Book, error) {
+	row := b.db.QueryRow(`SELECT `+bookColumns+` FROM books WHERE id = ?`, id)
+
+	book, err := scanBook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrBookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.loadLinks(book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (b *SQLiteBookRepository) GetBookByISBN(isbn string) (*models.Book, error) {
+	row := b.db.QueryRow(`SELECT `+bookColumns+` FROM books WHERE isbn = ?`, isbn)
+
+	book, err := scanBook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrBookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.loadLinks(book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (b *SQLiteBookRepository) queryBooks(query string, args ...any) ([]*models.Book, error) {
+	rows, err := b.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := make([]*models.Book, 0)
+	for rows.Next() {
+		book, err := scanBook(rows)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := b.loadLinks(books...); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+func (b *SQLiteBookRepository) GetAllBooks() ([]*models.Book, error) {
+	return b.queryBooks(`SELECT ` + bookColumns + ` FROM books ORDER BY id`)
+}
+
+var bookSortColumns = map[string]string{
+	"id":              "id",
+	"title":           "title COLLATE NOCASE",
+	"author":          "author COLLATE NOCASE",
+	"isbn":            "isbn",
+	"publisher":       "publisher COLLATE NOCASE",
+	"publicationYear": "publication_year",
+	"bookType":        "book_type",
+	"loanDuration":    "loan_duration",
+	"createdAt":       "created_at",
+	"updatedAt":       "updated_at",
+}
+
+func (b *SQLiteBookRepository) ListBooks(filter models.BookFilter, page paging.Request) (*paging.Page[*models.Book], error) {
+	var where database.Where
+	if filter.Query != "" {
+		pattern := database.Contains(filter.Query)
+		where.Add(`(title LIKE ? ESCAPE '\' OR author LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+	if filter.Author != "" {
+		where.Add(`author LIKE ? ESCAPE '\'`, database.Contains(filter.Author))
+	}
+	if filter.BookType != "" {
+		where.Add(`book_type = ?`, filter.BookType)
+	}
+	if filter.AuthorID != 0 {
+		where.Add(`id IN (SELECT book_id FROM book_authors WHERE author_id = ?)`, filter.AuthorID)
+	}
+	if filter.SubjectID != 0 {
+		where.Add(`id IN (SELECT book_id FROM book_subjects WHERE subject_id = ?)`, filter.SubjectID)
+	}
+
+	var total int
+	if err := b.db.QueryRow(`SELECT COUNT(*) FROM books`+where.String(), where.Args()...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	books, err := b.queryBooks(
+		`SELECT `+bookColumns+` FROM books`+where.String()+page.OrderBy(bookSortColumns)+` LIMIT ? OFFSET ?`,
+		append(where.Args(), page.Limit, page.Offset())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(books, total, page), nil
+}
+
+func (b *SQLiteBookRepository) UpdateBook(id int64, book *models.Book) error {
+	result, err := b.db.Exec(
+		`UPDATE books
+		 SET title = ?, author = ?, isbn = NULLIF(?, ''), publisher = ?, publication_year = ?, edition = ?,
+		     language = ?, pages = ?, book_type = ?, loan_duration = ?, updated_at = ?
+		 WHERE id = ?`,
+		book.Title, book.Author, book.ISBN, book.Publisher, book.PublicationYear, book.Edition,
+		book.Language, book.Pages, book.BookType, book.LoanDuration, book.UpdatedAt, id,
+	)
+	if isUniqueViolation(err) {
+		return models.ErrDuplicateISBN
+	}
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrBookNotFound
+	}
+
+	book.ID = id
+	return b.saveLinks(book)
+}
+
+func (b *SQLiteBookRepository) DeleteBook(id int64) error {
+	result, err := b.db.Exec(`DELETE FROM books WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrBookNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"cmp"
+	"librarymvc/internal/books/models"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type SubjectRepository struct {
+	subjects map[int64]*models.Subject
+	mu       sync.RWMutex
+	nextID   int64
+}
+
+func NewSubjectRepository() models.SubjectRepository {
+	return &SubjectRepository{
+		subjects: make(map[int64]*models.Subject),
+		nextID:   1,
+	}
+}
+
+func (s *SubjectRepository) FindOrCreateSubject(subject *models.Subject) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.subjects {
+		if strings.EqualFold(existing.Name, subject.Name) {
+			*subject = *existing
+			return nil
+		}
+	}
+
+	subject.ID = s.nextID
+	s.nextID++
+	stored := *subject
+	s.subjects[subject.ID] = &stored
+
+	return nil
+}
+
+func (s *SubjectRepository) GetSubject(id int64) (*models.Subject, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subject, exists := s.subjects[id]
+	if !exists {
+		return nil, models.ErrSubjectNotFound
+	}
+
+	return subject, nil
+}
+
+func (s *SubjectRepository) GetAllSubjects() ([]*models.Subject, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subjects := make([]*models.Subject, 0, len(s.subjects))
+	for _, subject := range s.subjects {
+		subjects = append(subjects, subject)
+	}
+	slices.SortFunc(subjects, func(x, y *models.Subject) int {
+		return cmp.Compare(strings.ToLower(x.Name), strings.ToLower(y.Name))
+	})
+
+	return subjects, nil
+}
+
+// Snapshot copies the current state and returns a function that restores it.
+// It is used by the in-memory unit of work to roll back failed transactions.
+func (s *SubjectRepository) Snapshot() func() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subjects := make(map[int64]models.Subject, len(s.subjects))
+	for id, subject := range s.subjects {
+		subjects[id] = *subject
+	}
+	nextID := s.nextID
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.subjects = make(map[int64]*models.Subject, len(subjects))
+		for id, subject := range subjects {
+			subject := subject
+			s.subjects[id] = &subject
+		}
+		s.nextID = nextID
+	}
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"librarymvc/internal/books/models"
+	"librarymvc/internal/database"
+)
+
+type SQLiteSubjectRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteSubjectRepository(db database.DBTX) models.SubjectRepository {
+	return &SQLiteSubjectRepository{db: db}
+}
+
+const subjectColumns = `id, name, created_at`
+
+func scanSubject(row interface{ Scan(...any) error }) (*models.Subject, error) {
+	var subject models.Subject
+	if err := row.Scan(&subject.ID, &subject.Name, &subject.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &subject, nil
+}
+
+// FindOrCreateSubject relies on the name column comparing case-insensitively.
+func (s *SQLiteSubjectRepository) FindOrCreateSubject(subject *models.Subject) error {
+	existing, err := scanSubject(s.db.QueryRow(`SELECT `+subjectColumns+` FROM subjects WHERE name = ?`, subject.Name))
+	if err == nil {
+		*subject = *existing
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	result, err := s.db.Exec(`INSERT INTO subjects (name, created_at) VALUES (?, ?)`, subject.Name, subject.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	subject.ID = id
+
+	return nil
+}
+
+func (s *SQLiteSubjectRepository) GetSubject(id int64) (*models.Subject, error) {
+	subject, err := scanSubject(s.db.QueryRow(`SELECT `+subjectColumns+` FROM subjects WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrSubjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return subject, nil
+}
+
+func (s *SQLiteSubjectRepository) GetAllSubjects() ([]*models.Subject, error) {
+	rows, err := s.db.Query(`SELECT ` + subjectColumns + ` FROM subjects ORDER BY name, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subjects := make([]*models.Subject, 0)
+	for rows.Next() {
+		subject, err := scanSubject(rows)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, subject)
+	}
+
+	return subjects, rows.Err()
+}
@@ -1,44 +1,502 @@
 package services
 
 import (
-	"errors"
 	"librarymvc/internal/books/models"
+	"librarymvc/internal/clock"
+	copyModel "librarymvc/internal/copies/models"
+	holdService "librarymvc/internal/holds/services"
+	"librarymvc/internal/isbn"
+	"librarymvc/internal/paging"
+	policyModel "librarymvc/internal/policies/models"
+	searchModel "librarymvc/internal/search/models"
+	"librarymvc/internal/unitofwork"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type BookService struct {
-	bookRepository models.BookRepository
+	bookRepository    models.BookRepository
+	authorRepository  models.AuthorRepository
+	subjectRepository models.SubjectRepository
+	copyRepository    copyModel.CopyRepository
+	policyRepository  policyModel.LoanPolicyRepository
+	searchIndex       searchModel.SearchIndex
+	unitOfWork        unitofwork.UnitOfWork
+	clock             clock.Clock
 }
 
-func NewBookService(bookRepository models.BookRepository) models.BookService {
-	return &BookService{bookRepository: bookRepository}
+func NewBookService(
+	bookRepository models.BookRepository,
+	authorRepository models.AuthorRepository,
+	subjectRepository models.SubjectRepository,
+	copyRepository copyModel.CopyRepository,
+	policyRepository policyModel.LoanPolicyRepository,
+	searchIndex searchModel.SearchIndex,
+	unitOfWork unitofwork.UnitOfWork,
+	clock clock.Clock,
+) models.BookService {
+	return &BookService{
+		bookRepository:    bookRepository,
+		authorRepository:  authorRepository,
+		subjectRepository: subjectRepository,
+		copyRepository:    copyRepository,
+		policyRepository:  policyRepository,
+		searchIndex:       searchIndex,
+		unitOfWork:        unitOfWork,
+		clock:             clock,
+	}
+}
+
+// splitNames trims the names, drops blank ones and repeats that differ only
+// in case, keeping the first spelling.
+func splitNames(names []string) []string {
+	kept := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.ContainsFunc(kept, func(k string) bool { return strings.EqualFold(k, name) }) {
+			continue
+		}
+		kept = append(kept, name)
+	}
+	return kept
+}
+
+// normalizeDetails cleans up the bibliographic fields. A book sent with only
+// Author gets the authors listed in it, separated by ";".
+func (b BookService) normalizeDetails(book *models.Book) error {
+	var names []string
+	if len(book.Authors) == 0 {
+		names = strings.Split(book.Author, ";")
+	}
+	for _, author := range book.Authors {
+		names = append(names, author.Name)
+	}
+	names = splitNames(names)
+	if len(names) == 0 {
+		return models.ErrAuthorRequired
+	}
+	book.Authors = make([]*models.Author, 0, len(names))
+	for _, name := range names {
+		book.Authors = append(book.Authors, &models.Author{Name: name})
+	}
+
+	names = nil
+	for _, subject := range book.Subjects {
+		names = append(names, subject.Name)
+	}
+	book.Subjects = make([]*models.Subject, 0, len(names))
+	for _, name := range splitNames(names) {
+		book.Subjects = append(book.Subjects, &models.Subject{Name: name})
+	}
+
+	if book.PublicationYear < 0 || book.PublicationYear > b.clock.Now().Year()+1 {
+		return models.ErrInvalidYear.WithMessage("publication year " + strconv.Itoa(book.PublicationYear) + " is out of range")
+	}
+	if book.Pages < 0 {
+		return models.ErrNegativePages
+	}
+	book.Publisher = strings.TrimSpace(book.Publisher)
+	book.Edition = strings.TrimSpace(book.Edition)
+	book.Language = strings.TrimSpace(book.Language)
+	return nil
+}
+
+// linkAuthorities finds or creates the book's authors and subjects, so that
+// they have IDs before the book is saved, and derives Author from them.
+func linkAuthorities(repos *unitofwork.Repositories, book *models.Book, now time.Time) error {
+	names := make([]string, 0, len(book.Authors))
+	for _, author := range book.Authors {
+		author.CreatedAt = now
+		if err := repos.Authors.FindOrCreateAuthor(author); err != nil {
+			return err
+		}
+		names = append(names, author.Name)
+	}
+	book.Author = strings.Join(names, "; ")
+
+	for _, subject := range book.Subjects {
+		subject.CreatedAt = now
+		if err := repos.Subjects.FindOrCreateSubject(subject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeISBN stores the ISBN as an ISBN-13, whichever form it was given in.
+func normalizeISBN(book *models.Book) error {
+	if strings.TrimSpace(book.ISBN) == "" {
+		book.ISBN = ""
+		return nil
+	}
+
+	isbn13, err := isbn.Parse(book.ISBN)
+	if err != nil {
+		return models.ErrInvalidISBN.WithMessage("invalid ISBN " + book.ISBN + ": " + err.Error())
+	}
+	book.ISBN = isbn13
+	return nil
+}
+
+// validateBookType checks that the book type refers to an existing loan policy.
+func (b BookService) validateBookType(book *models.Book) error {
+	if book.BookType == "" {
+		return models.ErrBookTypeRequired
+	}
+	if _, err := b.policyRepository.GetPolicyByCode(book.BookType); err != nil {
+		return models.ErrUnknownBookType.WithMessage("unknown book type: " + book.BookType)
+	}
+	if book.LoanDuration < 0 {
+		return models.ErrNegativeLoanPeriod
+	}
+	return nil
 }
 
 func (b BookService) CreateBook(book *models.Book) error {
 	if book.Title == "" {
-		return errors.New("title is required")
+		return models.ErrTitleRequired
 	}
-	if book.Author == "" {
-		return errors.New("author is required")
+	if err := b.normalizeDetails(book); err != nil {
+		return err
 	}
 	if book.Quantity < 0 {
-		return errors.New("quantity cannot be negative")
+		return models.ErrNegativeQuantity
+	}
+	if err := b.validateBookType(book); err != nil {
+		return err
+	}
+	if err := normalizeISBN(book); err != nil {
+		return err
+	}
+
+	now := b.clock.Now()
+	book.CreatedAt = now
+	book.UpdatedAt = now
+
+	// Quantity on creation is the number of copies to register
+	err := b.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		if err := linkAuthorities(repos, book, now); err != nil {
+			return err
+		}
+		if err := repos.Books.CreateBook(book); err != nil {
+			return err
+		}
+
+		for i := 0; i < book.Quantity; i++ {
+			copy := &copyModel.Copy{
+				BookID:    book.ID,
+				Condition: "good",
+				Status:    "available",
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := repos.Copies.CreateCopy(copy); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	return b.bookRepository.CreateBook(book)
+
+	book.ISBN10, _ = isbn.To10(book.ISBN)
+	b.searchIndex.Index(searchModel.NewDocument(book))
+	return nil
+}
+
+// withDerived fills the derived fields: Quantity from the available copies
+// and ISBN10 from the ISBN.
+func (b BookService) withDerived(book *models.Book) (*models.Book, error) {
+	available, err := b.copyRepository.CountAvailableCopies(book.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	book.Quantity = available
+	book.ISBN10, _ = isbn.To10(book.ISBN)
+	return book, nil
 }
 
 func (b BookService) GetBook(id int64) (*models.Book, error) {
-	return b.bookRepository.GetBook(id)
+	book, err := b.bookRepository.GetBook(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.withDerived(book)
+}
+
+func (b BookService) GetBookByISBN(raw string) (*models.Book, error) {
+	isbn13, err := isbn.Parse(raw)
+	if err != nil {
+		return nil, models.ErrInvalidISBN.WithMessage("invalid ISBN " + raw + ": " + err.Error())
+	}
+
+	book, err := b.bookRepository.GetBookByISBN(isbn13)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.withDerived(book)
 }
 
 func (b BookService) GetAllBooks() ([]*models.Book, error) {
-	return b.bookRepository.GetAllBooks()
+	books, err := b.bookRepository.GetAllBooks()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, book := range books {
+		if _, err := b.withDerived(book); err != nil {
+			return nil, err
+		}
+	}
+
+	return books, nil
+}
+
+func (b BookService) ListBooks(filter models.BookFilter, page paging.Request) (*paging.Page[*models.Book], error) {
+	if err := page.CheckSort(models.BookSortFields); err != nil {
+		return nil, err
+	}
+
+	books, err := b.bookRepository.ListBooks(filter, page.Normalize())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, book := range books.Items {
+		if _, err := b.withDerived(book); err != nil {
+			return nil, err
+		}
+	}
+
+	return books, nil
 }
 
+// UpdateBook ignores Quantity; stock is managed through the book's copies.
 func (b BookService) UpdateBook(id int64, book *models.Book) error {
-	return b.bookRepository.UpdateBook(id, book)
+	if err := b.normalizeDetails(book); err != nil {
+		return err
+	}
+	if err := b.validateBookType(book); err != nil {
+		return err
+	}
+	if err := normalizeISBN(book); err != nil {
+		return err
+	}
+
+	now := b.clock.Now()
+	book.UpdatedAt = now
+	err := b.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		if err := linkAuthorities(repos, book, now); err != nil {
+			return err
+		}
+		return repos.Books.UpdateBook(id, book)
+	})
+	if err != nil {
+		return err
+	}
+
+	book.ISBN10, _ = isbn.To10(book.ISBN)
+	b.searchIndex.Index(searchModel.NewDocument(book))
+	return nil
+}
+
+func (b BookService) GetAuthor(id int64) (*models.Author, error) {
+	return b.authorRepository.GetAuthor(id)
+}
+
+func (b BookService) GetAllAuthors() ([]*models.Author, error) {
+	return b.authorRepository.GetAllAuthors()
+}
+
+func (b BookService) GetSubject(id int64) (*models.Subject, error) {
+	return b.subjectRepository.GetSubject(id)
+}
+
+func (b BookService) GetAllSubjects() ([]*models.Subject, error) {
+	return b.subjectRepository.GetAllSubjects()
 }
 
 func (b BookService) DeleteBook(id int64) error {
-	return b.bookRepository.DeleteBook(id) // Provável erro aqui!
+	err := b.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		copies, err := repos.Copies.GetBookCopies(id)
+		if err != nil {
+			return err
+		}
+
+		for _, copy := range copies {
+			if copy.Status == "on_loan" {
+				return models.ErrBookHasCopiesOnLoan
+			}
+		}
+
+		holds, err := repos.Holds.GetBookHolds(id)
+		if err != nil {
+			return err
+		}
+		if len(holds) > 0 {
+			return models.ErrBookHasActiveHolds
+		}
+
+		for _, copy := range copies {
+			if err := repos.Copies.DeleteCopy(copy.ID); err != nil {
+				return err
+			}
+		}
+
+		return repos.Books.DeleteBook(id)
+	})
+	if err != nil {
+		return err
+	}
+
+	b.searchIndex.Remove(id)
+	return nil
+}
+
+func (b BookService) MergeBooks(keepID, duplicateID int64) (*models.Book, error) {
+	if keepID == duplicateID {
+		return nil, models.ErrMergeSameBook
+	}
+
+	var kept *models.Book
+	err := b.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		keep, err := repos.Books.GetBook(keepID)
+		if err != nil {
+			return err
+		}
+		duplicate, err := repos.Books.GetBook(duplicateID)
+		if err != nil {
+			return err
+		}
+		if !sameWork(keep, duplicate) {
+			return models.ErrMergeDifferentWorks
+		}
+
+		now := b.clock.Now()
+		copies, err := repos.Copies.GetBookCopies(duplicateID)
+		if err != nil {
+			return err
+		}
+		for _, copy := range copies {
+			copy.BookID = keepID
+			copy.UpdatedAt = now
+			if err := repos.Copies.UpdateCopy(copy.ID, copy); err != nil {
+				return err
+			}
+		}
+
+		if err := repos.Loans.MoveBookLoans(duplicateID, keepID); err != nil {
+			return err
+		}
+		if err := repos.Holds.MoveBookHolds(duplicateID, keepID); err != nil {
+			return err
+		}
+		if err := cancelRepeatedHolds(repos, keepID, now); err != nil {
+			return err
+		}
+		// Copies on the shelf of one record go to those waiting for the other
+		if _, err := holdService.OfferAvailableCopies(repos, keepID, now); err != nil {
+			return err
+		}
+
+		if err := repos.Books.DeleteBook(duplicateID); err != nil {
+			return err
+		}
+
+		// The duplicate may have authors, subjects or the ISBN the kept
+		// record lacks
+		changed := mergeAuthorities(keep, duplicate)
+		if keep.ISBN == "" && duplicate.ISBN != "" {
+			keep.ISBN = duplicate.ISBN
+			changed = true
+		}
+		if changed {
+			keep.UpdatedAt = now
+			if err := repos.Books.UpdateBook(keepID, keep); err != nil {
+				return err
+			}
+		}
+
+		kept = keep
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b.searchIndex.Remove(duplicateID)
+	b.searchIndex.Index(searchModel.NewDocument(kept))
+	return b.withDerived(kept)
 }
 
+// sameWork reports whether two records plausibly describe the same work:
+// they have the same ISBN, or titles that differ only in case, accents and
+// punctuation.
+func sameWork(a, b *models.Book) bool {
+	if a.ISBN != "" && a.ISBN == b.ISBN {
+		return true
+	}
+	return slices.Equal(searchModel.Tokenize(a.Title), searchModel.Tokenize(b.Title))
+}
+
+// mergeAuthorities adds to the book kept the authors and subjects only the
+// duplicate had, after its own, and reports whether it gained any.
+func mergeAuthorities(keep, duplicate *models.Book) bool {
+	changed := false
+	for _, author := range duplicate.Authors {
+		if !slices.ContainsFunc(keep.Authors, func(a *models.Author) bool { return a.ID == author.ID }) {
+			keep.Authors = append(keep.Authors, author)
+			changed = true
+		}
+	}
+	for _, subject := range duplicate.Subjects {
+		if !slices.ContainsFunc(keep.Subjects, func(s *models.Subject) bool { return s.ID == subject.ID }) {
+			keep.Subjects = append(keep.Subjects, subject)
+			changed = true
+		}
+	}
+
+	names := make([]string, 0, len(keep.Authors))
+	for _, author := range keep.Authors {
+		names = append(names, author.Name)
+	}
+	keep.Author = strings.Join(names, "; ")
+	return changed
+}
+
+// cancelRepeatedHolds keeps only the first place in the queue of a member
+// who was waiting for both merged records.
+func cancelRepeatedHolds(repos *unitofwork.Repositories, bookID int64, now time.Time) error {
+	holds, err := repos.Holds.GetBookHolds(bookID)
+	if err != nil {
+		return err
+	}
+
+	queued := make(map[int64]bool)
+	for _, hold := range holds {
+		if !queued[hold.UserID] {
+			queued[hold.UserID] = true
+			continue
+		}
+
+		wasReady := hold.Status == "ready"
+		hold.Status = "cancelled"
+		hold.UpdatedAt = now
+		if err := repos.Holds.UpdateHold(hold); err != nil {
+			return err
+		}
+		if wasReady {
+			if err := holdService.ReleaseHeldCopy(repos, hold.CopyID, bookID, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"librarymvc/internal/books/models"
+	searchRepository "librarymvc/internal/search/repositories"
+	"librarymvc/internal/testenv"
+)
+
+func newBookService(storage *testenv.Storage) models.BookService {
+	repos := storage.Repos
+	return NewBookService(repos.Books, repos.Authors, repos.Subjects, repos.Copies, repos.Policies,
+		searchRepository.NewSearchIndex(), storage.UnitOfWork, storage.Clock)
+}
+
+func createBook(t *testing.T, books models.BookService, book *models.Book) *models.Book {
+	t.Helper()
+	book.BookType = "emprestavel"
+	if err := books.CreateBook(book); err != nil {
+		t.Fatalf("CreateBook(%q) = %v", book.Title, err)
+	}
+	return book
+}
+
+func authorNames(book *models.Book) []string {
+	names := make([]string, 0, len(book.Authors))
+	for _, author := range book.Authors {
+		names = append(names, author.Name)
+	}
+	return names
+}
+
+func subjectNames(book *models.Book) []string {
+	names := make([]string, 0, len(book.Subjects))
+	for _, subject := range book.Subjects {
+		names = append(names, subject.Name)
+	}
+	return names
+}
+
+func TestMergeBooksTakesTheDuplicatesAuthoritiesAndISBN(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		books := newBookService(storage)
+		keep := createBook(t, books, &models.Book{Title: "Memórias Póstumas de Brás Cubas", Author: "Machado de Assis", Quantity: 1})
+		duplicate := createBook(t, books, &models.Book{
+			Title:    "Memorias postumas de Bras Cubas",
+			Author:   "Machado de Assis; Gustavo Franco",
+			ISBN:     "9788535910667",
+			Subjects: []*models.Subject{{Name: "Literatura brasileira"}},
+			Quantity: 2,
+		})
+
+		merged, err := books.MergeBooks(keep.ID, duplicate.ID)
+		if err != nil {
+			t.Fatalf("MergeBooks() = %v", err)
+		}
+
+		stored, err := books.GetBook(keep.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, got := range []*models.Book{merged, stored} {
+			if authors := authorNames(got); len(authors) != 2 || authors[0] != "Machado de Assis" || authors[1] != "Gustavo Franco" {
+				t.Errorf("authors = %q, want Machado de Assis and Gustavo Franco", authors)
+			}
+			if got.Author != "Machado de Assis; Gustavo Franco" {
+				t.Errorf("author = %q, want both names", got.Author)
+			}
+			if subjects := subjectNames(got); len(subjects) != 1 || subjects[0] != "Literatura brasileira" {
+				t.Errorf("subjects = %q, want Literatura brasileira", subjects)
+			}
+			if got.ISBN != "9788535910667" || got.Quantity != 3 {
+				t.Errorf("ISBN %q, %d copies; want 9788535910667, 3", got.ISBN, got.Quantity)
+			}
+		}
+		if _, err := books.GetBook(duplicate.ID); !errors.Is(err, models.ErrBookNotFound) {
+			t.Errorf("GetBook(duplicate) = %v, want %v", err, models.ErrBookNotFound)
+		}
+	})
+}
+
+func TestMergeBooksNeedsTheSameWork(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		books := newBookService(storage)
+		keep := createBook(t, books, &models.Book{Title: "Dom Casmurro", Author: "Machado de Assis", ISBN: "9788535911640", Quantity: 1})
+		other := createBook(t, books, &models.Book{Title: "Quincas Borba", Author: "Machado de Assis", Quantity: 1})
+		reprint := createBook(t, books, &models.Book{Title: "Dom Casmurro (edição de bolso)", Author: "Machado de Assis", Quantity: 1})
+
+		for _, duplicate := range []*models.Book{other, reprint} {
+			if _, err := books.MergeBooks(keep.ID, duplicate.ID); !errors.Is(err, models.ErrMergeDifferentWorks) {
+				t.Errorf("merging %q = %v, want %v", duplicate.Title, err, models.ErrMergeDifferentWorks)
+			}
+			if _, err := books.GetBook(duplicate.ID); err != nil {
+				t.Errorf("GetBook(%q) after a refused merge = %v", duplicate.Title, err)
+			}
+		}
+	})
+}
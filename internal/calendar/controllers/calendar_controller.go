@@ -0,0 +1,91 @@
+package calendar
+
+import (
+	"librarymvc/internal/apperror"
+	"librarymvc/internal/calendar/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarController struct {
+	calendarService models.CalendarService
+}
+
+func NewCalendarController(calendarService models.CalendarService) *CalendarController {
+	return &CalendarController{calendarService: calendarService}
+}
+
+func (c *CalendarController) RegisterRoutes(r *gin.Engine) {
+	calendar := r.Group("/calendar")
+	{
+		calendar.GET("", c.GetCalendar)
+		calendar.PUT("/hours/:weekday", c.UpdateOpeningHours)
+		calendar.POST("/closures", c.CreateClosure)
+		calendar.DELETE("/closures/:id", c.DeleteClosure)
+	}
+}
+
+func (c *CalendarController) GetCalendar(ctx *gin.Context) {
+	calendar, err := c.calendarService.GetCalendar()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, calendar)
+}
+
+func (c *CalendarController) UpdateOpeningHours(ctx *gin.Context) {
+	weekday, err := strconv.Atoi(ctx.Param("weekday"))
+	if err != nil {
+		ctx.Error(apperror.NewBadRequest("invalid_weekday", "Invalid weekday"))
+		return
+	}
+
+	var hours models.OpeningHours
+	if err := ctx.ShouldBindJSON(&hours); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+	hours.Weekday = time.Weekday(weekday)
+
+	if err := c.calendarService.UpdateOpeningHours(&hours); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hours)
+}
+
+func (c *CalendarController) CreateClosure(ctx *gin.Context) {
+	var closure models.Closure
+	if err := ctx.ShouldBindJSON(&closure); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	if err := c.calendarService.CreateClosure(&closure); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, closure)
+}
+
+func (c *CalendarController) DeleteClosure(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("closure"))
+		return
+	}
+
+	if err := c.calendarService.DeleteClosure(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// DateLayout is the format of closure dates.
+const DateLayout = "2006-01-02"
+
+// OpeningHours is the library's schedule for one day of the week.
+type OpeningHours struct {
+	Weekday   time.Weekday `json:"weekday"` // 0 = domingo ... 6 = sábado
+	Open      bool         `json:"open"`
+	OpensAt   string       `json:"opensAt"`  // HH:MM
+	ClosesAt  string       `json:"closesAt"` // HH:MM
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// Closure is a date on which the library is closed whatever the weekly
+// schedule says, e.g. a holiday.
+type Closure struct {
+	ID        int64     `json:"ID"`
+	Date      string    `json:"date" binding:"required"` // YYYY-MM-DD
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// DefaultOpeningHours are created on a fresh install: open on weekdays and
+// Saturday morning, closed on Sunday.
+var DefaultOpeningHours = []OpeningHours{
+	{Weekday: time.Sunday, Open: false},
+	{Weekday: time.Monday, Open: true, OpensAt: "08:00", ClosesAt: "18:00"},
+	{Weekday: time.Tuesday, Open: true, OpensAt: "08:00", ClosesAt: "18:00"},
+	{Weekday: time.Wednesday, Open: true, OpensAt: "08:00", ClosesAt: "18:00"},
+	{Weekday: time.Thursday, Open: true, OpensAt: "08:00", ClosesAt: "18:00"},
+	{Weekday: time.Friday, Open: true, OpensAt: "08:00", ClosesAt: "18:00"},
+	{Weekday: time.Saturday, Open: true, OpensAt: "08:00", ClosesAt: "12:00"},
+}
+
+// Calendar is the weekly schedule together with the dated closures. It tells
+// the fine policy which days not to charge for.
+type Calendar struct {
+	Hours    []*OpeningHours `json:"hours"`
+	Closures []*Closure      `json:"closures"`
+}
+
+// LoadCalendar reads the whole calendar from the repository.
+func LoadCalendar(repo CalendarRepository) (*Calendar, error) {
+	hours, err := repo.GetOpeningHours()
+	if err != nil {
+		return nil, err
+	}
+
+	closures, err := repo.GetClosures()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Calendar{Hours: hours, Closures: closures}, nil
+}
+
+// IsClosed reports whether the library is closed on the day of t. Weekdays
+// without a schedule count as open.
+func (c *Calendar) IsClosed(t time.Time) bool {
+	for _, hours := range c.Hours {
+		if hours.Weekday == t.Weekday() && !hours.Open {
+			return true
+		}
+	}
+
+	date := t.Format(DateLayout)
+	for _, closure := range c.Closures {
+		if closure.Date == date {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpenDay moves t forward one day at a time until it falls on a day the
+// library is open. If no open day is found within a year t is returned as is.
+func (c *Calendar) NextOpenDay(t time.Time) time.Time {
+	for days := 0; days <= 366; days++ {
+		if day := t.AddDate(0, 0, days); !c.IsClosed(day) {
+			return day
+		}
+	}
+	return t
+}
@@ -0,0 +1,12 @@
+package models
+
+type CalendarRepository interface {
+	// GetOpeningHours returns the weekly schedule ordered by weekday.
+	GetOpeningHours() ([]*OpeningHours, error)
+	// SaveOpeningHours creates or replaces the schedule of hours.Weekday.
+	SaveOpeningHours(hours *OpeningHours) error
+	CreateClosure(closure *Closure) error
+	DeleteClosure(id int64) error
+	// GetClosures returns the closures ordered by date.
+	GetClosures() ([]*Closure, error)
+}
@@ -0,0 +1,10 @@
+package models
+
+type CalendarService interface {
+	GetCalendar() (*Calendar, error)
+	UpdateOpeningHours(hours *OpeningHours) error
+	CreateClosure(closure *Closure) error
+	DeleteClosure(id int64) error
+	// EnsureDefaultHours seeds DefaultOpeningHours on a fresh install.
+	EnsureDefaultHours() error
+}
@@ -0,0 +1,13 @@
+package models
+
+import "librarymvc/internal/apperror"
+
+var (
+	ErrClosureNotFound   = apperror.NewNotFound("closure_not_found", "closure not found")
+	ErrClosureExists     = apperror.NewConflict("closure_exists", "a closure already exists on this date")
+	ErrInvalidWeekday    = apperror.NewValidation("invalid_weekday", "weekday must be between 0 (Sunday) and 6 (Saturday)")
+	ErrInvalidOpeningAt  = apperror.NewValidation("invalid_opening_time", "opening time must be in HH:MM format")
+	ErrInvalidClosingAt  = apperror.NewValidation("invalid_closing_time", "closing time must be in HH:MM format")
+	ErrClosesBeforeOpens = apperror.NewValidation("closes_before_opens", "closing time must be after opening time")
+	ErrInvalidDate       = apperror.NewValidation("invalid_date", "date must be in YYYY-MM-DD format")
+)
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"librarymvc/internal/calendar/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+type CalendarRepository struct {
+	hours    map[time.Weekday]*models.OpeningHours
+	closures map[int64]*models.Closure
+	mu       sync.RWMutex
+	nextID   int64
+}
+
+func NewCalendarRepository() models.CalendarRepository {
+	return &CalendarRepository{
+		hours:    make(map[time.Weekday]*models.OpeningHours),
+		closures: make(map[int64]*models.Closure),
+		nextID:   1,
+	}
+}
+
+func (c *CalendarRepository) GetOpeningHours() ([]*models.OpeningHours, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	hours := make([]*models.OpeningHours, 0, len(c.hours))
+	for _, day := range c.hours {
+		hours = append(hours, day)
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].Weekday < hours[j].Weekday })
+
+	return hours, nil
+}
+
+func (c *CalendarRepository) SaveOpeningHours(hours *models.OpeningHours) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hours[hours.Weekday] = hours
+	return nil
+}
+
+func (c *CalendarRepository) CreateClosure(closure *models.Closure) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, existing := range c.closures {
+		if existing.Date == closure.Date {
+			return models.ErrClosureExists
+		}
+	}
+
+	closure.ID = c.nextID
+	c.closures[closure.ID] = closure
+	c.nextID++
+
+	return nil
+}
+
+func (c *CalendarRepository) DeleteClosure(id int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.closures[id]; !exists {
+		return models.ErrClosureNotFound
+	}
+
+	delete(c.closures, id)
+	return nil
+}
+
+func (c *CalendarRepository) GetClosures() ([]*models.Closure, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	closures := make([]*models.Closure, 0, len(c.closures))
+	for _, closure := range c.closures {
+		closures = append(closures, closure)
+	}
+	sort.Slice(closures, func(i, j int) bool { return closures[i].Date < closures[j].Date })
+
+	return closures, nil
+}
+
+// Snapshot copies the current state and returns a function that restores it.
+// It is used by the in-memory unit of work to roll back failed transactions.
+func (c *CalendarRepository) Snapshot() func() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	hours := make(map[time.Weekday]models.OpeningHours, len(c.hours))
+	for weekday, day := range c.hours {
+		hours[weekday] = *day
+	}
+	closures := make(map[int64]models.Closure, len(c.closures))
+	for id, closure := range c.closures {
+		closures[id] = *closure
+	}
+	nextID := c.nextID
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.hours = make(map[time.Weekday]*models.OpeningHours, len(hours))
+		for weekday, day := range hours {
+			day := day
+			c.hours[weekday] = &day
+		}
+		c.closures = make(map[int64]*models.Closure, len(closures))
+		for id, closure := range closures {
+			closure := closure
+			c.closures[id] = &closure
+		}
+		c.nextID = nextID
+	}
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"librarymvc/internal/calendar/models"
+	"librarymvc/internal/database"
+	"strings"
+)
+
+type SQLiteCalendarRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteCalendarRepository(db database.DBTX) models.CalendarRepository {
+	return &SQLiteCalendarRepository{db: db}
+}
+
+func (c *SQLiteCalendarRepository) GetOpeningHours() ([]*models.OpeningHours, error) {
+	rows, err := c.db.Query(`SELECT weekday, open, opens_at, closes_at, updated_at FROM opening_hours ORDER BY weekday`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hours := make([]*models.OpeningHours, 0, 7)
+	for rows.Next() {
+		var day models.OpeningHours
+		if err := rows.Scan(&day.Weekday, &day.Open, &day.OpensAt, &day.ClosesAt, &day.UpdatedAt); err != nil {
+			return nil, err
+		}
+		hours = append(hours, &day)
+	}
+
+	return hours, rows.Err()
+}
+
+func (c *SQLiteCalendarRepository) SaveOpeningHours(hours *models.OpeningHours) error {
+	_, err := c.db.Exec(
+		`INSERT INTO opening_hours (weekday, open, opens_at, closes_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (weekday) DO UPDATE
+		 SET open = excluded.open, opens_at = excluded.opens_at, closes_at = excluded.closes_at, updated_at = excluded.updated_at`,
+		hours.Weekday, hours.Open, hours.OpensAt, hours.ClosesAt, hours.UpdatedAt,
+	)
+	return err
+}
+
+func (c *SQLiteCalendarRepository) CreateClosure(closure *models.Closure) error {
+	result, err := c.db.Exec(
+		`INSERT INTO closures (date, reason, created_at) VALUES (?, ?, ?)`,
+		closure.Date, closure.Reason, closure.CreatedAt,
+	)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return models.ErrClosureExists
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	closure.ID = id
+
+	return nil
+}
+
+func (c *SQLiteCalendarRepository) DeleteClosure(id int64) error {
+	result, err := c.db.Exec(`DELETE FROM closures WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrClosureNotFound
+	}
+
+	return nil
+}
+
+func (c *SQLiteCalendarRepository) GetClosures() ([]*models.Closure, error) {
+	rows, err := c.db.Query(`SELECT id, date, reason, created_at FROM closures ORDER BY date`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	closures := make([]*models.Closure, 0)
+	for rows.Next() {
+		var closure models.Closure
+		if err := rows.Scan(&closure.ID, &closure.Date, &closure.Reason, &closure.CreatedAt); err != nil {
+			return nil, err
+		}
+		closures = append(closures, &closure)
+	}
+
+	return closures, rows.Err()
+}
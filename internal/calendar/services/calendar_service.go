@@ -0,0 +1,84 @@
+package services
+
+import (
+	"librarymvc/internal/calendar/models"
+	"librarymvc/internal/clock"
+	"strings"
+	"time"
+)
+
+type CalendarService struct {
+	calendarRepository models.CalendarRepository
+	clock              clock.Clock
+}
+
+func NewCalendarService(calendarRepository models.CalendarRepository, clock clock.Clock) models.CalendarService {
+	return &CalendarService{
+		calendarRepository: calendarRepository,
+		clock:              clock,
+	}
+}
+
+func (c *CalendarService) GetCalendar() (*models.Calendar, error) {
+	return models.LoadCalendar(c.calendarRepository)
+}
+
+func (c *CalendarService) UpdateOpeningHours(hours *models.OpeningHours) error {
+	if hours.Weekday < time.Sunday || hours.Weekday > time.Saturday {
+		return models.ErrInvalidWeekday
+	}
+
+	if hours.Open {
+		opensAt, err := time.Parse("15:04", hours.OpensAt)
+		if err != nil {
+			return models.ErrInvalidOpeningAt
+		}
+		closesAt, err := time.Parse("15:04", hours.ClosesAt)
+		if err != nil {
+			return models.ErrInvalidClosingAt
+		}
+		if !closesAt.After(opensAt) {
+			return models.ErrClosesBeforeOpens
+		}
+	} else {
+		hours.OpensAt = ""
+		hours.ClosesAt = ""
+	}
+
+	hours.UpdatedAt = c.clock.Now()
+	return c.calendarRepository.SaveOpeningHours(hours)
+}
+
+func (c *CalendarService) CreateClosure(closure *models.Closure) error {
+	closure.Date = strings.TrimSpace(closure.Date)
+	if _, err := time.Parse(models.DateLayout, closure.Date); err != nil {
+		return models.ErrInvalidDate
+	}
+	closure.Reason = strings.TrimSpace(closure.Reason)
+
+	closure.CreatedAt = c.clock.Now()
+	return c.calendarRepository.CreateClosure(closure)
+}
+
+func (c *CalendarService) DeleteClosure(id int64) error {
+	return c.calendarRepository.DeleteClosure(id)
+}
+
+func (c *CalendarService) EnsureDefaultHours() error {
+	hours, err := c.calendarRepository.GetOpeningHours()
+	if err != nil {
+		return err
+	}
+	if len(hours) > 0 {
+		return nil
+	}
+
+	for _, day := range models.DefaultOpeningHours {
+		day := day
+		if err := c.UpdateOpeningHours(&day); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package catalog
+
+import (
+	"io"
+	"librarymvc/internal/catalog/models"
+	"librarymvc/internal/marc"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogController struct {
+	catalogService models.CatalogService
+}
+
+func NewCatalogController(catalogService models.CatalogService) *CatalogController {
+	return &CatalogController{catalogService: catalogService}
+}
+
+func (c *CatalogController) RegisterRoutes(r *gin.Engine) {
+	catalog := r.Group("/catalog")
+	{
+		catalog.POST("/import", c.Import)
+		catalog.GET("/export", c.Export)
+	}
+}
+
+// Import reads a MARC file uploaded as the "file" field of a form, or sent
+// as the request body. With dryRun=true nothing is saved.
+func (c *CatalogController) Import(ctx *gin.Context) {
+	data, err := readUpload(ctx)
+	if err != nil {
+		ctx.Error(models.ErrInvalidUpload.WithMessage("could not read the uploaded file: " + err.Error()))
+		return
+	}
+
+	report, err := c.catalogService.Import(data, models.ImportOptions{
+		DryRun:   ctx.Query("dryRun") == "true",
+		BookType: strings.TrimSpace(ctx.Query("bookType")),
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+func readUpload(ctx *gin.Context) ([]byte, error) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, models.MaxImportSize)
+	if ctx.ContentType() != "multipart/form-data" {
+		return io.ReadAll(ctx.Request.Body)
+	}
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// Export downloads the catalog as MARCXML, or as ISO 2709 with format=marc.
+func (c *CatalogController) Export(ctx *gin.Context) {
+	format := ctx.DefaultQuery("format", string(marc.XML))
+	data, err := c.catalogService.Export(format)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	contentType, filename := "application/marcxml+xml", "catalog.xml"
+	if marc.Format(format) == marc.Binary {
+		contentType, filename = "application/marc", "catalog.mrc"
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Data(http.StatusOK, contentType, data)
+}
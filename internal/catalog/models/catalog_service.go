@@ -0,0 +1,9 @@
+package models
+
+type CatalogService interface {
+	// Import creates or updates a book for each record of a MARC 21 file,
+	// in ISO 2709 or MARCXML. Records are matched to books by ISBN.
+	Import(data []byte, options ImportOptions) (*ImportReport, error)
+	// Export encodes the whole catalog in format, "marc" or "marcxml".
+	Export(format string) ([]byte, error)
+}
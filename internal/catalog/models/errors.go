@@ -0,0 +1,9 @@
+package models
+
+import "librarymvc/internal/apperror"
+
+var (
+	ErrUnknownFormat = apperror.NewBadRequest("unknown_format", "format must be marc or marcxml")
+	ErrInvalidMARC   = apperror.NewValidation("invalid_marc", "the file is not valid MARC 21")
+	ErrInvalidUpload = apperror.NewBadRequest("invalid_upload", "could not read the uploaded file")
+)
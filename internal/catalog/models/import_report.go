@@ -0,0 +1,47 @@
+package models
+
+// DefaultBookType is given to imported books when the import does not name
+// one.
+const DefaultBookType = "emprestavel"
+
+// MaxImportSize limits the size of an uploaded MARC file.
+const MaxImportSize = 32 << 20
+
+type ImportOptions struct {
+	// DryRun reports what the import would do without saving anything.
+	DryRun bool
+	// BookType is the loan policy of the books created.
+	BookType string
+}
+
+// ImportReport tells what happened to each record of an imported file.
+type ImportReport struct {
+	DryRun  bool            `json:"dryRun"`
+	Format  string          `json:"format"` // marc ou marcxml
+	Created int             `json:"created"`
+	Updated int             `json:"updated"`
+	Skipped int             `json:"skipped"`
+	Records []*RecordResult `json:"records"`
+}
+
+type RecordResult struct {
+	Record int    `json:"record"` // posição do registro no arquivo, a partir de 1
+	Action string `json:"action"` // created, updated ou skipped
+	BookID int64  `json:"bookID,omitempty"`
+	Title  string `json:"title"`
+	ISBN   string `json:"isbn,omitempty"`
+	Reason string `json:"reason,omitempty"` // por que o registro foi ignorado
+}
+
+// Add counts the result in the report.
+func (r *ImportReport) Add(result *RecordResult) {
+	switch result.Action {
+	case "created":
+		r.Created++
+	case "updated":
+		r.Updated++
+	default:
+		r.Skipped++
+	}
+	r.Records = append(r.Records, result)
+}
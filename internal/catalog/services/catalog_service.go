@@ -0,0 +1,186 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	bookModel "librarymvc/internal/books/models"
+	"librarymvc/internal/catalog/models"
+	"librarymvc/internal/marc"
+	"librarymvc/internal/paging"
+	"strconv"
+	"strings"
+)
+
+type CatalogService struct {
+	bookService bookModel.BookService
+}
+
+// NewCatalogService imports and exports through the book service, so that
+// imported books are validated and indexed like any other.
+func NewCatalogService(bookService bookModel.BookService) models.CatalogService {
+	return &CatalogService{bookService: bookService}
+}
+
+func (c *CatalogService) Import(data []byte, options models.ImportOptions) (*models.ImportReport, error) {
+	records, err := marc.Read(data)
+	if err != nil {
+		return nil, models.ErrInvalidMARC.WithMessage("invalid MARC 21 file: " + err.Error())
+	}
+
+	if options.BookType == "" {
+		options.BookType = models.DefaultBookType
+	}
+
+	report := &models.ImportReport{DryRun: options.DryRun, Format: string(marc.Detect(data))}
+	seen := make(map[string]int)
+	for i, record := range records {
+		result := c.importRecord(record, options, seen)
+		result.Record = i + 1
+		if result.ISBN != "" && seen[result.ISBN] == 0 {
+			seen[result.ISBN] = result.Record
+		}
+		report.Add(result)
+	}
+
+	return report, nil
+}
+
+// importRecord creates the book of a record, or updates the book it
+// matches. seen maps the ISBNs of the records before it to their position.
+func (c *CatalogService) importRecord(record *marc.Record, options models.ImportOptions, seen map[string]int) *models.RecordResult {
+	book, err := bookFromRecord(record)
+	result := &models.RecordResult{Title: book.Title, ISBN: book.ISBN}
+	if err != nil {
+		return skipped(result, err.Error())
+	}
+	if first, ok := seen[book.ISBN]; ok {
+		return skipped(result, "same ISBN as record "+strconv.Itoa(first))
+	}
+
+	existing, err := c.findBook(book)
+	if err != nil {
+		return skipped(result, err.Error())
+	}
+
+	if existing == nil {
+		result.Action = "created"
+		if options.DryRun {
+			return result
+		}
+
+		book.BookType = options.BookType
+		if err := c.bookService.CreateBook(book); err != nil {
+			return skipped(result, err.Error())
+		}
+		result.BookID = book.ID
+		return result
+	}
+
+	result.BookID = existing.ID
+	if sameDetails(existing, book) {
+		return skipped(result, "unchanged")
+	}
+	result.Action = "updated"
+	if options.DryRun {
+		return result
+	}
+
+	// The loan settings are the library's, not part of the record
+	book.BookType = existing.BookType
+	book.LoanDuration = existing.LoanDuration
+	book.CreatedAt = existing.CreatedAt
+	if err := c.bookService.UpdateBook(existing.ID, book); err != nil {
+		return skipped(result, err.Error())
+	}
+	return result
+}
+
+// findBook finds the book a record describes: the book with its ISBN or,
+// for a record without one, a book with no ISBN and the same title and
+// authors. It returns nil when there is none.
+func (c *CatalogService) findBook(book *bookModel.Book) (*bookModel.Book, error) {
+	if book.ISBN != "" {
+		existing, err := c.bookService.GetBookByISBN(book.ISBN)
+		if errors.Is(err, bookModel.ErrBookNotFound) {
+			return nil, nil
+		}
+		return existing, err
+	}
+
+	candidates, err := c.bookService.ListBooks(
+		bookModel.BookFilter{Query: book.Title},
+		paging.Request{Limit: paging.MaxLimit},
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates.Items {
+		if candidate.ISBN == "" && strings.EqualFold(candidate.Title, book.Title) &&
+			strings.EqualFold(authorNames(candidate), authorNames(book)) {
+			return candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+func skipped(result *models.RecordResult, reason string) *models.RecordResult {
+	result.Action = "skipped"
+	result.Reason = reason
+	return result
+}
+
+// sameDetails reports whether importing imported over book would change
+// any of the fields a record carries.
+func sameDetails(book, imported *bookModel.Book) bool {
+	subjects := func(b *bookModel.Book) string {
+		names := make([]string, 0, len(b.Subjects))
+		for _, subject := range b.Subjects {
+			names = append(names, subject.Name)
+		}
+		return strings.Join(names, "; ")
+	}
+
+	return book.Title == imported.Title &&
+		strings.EqualFold(authorNames(book), authorNames(imported)) &&
+		strings.EqualFold(subjects(book), subjects(imported)) &&
+		book.Publisher == imported.Publisher &&
+		book.PublicationYear == imported.PublicationYear &&
+		book.Edition == imported.Edition &&
+		book.Language == imported.Language &&
+		book.Pages == imported.Pages
+}
+
+func authorNames(book *bookModel.Book) string {
+	names := make([]string, 0, len(book.Authors))
+	for _, author := range book.Authors {
+		names = append(names, author.Name)
+	}
+	return strings.Join(names, "; ")
+}
+
+func (c *CatalogService) Export(format string) ([]byte, error) {
+	write := marc.WriteXML
+	switch marc.Format(format) {
+	case marc.XML:
+	case marc.Binary:
+		write = marc.WriteBinary
+	default:
+		return nil, models.ErrUnknownFormat
+	}
+
+	books, err := c.bookService.GetAllBooks()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*marc.Record, 0, len(books))
+	for _, book := range books {
+		records = append(records, recordFromBook(book))
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	bookModel "librarymvc/internal/books/models"
+	bookService "librarymvc/internal/books/services"
+	"librarymvc/internal/catalog/models"
+	searchRepository "librarymvc/internal/search/repositories"
+	"librarymvc/internal/testenv"
+)
+
+func newBookService(storage *testenv.Storage) bookModel.BookService {
+	repos := storage.Repos
+	return bookService.NewBookService(repos.Books, repos.Authors, repos.Subjects, repos.Copies, repos.Policies,
+		searchRepository.NewSearchIndex(), storage.UnitOfWork, storage.Clock)
+}
+
+func catalogBooks() []*bookModel.Book {
+	return []*bookModel.Book{
+		{
+			Title:           "Memórias Póstumas de Brás Cubas",
+			Author:          "Machado de Assis; Gustavo Franco",
+			Subjects:        []*bookModel.Subject{{Name: "Literatura brasileira"}, {Name: "Romance -- Século XIX"}},
+			ISBN:            "9788535910667",
+			Publisher:       "Penguin-Companhia",
+			PublicationYear: 2014,
+			Edition:         "1ª edição",
+			Language:        "por",
+			Pages:           432,
+		},
+		{
+			Title:           "Vidas Secas",
+			Author:          "Graciliano Ramos",
+			Publisher:       "Record",
+			PublicationYear: 1938,
+			Language:        "por",
+			Pages:           176,
+		},
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []string{"marc", "marcxml"} {
+		t.Run(format, func(t *testing.T) {
+			testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+				source := newBookService(storage)
+				for _, book := range catalogBooks() {
+					book.BookType = "emprestavel"
+					if err := source.CreateBook(book); err != nil {
+						t.Fatalf("CreateBook(%q) = %v", book.Title, err)
+					}
+				}
+				data, err := NewCatalogService(source).Export(format)
+				if err != nil {
+					t.Fatalf("Export() = %v", err)
+				}
+
+				// Into an empty library of the same kind
+				target := testenv.Memory(t)
+				if storage.Driver == "sqlite" {
+					target = testenv.SQLite(t)
+				}
+				books := newBookService(target)
+				catalog := NewCatalogService(books)
+				report, err := catalog.Import(data, models.ImportOptions{})
+				if err != nil {
+					t.Fatalf("Import() = %v", err)
+				}
+				if report.Format != format || report.Created != 2 || report.Skipped != 0 {
+					t.Fatalf("report = %+v, want 2 created from %s", report, format)
+				}
+
+				imported, err := books.GetAllBooks()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(imported) != 2 {
+					t.Fatalf("imported %d books, want 2", len(imported))
+				}
+				for _, want := range catalogBooks() {
+					got := findTitle(imported, want.Title)
+					if got == nil {
+						t.Errorf("%q was not imported", want.Title)
+						continue
+					}
+					if got.ISBN != want.ISBN || got.Author != want.Author || got.Publisher != want.Publisher ||
+						got.PublicationYear != want.PublicationYear || got.Edition != want.Edition ||
+						got.Language != want.Language || got.Pages != want.Pages || got.BookType != models.DefaultBookType {
+						t.Errorf("imported %+v, want %+v", got, want)
+					}
+					if subjects, wanted := subjectNames(got), subjectNames(want); subjects != wanted {
+						t.Errorf("%q: subjects %q, want %q", want.Title, subjects, wanted)
+					}
+				}
+
+				// Importing the same file again changes nothing
+				report, err = catalog.Import(data, models.ImportOptions{})
+				if err != nil {
+					t.Fatalf("second Import() = %v", err)
+				}
+				if report.Created != 0 || report.Updated != 0 || report.Skipped != 2 {
+					t.Errorf("second report = %+v, want both unchanged", report)
+				}
+			})
+		})
+	}
+}
+
+func findTitle(books []*bookModel.Book, title string) *bookModel.Book {
+	for _, book := range books {
+		if book.Title == title {
+			return book
+		}
+	}
+	return nil
+}
+
+func subjectNames(book *bookModel.Book) string {
+	names := make([]string, 0, len(book.Subjects))
+	for _, subject := range book.Subjects {
+		names = append(names, subject.Name)
+	}
+	return strings.Join(names, "; ")
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	bookModel "librarymvc/internal/books/models"
+	"librarymvc/internal/isbn"
+	"librarymvc/internal/marc"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	yearPattern  = regexp.MustCompile(`\d{4}`)
+	pagesPattern = regexp.MustCompile(`(\d+)\s*(p\b|p\.|pages|páginas)`)
+)
+
+// bookFromRecord maps a bibliographic record to a book:
+//
+//	020 $a        ISBN (the first valid one)
+//	041 $a        language, else 008/35-37
+//	1XX, 7XX $a   authors, main entry first
+//	245 $a $b     title and subtitle
+//	250 $a        edition
+//	260, 264 $b   publisher; $c the year, else 008/07-10
+//	300 $a        pages
+//	6XX           subjects, subdivisions joined by " -- "
+func bookFromRecord(record *marc.Record) (*bookModel.Book, error) {
+	book := &bookModel.Book{}
+
+	if title := record.Get("245"); title != nil {
+		book.Title = trimISBD(title.Subfield('a'))
+		if subtitle := trimISBD(title.Subfield('b')); subtitle != "" {
+			book.Title += ": " + subtitle
+		}
+	}
+	if book.Title == "" {
+		return book, errors.New("no title (245 $a)")
+	}
+
+	isbn13, err := recordISBN(record)
+	if err != nil {
+		return book, err
+	}
+	book.ISBN = isbn13
+
+	for _, field := range record.Fields {
+		switch field.Tag {
+		case "100", "110", "111", "700", "710", "711":
+			if name := trimISBD(field.Subfield('a')); name != "" {
+				book.Authors = append(book.Authors, &bookModel.Author{Name: name})
+			}
+		case "600", "610", "611", "630", "650", "651":
+			var parts []string
+			for _, subfield := range field.Subfields {
+				switch subfield.Code {
+				case 'a', 'v', 'x', 'y', 'z':
+					if part := trimISBD(subfield.Value); part != "" {
+						parts = append(parts, part)
+					}
+				}
+			}
+			if len(parts) > 0 {
+				book.Subjects = append(book.Subjects, &bookModel.Subject{Name: strings.Join(parts, " -- ")})
+			}
+		}
+	}
+	if len(book.Authors) == 0 {
+		return book, errors.New("no author (1XX or 7XX $a)")
+	}
+
+	fixed := record.ControlValue("008")
+	if edition := record.Get("250"); edition != nil {
+		book.Edition = strings.TrimSpace(strings.TrimRight(edition.Subfield('a'), " /:;,="))
+	}
+	if publication := publicationField(record); publication != nil {
+		book.Publisher = trimISBD(publication.Subfield('b'))
+		book.PublicationYear, _ = strconv.Atoi(yearPattern.FindString(publication.Subfield('c')))
+	}
+	if book.PublicationYear == 0 && len(fixed) >= 11 {
+		book.PublicationYear, _ = strconv.Atoi(fixed[7:11])
+	}
+	if extent := record.Get("300"); extent != nil {
+		if match := pagesPattern.FindStringSubmatch(extent.Subfield('a')); match != nil {
+			book.Pages, _ = strconv.Atoi(match[1])
+		}
+	}
+	if language := record.Get("041"); language != nil {
+		book.Language = strings.TrimSpace(language.Subfield('a'))
+	}
+	if book.Language == "" && len(fixed) >= 38 {
+		if code := strings.TrimSpace(fixed[35:38]); code != "" && code != "|||" && code != "und" {
+			book.Language = code
+		}
+	}
+
+	return book, nil
+}
+
+// recordISBN is the first valid ISBN in 020 $a, where it may be followed by
+// a qualifier such as "(pbk.)". A record whose only ISBNs are invalid is
+// rejected rather than imported without one.
+func recordISBN(record *marc.Record) (string, error) {
+	var invalid string
+	for _, field := range record.GetAll("020") {
+		words := strings.Fields(field.Subfield('a'))
+		if len(words) == 0 {
+			continue
+		}
+		if isbn13, err := isbn.Parse(words[0]); err == nil {
+			return isbn13, nil
+		}
+		if invalid == "" {
+			invalid = words[0]
+		}
+	}
+	if invalid != "" {
+		return "", fmt.Errorf("invalid ISBN %s in 020 $a", invalid)
+	}
+	return "", nil
+}
+
+// publicationField is the 264 publication statement, or the older 260.
+func publicationField(record *marc.Record) *marc.Field {
+	for _, field := range record.GetAll("264") {
+		if field.Ind2 == '1' {
+			return field
+		}
+	}
+	return record.Get("260")
+}
+
+// trimISBD removes the punctuation ISBD puts at the end of a subfield to
+// separate it from the next one.
+func trimISBD(s string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s), " /:;,=."))
+}
+
+// recordFromBook maps a book back to a record with the fields that
+// bookFromRecord reads, so that an exported catalog imports unchanged.
+func recordFromBook(book *bookModel.Book) *marc.Record {
+	record := &marc.Record{Leader: "00000nam a2200000 i 4500"}
+	record.AddControl("001", strconv.FormatInt(book.ID, 10))
+	record.AddControl("005", book.UpdatedAt.Format("20060102150405.0"))
+	record.AddControl("008", fixedField(book))
+
+	if book.ISBN != "" {
+		record.AddData("020", ' ', ' ', marc.Subfield{Code: 'a', Value: book.ISBN})
+	}
+	if book.Language != "" {
+		record.AddData("041", '0', ' ', marc.Subfield{Code: 'a', Value: book.Language})
+	}
+	if len(book.Authors) > 0 {
+		record.AddData("100", nameForm(book.Authors[0].Name), ' ', marc.Subfield{Code: 'a', Value: book.Authors[0].Name})
+	}
+	record.AddData("245", '1', '0', marc.Subfield{Code: 'a', Value: book.Title})
+	if book.Edition != "" {
+		record.AddData("250", ' ', ' ', marc.Subfield{Code: 'a', Value: book.Edition})
+	}
+	if book.Publisher != "" || book.PublicationYear != 0 {
+		var subfields []marc.Subfield
+		if book.Publisher != "" {
+			subfields = append(subfields, marc.Subfield{Code: 'b', Value: book.Publisher})
+		}
+		if book.PublicationYear != 0 {
+			subfields = append(subfields, marc.Subfield{Code: 'c', Value: strconv.Itoa(book.PublicationYear)})
+		}
+		record.AddData("264", ' ', '1', subfields...)
+	}
+	if book.Pages > 0 {
+		record.AddData("300", ' ', ' ', marc.Subfield{Code: 'a', Value: strconv.Itoa(book.Pages) + " p."})
+	}
+	for _, subject := range book.Subjects {
+		var subfields []marc.Subfield
+		for i, part := range strings.Split(subject.Name, " -- ") {
+			code := byte('a')
+			if i > 0 {
+				code = 'x'
+			}
+			subfields = append(subfields, marc.Subfield{Code: code, Value: part})
+		}
+		record.AddData("650", ' ', '4', subfields...)
+	}
+	if len(book.Authors) > 1 {
+		for _, author := range book.Authors[1:] {
+			record.AddData("700", nameForm(author.Name), ' ', marc.Subfield{Code: 'a', Value: author.Name})
+		}
+	}
+
+	return record
+}
+
+// fixedField builds the 40 characters of 008: date entered, publication
+// date and, when the language is a three-letter MARC code, the language.
+func fixedField(book *bookModel.Book) string {
+	fixed := []byte(book.CreatedAt.Format("060102") + "nuuuu    xx " + strings.Repeat(" ", 17) + "und d")
+	if book.PublicationYear > 0 && book.PublicationYear <= 9999 {
+		copy(fixed[6:11], fmt.Sprintf("s%04d", book.PublicationYear))
+	}
+	if len(book.Language) == 3 {
+		copy(fixed[35:38], strings.ToLower(book.Language))
+	}
+	return string(fixed)
+}
+
+// nameForm is the first indicator of a personal name: 1 when it is given
+// surname first, 0 otherwise.
+func nameForm(name string) byte {
+	if strings.Contains(name, ",") {
+		return '1'
+	}
+	return '0'
+}
@@ -0,0 +1,62 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the services what time it is, so due dates, fines and
+// deadlines can be computed against a controlled time instead of the
+// wall clock.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+// New returns the wall clock.
+func New() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Fake is a Clock that only moves when told to. It is safe for concurrent use.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set moves the clock to now.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// Advance moves the clock forward by d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// AdvanceDays moves the clock forward by whole calendar days, keeping the
+// wall-clock time across DST changes.
+func (f *Fake) AdvanceDays(days int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.AddDate(0, 0, days)
+}
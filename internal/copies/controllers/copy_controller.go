@@ -0,0 +1,142 @@
+package copies
+
+import (
+	"librarymvc/internal/apperror"
+	"librarymvc/internal/copies/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CopyController struct {
+	copyService models.CopyService
+}
+
+func NewCopyController(copyService models.CopyService) *CopyController {
+	return &CopyController{copyService: copyService}
+}
+
+func (c *CopyController) RegisterRoutes(r *gin.Engine) {
+	copies := r.Group("/copies")
+	{
+		copies.GET("", c.GetAllCopies)
+		copies.GET("/:id", c.GetCopy)
+		copies.GET("/barcode/:barcode", c.GetCopyByBarcode)
+		copies.POST("", c.CreateCopy)
+		copies.PUT("/:id", c.UpdateCopy)
+		copies.DELETE("/:id", c.DeleteCopy)
+	}
+
+	books := r.Group("/books")
+	{
+		books.GET("/:id/copies", c.GetBookCopies)
+	}
+}
+
+func (c *CopyController) CreateCopy(ctx *gin.Context) {
+	var copy models.Copy
+
+	if err := ctx.ShouldBindJSON(&copy); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	err := c.copyService.CreateCopy(&copy)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, copy)
+}
+
+func (c *CopyController) GetCopy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("copy"))
+		return
+	}
+
+	copy, err := c.copyService.GetCopy(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, copy)
+}
+
+func (c *CopyController) GetCopyByBarcode(ctx *gin.Context) {
+	copy, err := c.copyService.GetCopyByBarcode(ctx.Param("barcode"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, copy)
+}
+
+func (c *CopyController) GetAllCopies(ctx *gin.Context) {
+	copies, err := c.copyService.GetAllCopies()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, copies)
+}
+
+func (c *CopyController) GetBookCopies(ctx *gin.Context) {
+	bookID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("book"))
+		return
+	}
+
+	copies, err := c.copyService.GetBookCopies(bookID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, copies)
+}
+
+func (c *CopyController) UpdateCopy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("copy"))
+		return
+	}
+
+	var copy models.Copy
+	if err := ctx.ShouldBindJSON(&copy); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	err = c.copyService.UpdateCopy(id, &copy)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, copy)
+}
+
+func (c *CopyController) DeleteCopy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("copy"))
+		return
+	}
+
+	err = c.copyService.DeleteCopy(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type Copy struct {
+	ID            int64     `json:"ID"`
+	BookID        int64     `json:"bookID"`
+	Barcode       string    `json:"barcode"`                                                                 // gerado automaticamente se vazio
+	Condition     string    `json:"condition" binding:"omitempty,oneof=good worn damaged"`                   // good, worn, damaged
+	ShelfLocation string    `json:"shelfLocation"`                                                           // Ex.: "Estante 3 - Prateleira B"
+	Status        string    `json:"status" binding:"omitempty,oneof=available on_loan on_hold damaged lost"` // available, on_loan, on_hold, damaged, lost
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// DefaultBarcode is the barcode assigned to copies created without one.
+func DefaultBarcode(id int64) string {
+	return fmt.Sprintf("LIB%08d", id)
+}
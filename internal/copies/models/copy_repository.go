@@ -0,0 +1,23 @@
+package models
+
+type CopyRepository interface {
+	CreateCopy(copy *Copy) error
+	GetCopy(id int64) (*Copy, error)
+	GetCopyByBarcode(barcode string) (*Copy, error)
+	GetBookCopies(bookID int64) ([]*Copy, error)
+	GetAllCopies() ([]*Copy, error)
+	// UpdateCopy saves every field but the status, which changes only
+	// through the atomic moves below.
+	UpdateCopy(id int64, copy *Copy) error
+	DeleteCopy(id int64) error
+	CountAvailableCopies(bookID int64) (int, error)
+	// ReserveCopy atomically moves an available copy to on_loan.
+	ReserveCopy(id int64) error
+	// ReserveAvailableCopy atomically picks any available copy of the book and moves it to on_loan.
+	ReserveAvailableCopy(bookID int64) (*Copy, error)
+	// ReleaseCopy atomically moves an on_loan copy back to available.
+	ReleaseCopy(id int64) error
+	// TransitionCopy atomically moves a copy from one status to another,
+	// failing if the copy is not in the from status.
+	TransitionCopy(id int64, from, to string) error
+}
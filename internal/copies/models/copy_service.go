@@ -0,0 +1,11 @@
+package models
+
+type CopyService interface {
+	CreateCopy(copy *Copy) error
+	GetCopy(id int64) (*Copy, error)
+	GetCopyByBarcode(barcode string) (*Copy, error)
+	GetBookCopies(bookID int64) ([]*Copy, error)
+	GetAllCopies() ([]*Copy, error)
+	UpdateCopy(id int64, copy *Copy) error
+	DeleteCopy(id int64) error
+}
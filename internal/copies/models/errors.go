@@ -0,0 +1,17 @@
+package models
+
+import "librarymvc/internal/apperror"
+
+var (
+	ErrCopyNotFound       = apperror.NewNotFound("copy_not_found", "copy not found")
+	ErrBarcodeExists      = apperror.NewConflict("barcode_exists", "barcode already exists")
+	ErrNoCopyAvailable    = apperror.NewConflict("no_copy_available", "book is not available")
+	ErrCopyNotAvailable   = apperror.NewConflict("copy_not_available", "copy is not available")
+	ErrCopyNotOnLoan      = apperror.NewConflict("copy_not_on_loan", "copy is not on loan")
+	ErrCopyStatusMismatch = apperror.NewConflict("copy_status_mismatch", "copy is not in the expected status")
+	ErrCopyOnLoan         = apperror.NewConflict("copy_on_loan", "copy is on loan")
+	ErrCopyOnHold         = apperror.NewConflict("copy_on_hold", "copy is set aside for a hold")
+	ErrCirculationStatus  = apperror.NewValidation("circulation_status", "copies are only put on loan or on hold through the circulation desk")
+	ErrInvalidCondition   = apperror.NewValidation("invalid_condition", "invalid condition")
+	ErrInvalidStatus      = apperror.NewValidation("invalid_status", "invalid status")
+)
@@ -0,0 +1,251 @@
+package repositories
+
+import (
+	"fmt"
+	"librarymvc/internal/clock"
+	"librarymvc/internal/copies/models"
+	"sort"
+	"sync"
+)
+
+type CopyRepository struct {
+	copies map[int64]*models.Copy
+	mu     sync.RWMutex
+	nextID int64
+	clock  clock.Clock
+}
+
+// NewCopyRepository creates the repository. clock stamps the status changes
+// it makes on its own.
+func NewCopyRepository(clock clock.Clock) models.CopyRepository {
+	return &CopyRepository{
+		copies: make(map[int64]*models.Copy),
+		nextID: 1,
+		clock:  clock,
+	}
+}
+
+func (c *CopyRepository) barcodeTaken(barcode string, exceptID int64) bool {
+	for id, copy := range c.copies {
+		if id != exceptID && copy.Barcode == barcode {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CopyRepository) CreateCopy(copy *models.Copy) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if copy.Barcode == "" {
+		copy.Barcode = models.DefaultBarcode(c.nextID)
+	}
+	if c.barcodeTaken(copy.Barcode, 0) {
+		return models.ErrBarcodeExists
+	}
+
+	copy.ID = c.nextID
+	c.copies[copy.ID] = copy
+	c.nextID++
+
+	return nil
+}
+
+func (c *CopyRepository) GetCopy(id int64) (*models.Copy, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	copy, exists := c.copies[id]
+	if !exists {
+		return nil, models.ErrCopyNotFound
+	}
+
+	return copy, nil
+}
+
+func (c *CopyRepository) GetCopyByBarcode(barcode string) (*models.Copy, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, copy := range c.copies {
+		if copy.Barcode == barcode {
+			return copy, nil
+		}
+	}
+
+	return nil, models.ErrCopyNotFound
+}
+
+func (c *CopyRepository) GetBookCopies(bookID int64) ([]*models.Copy, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	copies := make([]*models.Copy, 0)
+	for _, copy := range c.copies {
+		if copy.BookID == bookID {
+			copies = append(copies, copy)
+		}
+	}
+	sortCopies(copies)
+
+	return copies, nil
+}
+
+func (c *CopyRepository) GetAllCopies() ([]*models.Copy, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	copies := make([]*models.Copy, 0, len(c.copies))
+	for _, copy := range c.copies {
+		copies = append(copies, copy)
+	}
+	sortCopies(copies)
+
+	return copies, nil
+}
+
+func (c *CopyRepository) UpdateCopy(id int64, copy *models.Copy) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, exists := c.copies[id]
+	if !exists {
+		return models.ErrCopyNotFound
+	}
+	if c.barcodeTaken(copy.Barcode, id) {
+		return models.ErrBarcodeExists
+	}
+
+	copy.ID = id
+	copy.Status = existing.Status
+	c.copies[id] = copy
+	return nil
+}
+
+func (c *CopyRepository) DeleteCopy(id int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, exists := c.copies[id]
+	if !exists {
+		return models.ErrCopyNotFound
+	}
+
+	delete(c.copies, id)
+	return nil
+}
+
+func (c *CopyRepository) CountAvailableCopies(bookID int64) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	count := 0
+	for _, copy := range c.copies {
+		if copy.BookID == bookID && copy.Status == "available" {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (c *CopyRepository) ReserveCopy(id int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	copy, exists := c.copies[id]
+	if !exists {
+		return models.ErrCopyNotFound
+	}
+	if copy.Status != "available" {
+		return models.ErrCopyNotAvailable
+	}
+
+	copy.Status = "on_loan"
+	copy.UpdatedAt = c.clock.Now()
+	return nil
+}
+
+func (c *CopyRepository) ReserveAvailableCopy(bookID int64) (*models.Copy, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Lowest ID first, matching the SQLite implementation
+	var reserved *models.Copy
+	for _, copy := range c.copies {
+		if copy.BookID == bookID && copy.Status == "available" && (reserved == nil || copy.ID < reserved.ID) {
+			reserved = copy
+		}
+	}
+	if reserved == nil {
+		return nil, models.ErrNoCopyAvailable
+	}
+
+	reserved.Status = "on_loan"
+	reserved.UpdatedAt = c.clock.Now()
+	return reserved, nil
+}
+
+func (c *CopyRepository) ReleaseCopy(id int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	copy, exists := c.copies[id]
+	if !exists {
+		return models.ErrCopyNotFound
+	}
+	if copy.Status != "on_loan" {
+		return models.ErrCopyNotOnLoan
+	}
+
+	copy.Status = "available"
+	copy.UpdatedAt = c.clock.Now()
+	return nil
+}
+
+func (c *CopyRepository) TransitionCopy(id int64, from, to string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	copy, exists := c.copies[id]
+	if !exists {
+		return models.ErrCopyNotFound
+	}
+	if copy.Status != from {
+		return models.ErrCopyStatusMismatch.WithMessage(fmt.Sprintf("copy is not %s", from))
+	}
+
+	copy.Status = to
+	copy.UpdatedAt = c.clock.Now()
+	return nil
+}
+
+// Snapshot copies the current state and returns a function that restores it.
+// It is used by the in-memory unit of work to roll back failed transactions.
+func (c *CopyRepository) Snapshot() func() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	copies := make(map[int64]models.Copy, len(c.copies))
+	for id, copy := range c.copies {
+		copies[id] = *copy
+	}
+	nextID := c.nextID
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.copies = make(map[int64]*models.Copy, len(copies))
+		for id, copy := range copies {
+			copy := copy
+			c.copies[id] = &copy
+		}
+		c.nextID = nextID
+	}
+}
+
+func sortCopies(copies []*models.Copy) {
+	sort.Slice(copies, func(i, j int) bool { return copies[i].ID < copies[j].ID })
+}
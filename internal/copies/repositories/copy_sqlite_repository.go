@@ -0,0 +1,272 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"librarymvc/internal/clock"
+	"librarymvc/internal/copies/models"
+	"librarymvc/internal/database"
+	"strings"
+)
+
+type SQLiteCopyRepository struct {
+	db    database.DBTX
+	clock clock.Clock
+}
+
+// NewSQLiteCopyRepository creates the repository. clock stamps the status
+// changes it makes on its own.
+func NewSQLiteCopyRepository(db database.DBTX, clock clock.Clock) models.CopyRepository {
+	return &SQLiteCopyRepository{db: db, clock: clock}
+}
+
+const copyColumns = `id, book_id, barcode, condition, shelf_location, status, created_at, updated_at`
+
+func scanCopy(row interface{ Scan(...any) error }) (*models.Copy, error) {
+	var copy models.Copy
+	err := row.Scan(
+		&copy.ID,
+		&copy.BookID,
+		&copy.Barcode,
+		&copy.Condition,
+		&copy.ShelfLocation,
+		&copy.Status,
+		&copy.CreatedAt,
+		&copy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &copy, nil
+}
+
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func (c *SQLiteCopyRepository) queryCopies(query string, args ...any) ([]*models.Copy, error) {
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	copies := make([]*models.Copy, 0)
+	for rows.Next() {
+		copy, err := scanCopy(rows)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, copy)
+	}
+
+	return copies, rows.Err()
+}
+
+func (c *SQLiteCopyRepository) CreateCopy(copy *models.Copy) error {
+	// A NULL barcode is replaced by the default one once the ID is known
+	var barcode any
+	if copy.Barcode != "" {
+		barcode = copy.Barcode
+	}
+
+	result, err := c.db.Exec(
+		`INSERT INTO copies (book_id, barcode, condition, shelf_location, status, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		copy.BookID, barcode, copy.Condition, copy.ShelfLocation, copy.Status, copy.CreatedAt, copy.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return models.ErrBarcodeExists
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	copy.ID = id
+
+	if copy.Barcode == "" {
+		copy.Barcode = models.DefaultBarcode(id)
+		if _, err := c.db.Exec(`UPDATE copies SET barcode = ? WHERE id = ?`, copy.Barcode, id); err != nil {
+			if isUniqueViolation(err) {
+				return models.ErrBarcodeExists
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *SQLiteCopyRepository) getOne(query string, args ...any) (*models.Copy, error) {
+	copy, err := scanCopy(c.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrCopyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return copy, nil
+}
+
+func (c *SQLiteCopyRepository) GetCopy(id int64) (*models.Copy, error) {
+	return c.getOne(`SELECT `+copyColumns+` FROM copies WHERE id = ?`, id)
+}
+
+func (c *SQLiteCopyRepository) GetCopyByBarcode(barcode string) (*models.Copy, error) {
+	return c.getOne(`SELECT `+copyColumns+` FROM copies WHERE barcode = ?`, barcode)
+}
+
+func (c *SQLiteCopyRepository) GetBookCopies(bookID int64) ([]*models.Copy, error) {
+	return c.queryCopies(`SELECT `+copyColumns+` FROM copies WHERE book_id = ? ORDER BY id`, bookID)
+}
+
+func (c *SQLiteCopyRepository) GetAllCopies() ([]*models.Copy, error) {
+	return c.queryCopies(`SELECT ` + copyColumns + ` FROM copies ORDER BY id`)
+}
+
+func (c *SQLiteCopyRepository) UpdateCopy(id int64, copy *models.Copy) error {
+	result, err := c.db.Exec(
+		`UPDATE copies
+		 SET book_id = ?, barcode = ?, condition = ?, shelf_location = ?, updated_at = ?
+		 WHERE id = ?`,
+		copy.BookID, copy.Barcode, copy.Condition, copy.ShelfLocation, copy.UpdatedAt, id,
+	)
+	if isUniqueViolation(err) {
+		return models.ErrBarcodeExists
+	}
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrCopyNotFound
+	}
+
+	copy.ID = id
+	return nil
+}
+
+func (c *SQLiteCopyRepository) DeleteCopy(id int64) error {
+	result, err := c.db.Exec(`DELETE FROM copies WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrCopyNotFound
+	}
+
+	return nil
+}
+
+func (c *SQLiteCopyRepository) CountAvailableCopies(bookID int64) (int, error) {
+	var count int
+	err := c.db.QueryRow(
+		`SELECT COUNT(*) FROM copies WHERE book_id = ? AND status = 'available'`,
+		bookID,
+	).Scan(&count)
+	return count, err
+}
+
+// transition moves a copy from one status to another with a single
+// conditional update, so concurrent callers can't both win.
+func (c *SQLiteCopyRepository) transition(id int64, from, to string) (bool, error) {
+	result, err := c.db.Exec(
+		`UPDATE copies SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		to, c.clock.Now(), id, from,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (c *SQLiteCopyRepository) ReserveCopy(id int64) error {
+	ok, err := c.transition(id, "available", "on_loan")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if _, err := c.GetCopy(id); err != nil {
+			return err
+		}
+		return models.ErrCopyNotAvailable
+	}
+
+	return nil
+}
+
+func (c *SQLiteCopyRepository) ReserveAvailableCopy(bookID int64) (*models.Copy, error) {
+	// Retry if another transaction grabbed the candidate between the SELECT and the UPDATE
+	for {
+		var id int64
+		err := c.db.QueryRow(
+			`SELECT id FROM copies WHERE book_id = ? AND status = 'available' ORDER BY id LIMIT 1`,
+			bookID,
+		).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoCopyAvailable
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := c.transition(id, "available", "on_loan")
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return c.GetCopy(id)
+		}
+	}
+}
+
+func (c *SQLiteCopyRepository) ReleaseCopy(id int64) error {
+	ok, err := c.transition(id, "on_loan", "available")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if _, err := c.GetCopy(id); err != nil {
+			return err
+		}
+		return models.ErrCopyNotOnLoan
+	}
+
+	return nil
+}
+
+func (c *SQLiteCopyRepository) TransitionCopy(id int64, from, to string) error {
+	ok, err := c.transition(id, from, to)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if _, err := c.GetCopy(id); err != nil {
+			return err
+		}
+		return models.ErrCopyStatusMismatch.WithMessage(fmt.Sprintf("copy is not %s", from))
+	}
+
+	return nil
+}
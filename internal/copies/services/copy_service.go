@@ -0,0 +1,170 @@
+package services
+
+import (
+	bookModel "librarymvc/internal/books/models"
+	"librarymvc/internal/clock"
+	"librarymvc/internal/copies/models"
+	holdService "librarymvc/internal/holds/services"
+	"librarymvc/internal/unitofwork"
+)
+
+type CopyService struct {
+	copyRepository models.CopyRepository
+	bookRepository bookModel.BookRepository
+	unitOfWork     unitofwork.UnitOfWork
+	clock          clock.Clock
+}
+
+func NewCopyService(
+	copyRepository models.CopyRepository,
+	bookRepository bookModel.BookRepository,
+	unitOfWork unitofwork.UnitOfWork,
+	clock clock.Clock,
+) models.CopyService {
+	return &CopyService{
+		copyRepository: copyRepository,
+		bookRepository: bookRepository,
+		unitOfWork:     unitOfWork,
+		clock:          clock,
+	}
+}
+
+func validateCopy(copy *models.Copy) error {
+	switch copy.Condition {
+	case "good", "worn", "damaged":
+	default:
+		return models.ErrInvalidCondition
+	}
+
+	switch copy.Status {
+	case "available", "on_loan", "on_hold", "damaged", "lost":
+	default:
+		return models.ErrInvalidStatus
+	}
+
+	return nil
+}
+
+// CreateCopy adds a copy to the book. A copy put on the shelf goes to the
+// first member waiting for the book, if any.
+func (c CopyService) CreateCopy(copy *models.Copy) error {
+	if copy.Condition == "" {
+		copy.Condition = "good"
+	}
+	if copy.Status == "" {
+		copy.Status = "available"
+	}
+	if err := validateCopy(copy); err != nil {
+		return err
+	}
+	if copy.Status == "on_loan" || copy.Status == "on_hold" {
+		return models.ErrCirculationStatus
+	}
+
+	now := c.clock.Now()
+	copy.CreatedAt = now
+	copy.UpdatedAt = now
+	return c.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		if _, err := repos.Books.GetBook(copy.BookID); err != nil {
+			return err
+		}
+		if err := repos.Copies.CreateCopy(copy); err != nil {
+			return err
+		}
+		if copy.Status == "available" {
+			return holdService.OfferAvailableCopy(repos, copy, now)
+		}
+		return nil
+	})
+}
+
+func (c CopyService) GetCopy(id int64) (*models.Copy, error) {
+	return c.copyRepository.GetCopy(id)
+}
+
+func (c CopyService) GetCopyByBarcode(barcode string) (*models.Copy, error) {
+	return c.copyRepository.GetCopyByBarcode(barcode)
+}
+
+func (c CopyService) GetBookCopies(bookID int64) ([]*models.Copy, error) {
+	if _, err := c.bookRepository.GetBook(bookID); err != nil {
+		return nil, err
+	}
+	return c.copyRepository.GetBookCopies(bookID)
+}
+
+func (c CopyService) GetAllCopies() ([]*models.Copy, error) {
+	return c.copyRepository.GetAllCopies()
+}
+
+// UpdateCopy changes barcode, condition, shelf location and status. The book
+// a copy belongs to never changes, and the on_loan and on_hold statuses are
+// owned by loans and holds. The status changes only if the copy is still in
+// the status it was read in, so a checkout made meanwhile is not undone, and
+// a copy back on the shelf goes to the first member waiting for the book.
+func (c CopyService) UpdateCopy(id int64, copy *models.Copy) error {
+	return c.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		existing, err := repos.Copies.GetCopy(id)
+		if err != nil {
+			return err
+		}
+
+		if copy.Barcode == "" {
+			copy.Barcode = existing.Barcode
+		}
+		if copy.Condition == "" {
+			copy.Condition = existing.Condition
+		}
+		if copy.Status == "" {
+			copy.Status = existing.Status
+		}
+		if err := validateCopy(copy); err != nil {
+			return err
+		}
+
+		if existing.Status == "on_loan" && copy.Status != "on_loan" {
+			return models.ErrCopyOnLoan.WithMessage("copy is on loan; return it first")
+		}
+		if existing.Status == "on_hold" && copy.Status != "on_hold" {
+			return models.ErrCopyOnHold.WithMessage("copy is set aside for a hold; cancel the hold first")
+		}
+		if existing.Status != copy.Status && (copy.Status == "on_loan" || copy.Status == "on_hold") {
+			return models.ErrCirculationStatus
+		}
+
+		now := c.clock.Now()
+		from, to := existing.Status, copy.Status
+		copy.BookID = existing.BookID
+		copy.CreatedAt = existing.CreatedAt
+		copy.UpdatedAt = now
+		if err := repos.Copies.UpdateCopy(id, copy); err != nil {
+			return err
+		}
+		if from == to {
+			return nil
+		}
+		if err := repos.Copies.TransitionCopy(id, from, to); err != nil {
+			return err
+		}
+		copy.Status = to
+		if to == "available" {
+			return holdService.OfferAvailableCopy(repos, copy, now)
+		}
+		return nil
+	})
+}
+
+func (c CopyService) DeleteCopy(id int64) error {
+	copy, err := c.copyRepository.GetCopy(id)
+	if err != nil {
+		return err
+	}
+	if copy.Status == "on_loan" {
+		return models.ErrCopyOnLoan
+	}
+	if copy.Status == "on_hold" {
+		return models.ErrCopyOnHold
+	}
+
+	return c.copyRepository.DeleteCopy(id)
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	bookModel "librarymvc/internal/books/models"
+	"librarymvc/internal/copies/models"
+	holdModel "librarymvc/internal/holds/models"
+	holdService "librarymvc/internal/holds/services"
+	"librarymvc/internal/testenv"
+	userModel "librarymvc/internal/users/models"
+)
+
+func newCopy(t *testing.T, storage *testenv.Storage) (models.CopyService, *models.Copy) {
+	t.Helper()
+	now := storage.Clock.Now()
+	book := &bookModel.Book{Title: "Dom Casmurro", Author: "Machado de Assis", BookType: "emprestavel", CreatedAt: now, UpdatedAt: now}
+	if err := storage.Repos.Books.CreateBook(book); err != nil {
+		t.Fatal(err)
+	}
+
+	copies := NewCopyService(storage.Repos.Copies, storage.Repos.Books, storage.UnitOfWork, storage.Clock)
+	copy := &models.Copy{BookID: book.ID, Barcode: "B0001"}
+	if err := copies.CreateCopy(copy); err != nil {
+		t.Fatalf("CreateCopy() = %v", err)
+	}
+	return copies, copy
+}
+
+func TestUpdateCopyStatus(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		copies, copy := newCopy(t, storage)
+
+		steps := []struct {
+			status string
+			err    error
+			want   string
+		}{
+			{"damaged", nil, "damaged"},
+			{"available", nil, "available"},
+			{"on_loan", models.ErrCirculationStatus, "available"},
+			{"lost", nil, "lost"},
+		}
+		for _, step := range steps {
+			update := &models.Copy{Status: step.status, ShelfLocation: "Estante 3"}
+			if err := copies.UpdateCopy(copy.ID, update); !errors.Is(err, step.err) {
+				t.Errorf("UpdateCopy(%s) = %v, want %v", step.status, err, step.err)
+			}
+			stored, err := copies.GetCopy(copy.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != step.want {
+				t.Errorf("after UpdateCopy(%s): status %q, want %q", step.status, stored.Status, step.want)
+			}
+		}
+	})
+}
+
+func TestUpdateCopyKeepsACheckoutMadeMeanwhile(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		copies, copy := newCopy(t, storage)
+
+		// The edit form was loaded while the copy was on the shelf
+		form, err := copies.GetCopy(copy.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		edited := *form
+
+		if err := storage.Repos.Copies.ReserveCopy(copy.ID); err != nil {
+			t.Fatalf("ReserveCopy() = %v", err)
+		}
+
+		// Saving the whole stale record leaves the status alone
+		edited.ShelfLocation = "Estante 3"
+		if err := storage.Repos.Copies.UpdateCopy(copy.ID, &edited); err != nil {
+			t.Fatalf("UpdateCopy() = %v", err)
+		}
+		stored, err := copies.GetCopy(copy.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != "on_loan" || stored.ShelfLocation != "Estante 3" {
+			t.Errorf("status %q, shelf %q; want on_loan, Estante 3", stored.Status, stored.ShelfLocation)
+		}
+
+		// And the service refuses to put it back on the shelf
+		err = copies.UpdateCopy(copy.ID, &models.Copy{Status: "available", ShelfLocation: "Estante 4"})
+		if !errors.Is(err, models.ErrCopyOnLoan) {
+			t.Errorf("UpdateCopy(available) = %v, want %v", err, models.ErrCopyOnLoan)
+		}
+		if err := storage.Repos.Copies.ReleaseCopy(copy.ID); err != nil {
+			t.Errorf("ReleaseCopy() = %v, want the loan to end normally", err)
+		}
+	})
+}
+
+func TestCopiesBackOnTheShelfGoToTheHoldQueue(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		copies, lost := newCopy(t, storage)
+		if err := copies.UpdateCopy(lost.ID, &models.Copy{Status: "lost"}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Two members wait for the book, whose only copy is lost
+		now := storage.Clock.Now()
+		holds := holdService.NewHoldService(storage.Repos.Holds, storage.UnitOfWork, storage.Clock)
+		var queue []*holdModel.Hold
+		for _, email := range []string{"primeira@lib.org", "segunda@lib.org"} {
+			user := &userModel.User{
+				Name:      "Leitora da Fila",
+				Email:     email,
+				Role:      userModel.RolePatron,
+				Category:  userModel.DefaultCategory,
+				Status:    userModel.StatusActive,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := storage.Repos.Users.CreateUser(user); err != nil {
+				t.Fatal(err)
+			}
+			hold, err := holds.PlaceHold(lost.BookID, user.ID)
+			if err != nil {
+				t.Fatalf("PlaceHold() = %v", err)
+			}
+			queue = append(queue, hold)
+			storage.Clock.Advance(time.Minute)
+		}
+
+		// A new copy goes to the first in line, not to the shelf
+		added := &models.Copy{BookID: lost.BookID, Barcode: "B0002"}
+		if err := copies.CreateCopy(added); err != nil {
+			t.Fatalf("CreateCopy() = %v", err)
+		}
+		// The lost one, found again, goes to the second
+		found := &models.Copy{Status: "available"}
+		if err := copies.UpdateCopy(lost.ID, found); err != nil {
+			t.Fatalf("UpdateCopy() = %v", err)
+		}
+
+		for i, want := range []*models.Copy{added, found} {
+			if want.Status != "on_hold" {
+				t.Errorf("copy %s returned with status %q, want on_hold", want.Barcode, want.Status)
+			}
+			stored, err := copies.GetCopy(want.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hold, err := holds.GetHold(queue[i].ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != "on_hold" || hold.Status != "ready" || hold.CopyID != want.ID {
+				t.Errorf("hold %d: copy %d is %q, hold %q for copy %d; want the copy on hold for it", i+1, want.ID, stored.Status, hold.Status, hold.CopyID)
+			}
+		}
+	})
+}
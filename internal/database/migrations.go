@@ -0,0 +1,379 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// migrations must only ever be appended to; applied versions are tracked in
+// the schema_migrations table.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create_books_users_loans",
+		sql: `
+CREATE TABLE books (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	title         TEXT     NOT NULL,
+	author        TEXT     NOT NULL,
+	quantity      INTEGER  NOT NULL DEFAULT 0,
+	book_type     TEXT     NOT NULL,
+	loan_duration INTEGER  NOT NULL DEFAULT 0,
+	created_at    DATETIME NOT NULL,
+	updated_at    DATETIME NOT NULL
+);
+
+CREATE TABLE users (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT     NOT NULL,
+	email      TEXT     NOT NULL,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE loans (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	book_id     INTEGER  NOT NULL,
+	user_id     INTEGER  NOT NULL,
+	borrowed_at DATETIME NOT NULL,
+	due_date    DATETIME NOT NULL,
+	returned_at DATETIME NOT NULL,
+	fine        REAL     NOT NULL DEFAULT 0,
+	status      TEXT     NOT NULL,
+	created_at  DATETIME NOT NULL,
+	updated_at  DATETIME NOT NULL
+);
+
+CREATE INDEX idx_loans_user_status ON loans (user_id, status);
+`,
+	},
+	{
+		version: 2,
+		name:    "create_copies",
+		sql: `
+CREATE TABLE copies (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	book_id        INTEGER  NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+	barcode        TEXT     UNIQUE,
+	condition      TEXT     NOT NULL DEFAULT 'good',
+	shelf_location TEXT     NOT NULL DEFAULT '',
+	status         TEXT     NOT NULL DEFAULT 'available',
+	created_at     DATETIME NOT NULL,
+	updated_at     DATETIME NOT NULL
+);
+
+CREATE INDEX idx_copies_book_status ON copies (book_id, status);
+
+ALTER TABLE loans ADD COLUMN copy_id INTEGER NOT NULL DEFAULT 0;
+
+-- One on-loan copy for every active loan, linked back to the loan
+INSERT INTO copies (book_id, barcode, status, created_at, updated_at)
+SELECT book_id, 'LOAN' || id, 'on_loan', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM loans WHERE status = 'active';
+
+UPDATE loans
+SET copy_id = (SELECT c.id FROM copies c WHERE c.barcode = 'LOAN' || loans.id)
+WHERE status = 'active';
+
+-- One available copy for every unit left in books.quantity
+WITH RECURSIVE stock (book_id, n, total) AS (
+	SELECT id, 1, quantity FROM books WHERE quantity > 0
+	UNION ALL
+	SELECT book_id, n + 1, total FROM stock WHERE n < total
+)
+INSERT INTO copies (book_id, barcode, status, created_at, updated_at)
+SELECT book_id, NULL, 'available', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM stock;
+
+UPDATE copies SET barcode = printf('LIB%08d', id);
+
+-- Quantity is now derived from the available copies
+ALTER TABLE books DROP COLUMN quantity;
+`,
+	},
+	{
+		version: 3,
+		name:    "create_loan_policies",
+		sql: `
+CREATE TABLE loan_policies (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	code         TEXT     NOT NULL UNIQUE,
+	name         TEXT     NOT NULL,
+	loanable     BOOLEAN  NOT NULL DEFAULT 1,
+	loan_days    INTEGER  NOT NULL DEFAULT 0,
+	renewable    BOOLEAN  NOT NULL DEFAULT 0,
+	max_renewals INTEGER  NOT NULL DEFAULT 0,
+	fine_rate    REAL     NOT NULL DEFAULT 0,
+	created_at   DATETIME NOT NULL,
+	updated_at   DATETIME NOT NULL
+);
+`,
+	},
+	{
+		version: 4,
+		name:    "add_loan_renewals",
+		sql: `
+ALTER TABLE loans ADD COLUMN renewals INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE loan_policies ADD COLUMN renewal_grace_days INTEGER NOT NULL DEFAULT 0;
+`,
+	},
+	{
+		version: 5,
+		name:    "create_holds",
+		sql: `
+CREATE TABLE holds (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	book_id         INTEGER  NOT NULL,
+	user_id         INTEGER  NOT NULL,
+	copy_id         INTEGER  NOT NULL DEFAULT 0,
+	status          TEXT     NOT NULL,
+	placed_at       DATETIME NOT NULL,
+	ready_at        DATETIME NOT NULL,
+	pickup_deadline DATETIME NOT NULL,
+	created_at      DATETIME NOT NULL,
+	updated_at      DATETIME NOT NULL
+);
+
+CREATE INDEX idx_holds_book_status ON holds (book_id, status);
+CREATE INDEX idx_holds_user ON holds (user_id);
+`,
+	},
+	{
+		version: 6,
+		name:    "add_user_max_loans",
+		sql: `
+ALTER TABLE users ADD COLUMN max_loans INTEGER NOT NULL DEFAULT 0;
+`,
+	},
+	{
+		version: 7,
+		name:    "create_account_transactions",
+		sql: `
+CREATE TABLE account_transactions (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id    INTEGER  NOT NULL,
+	loan_id    INTEGER  NOT NULL DEFAULT 0,
+	type       TEXT     NOT NULL,
+	reason     TEXT     NOT NULL DEFAULT '',
+	amount     INTEGER  NOT NULL,
+	note       TEXT     NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_account_transactions_user ON account_transactions (user_id);
+
+-- Money is stored as integer cents
+ALTER TABLE loans ADD COLUMN fine_cents INTEGER NOT NULL DEFAULT 0;
+UPDATE loans SET fine_cents = CAST(ROUND(fine * 100) AS INTEGER);
+ALTER TABLE loans DROP COLUMN fine;
+
+ALTER TABLE loan_policies ADD COLUMN fine_rate_cents INTEGER NOT NULL DEFAULT 0;
+UPDATE loan_policies SET fine_rate_cents = CAST(ROUND(fine_rate * 100) AS INTEGER);
+ALTER TABLE loan_policies DROP COLUMN fine_rate;
+
+-- Fines of loans returned before the ledger existed were never collected
+INSERT INTO account_transactions (user_id, loan_id, type, reason, amount, note, created_at)
+SELECT user_id, id, 'charge', 'overdue', fine_cents, 'Multa por atraso - empréstimo #' || id, returned_at
+FROM loans WHERE status = 'returned' AND fine_cents > 0;
+`,
+	},
+	{
+		version: 8,
+		name:    "add_fine_policy",
+		sql: `
+ALTER TABLE loan_policies ADD COLUMN fine_grace_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE loan_policies ADD COLUMN max_fine_cents INTEGER NOT NULL DEFAULT 0;
+`,
+	},
+	{
+		version: 9,
+		name:    "create_calendar",
+		sql: `
+CREATE TABLE opening_hours (
+	weekday    INTEGER PRIMARY KEY,
+	open       BOOLEAN  NOT NULL DEFAULT 1,
+	opens_at   TEXT     NOT NULL DEFAULT '',
+	closes_at  TEXT     NOT NULL DEFAULT '',
+	updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE closures (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	date       TEXT     NOT NULL UNIQUE,
+	reason     TEXT     NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL
+);
+`,
+	},
+	{
+		version: 10,
+		name:    "add_book_isbn",
+		sql: `
+-- Stored as ISBN-13; books catalogued before have none
+ALTER TABLE books ADD COLUMN isbn TEXT;
+CREATE UNIQUE INDEX idx_books_isbn ON books (isbn) WHERE isbn IS NOT NULL;
+`,
+	},
+	{
+		version: 11,
+		name:    "create_authors_and_subjects",
+		sql: `
+CREATE TABLE authors (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT     NOT NULL UNIQUE COLLATE NOCASE,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE subjects (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT     NOT NULL UNIQUE COLLATE NOCASE,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE book_authors (
+	book_id   INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+	author_id INTEGER NOT NULL REFERENCES authors (id),
+	position  INTEGER NOT NULL,
+	PRIMARY KEY (book_id, author_id)
+);
+CREATE INDEX idx_book_authors_author ON book_authors (author_id);
+
+CREATE TABLE book_subjects (
+	book_id    INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+	subject_id INTEGER NOT NULL REFERENCES subjects (id),
+	position   INTEGER NOT NULL,
+	PRIMARY KEY (book_id, subject_id)
+);
+CREATE INDEX idx_book_subjects_subject ON book_subjects (subject_id);
+
+ALTER TABLE books ADD COLUMN publisher TEXT NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN publication_year INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE books ADD COLUMN edition TEXT NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN language TEXT NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN pages INTEGER NOT NULL DEFAULT 0;
+
+-- Each existing book keeps its author as its only author
+INSERT OR IGNORE INTO authors (name, created_at)
+SELECT TRIM(author), MIN(created_at) FROM books WHERE TRIM(author) <> '' GROUP BY TRIM(author) COLLATE NOCASE;
+INSERT INTO book_authors (book_id, author_id, position)
+SELECT books.id, authors.id, 0 FROM books JOIN authors ON authors.name = TRIM(books.author);
+`,
+	},
+	{
+		version: 12,
+		name:    "create_credentials_sessions_api_tokens",
+		sql: `
+CREATE TABLE credentials (
+	user_id       INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+	password_hash TEXT     NOT NULL,
+	updated_at    DATETIME NOT NULL
+);
+
+CREATE TABLE sessions (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	token_hash TEXT     NOT NULL UNIQUE,
+	created_at DATETIME NOT NULL,
+	expires_at DATETIME NOT NULL
+);
+CREATE INDEX idx_sessions_user ON sessions (user_id);
+
+CREATE TABLE api_tokens (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id      INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	name         TEXT     NOT NULL,
+	token_hash   TEXT     NOT NULL UNIQUE,
+	created_at   DATETIME NOT NULL,
+	expires_at   DATETIME NOT NULL,
+	last_used_at DATETIME
+);
+CREATE INDEX idx_api_tokens_user ON api_tokens (user_id);
+`,
+	},
+	{
+		version: 13,
+		name:    "add_user_role",
+		sql: `
+-- Everyone starts as a patron; ADMIN_EMAIL names the administrator at
+-- startup, who then gives staff their roles
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'patron';
+`,
+	},
+	{
+		version: 14,
+		name:    "add_user_membership",
+		sql: `
+ALTER TABLE users ADD COLUMN card_number TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN category TEXT NOT NULL DEFAULT 'community';
+ALTER TABLE users ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN member_since DATETIME;
+ALTER TABLE users ADD COLUMN expires_at DATETIME;
+
+-- Existing members get a card numbered after their ID, count as members
+-- since they registered and keep a membership that doesn't expire
+UPDATE users SET card_number = printf('%06d', id), member_since = created_at;
+
+CREATE UNIQUE INDEX idx_users_card_number ON users (card_number) WHERE card_number <> '';
+`,
+	},
+}
+
+// Migrate applies every migration that has not been recorded yet, each one
+// inside its own transaction.
+func Migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT     NOT NULL,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[int]bool)
+	rows, err := db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
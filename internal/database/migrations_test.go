@@ -0,0 +1,68 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// migrateTo applies the migrations up to and including version.
+func migrateTo(t *testing.T, db *sql.DB, version int) {
+	t.Helper()
+	all := migrations
+	defer func() { migrations = all }()
+
+	migrations = nil
+	for _, m := range all {
+		if m.version <= version {
+			migrations = append(migrations, m)
+		}
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() up to %d = %v", version, err)
+	}
+}
+
+func TestUpgradeLeavesUsersWithPasswordsAsPatrons(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "library.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// A library from before roles, where staff and members alike could log in
+	migrateTo(t, db, 12)
+	_, err = db.Exec(`
+INSERT INTO users (id, name, email, created_at, updated_at) VALUES
+	(1, 'Leitora com Senha', 'leitora@lib.org', '2026-01-05 10:00:00', '2026-01-05 10:00:00'),
+	(2, 'Leitor sem Senha', 'leitor@lib.org', '2026-01-05 10:00:00', '2026-01-05 10:00:00');
+INSERT INTO credentials (user_id, password_hash, updated_at) VALUES (1, 'hash', '2026-01-05 10:00:00');
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() = %v", err)
+	}
+	rows, err := db.Query(`SELECT id, role FROM users ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var role string
+		if err := rows.Scan(&id, &role); err != nil {
+			t.Fatal(err)
+		}
+		if role != "patron" {
+			t.Errorf("user %d is %q after the upgrade, want patron", id, role)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,39 @@
+package database
+
+import "strings"
+
+// Where collects the conditions of a filtered listing, joined with AND.
+type Where struct {
+	conditions []string
+	args       []any
+}
+
+func (w *Where) Add(condition string, args ...any) {
+	w.conditions = append(w.conditions, condition)
+	w.args = append(w.args, args...)
+}
+
+// String is the WHERE clause, or "" when there are no conditions.
+func (w *Where) String() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+func (w *Where) Args() []any {
+	return w.args
+}
+
+// Contains is the LIKE pattern matching text anywhere in a column; use it
+// with ESCAPE '\'.
+func Contains(text string) string {
+	text = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+	return "%" + text + "%"
+}
+
+// DayOf extracts the date from a column holding a time.Time, which is
+// stored as text starting with YYYY-MM-DD.
+func DayOf(column string) string {
+	return "substr(" + column + ", 1, 10)"
+}
@@ -0,0 +1,163 @@
+package holds
+
+import (
+	"librarymvc/internal/apperror"
+	auth "librarymvc/internal/auth/controllers"
+	authModel "librarymvc/internal/auth/models"
+	"librarymvc/internal/holds/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HoldController struct {
+	holdService models.HoldService
+}
+
+func NewHoldController(holdService models.HoldService) *HoldController {
+	return &HoldController{holdService: holdService}
+}
+
+func (h *HoldController) RegisterRoutes(r *gin.Engine) {
+	holds := r.Group("/holds")
+	{
+		holds.POST("", h.PlaceHold)
+		holds.GET("", h.GetAllHolds)
+		holds.GET("/:id", h.GetHold)
+		holds.PUT("/:id/cancel", h.CancelHold)
+		holds.POST("/process", h.ProcessHolds)
+	}
+
+	users := r.Group("/holds/users")
+	{
+		users.GET("/:userId/holds", h.GetUserHolds)
+	}
+}
+
+func (h *HoldController) PlaceHold(ctx *gin.Context) {
+	var request struct {
+		BookID int64 `json:"bookID"`
+		UserID int64 `json:"userID"`
+	}
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	// Patrons place holds for themselves; staff for anyone
+	if err := auth.CheckSelfOr(ctx, request.UserID, authModel.Circulate); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	hold, err := h.holdService.PlaceHold(request.BookID, request.UserID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, hold)
+}
+
+func (h *HoldController) GetHold(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("hold"))
+		return
+	}
+
+	hold, err := h.holdService.GetHold(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := auth.CheckSelfOr(ctx, hold.UserID, authModel.ViewCirculation); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+func (h *HoldController) GetAllHolds(ctx *gin.Context) {
+	holds, err := h.holdService.GetAllHolds()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, holds)
+}
+
+// GetBookHolds returns the queue of active holds for a book.
+func (h *HoldController) GetBookHolds(ctx *gin.Context) {
+	bookID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("book"))
+		return
+	}
+
+	holds, err := h.holdService.GetBookHolds(bookID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, holds)
+}
+
+func (h *HoldController) GetUserHolds(ctx *gin.Context) {
+	userID, err := strconv.ParseInt(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("user"))
+		return
+	}
+
+	holds, err := h.holdService.GetUserHolds(userID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, holds)
+}
+
+func (h *HoldController) CancelHold(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("hold"))
+		return
+	}
+
+	hold, err := h.holdService.GetHold(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := auth.CheckSelfOr(ctx, hold.UserID, authModel.Circulate); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.holdService.CancelHold(id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// ProcessHolds expires missed pickups and sets aside available copies.
+func (h *HoldController) ProcessHolds(ctx *gin.Context) {
+	processed, err := h.holdService.ProcessHolds()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"processed": processed})
+}
@@ -0,0 +1,11 @@
+package models
+
+import "librarymvc/internal/apperror"
+
+var (
+	ErrHoldNotFound    = apperror.NewNotFound("hold_not_found", "hold not found")
+	ErrHoldNotActive   = apperror.NewConflict("hold_not_active", "hold is not active")
+	ErrAlreadyOnHold   = apperror.NewConflict("already_on_hold", "user already has a hold on this book")
+	ErrCopiesAvailable = apperror.NewConflict("copies_available", "book has available copies; borrow it instead")
+	ErrNotHoldable     = apperror.NewValidation("not_holdable", "this material type cannot be placed on hold")
+)
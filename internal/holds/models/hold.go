@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// PickupDays is how long a copy set aside for a hold waits for the member.
+const PickupDays = 3
+
+type Hold struct {
+	ID             int64     `json:"ID"`
+	BookID         int64     `json:"bookID"`
+	UserID         int64     `json:"userID"`
+	CopyID         int64     `json:"copyID"`         // Exemplar separado (status ready)
+	Status         string    `json:"status"`         // waiting, ready, fulfilled, cancelled, expired
+	PlacedAt       time.Time `json:"placedAt"`       // Define a ordem na fila
+	ReadyAt        time.Time `json:"readyAt"`        // Quando o exemplar foi separado
+	PickupDeadline time.Time `json:"pickupDeadline"` // Prazo para retirada
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Active reports whether the hold is still in the queue.
+func (h *Hold) Active() bool {
+	return h.Status == "waiting" || h.Status == "ready"
+}
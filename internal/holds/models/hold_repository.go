@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type HoldRepository interface {
+	CreateHold(hold *Hold) error
+	GetHold(id int64) (*Hold, error)
+	UpdateHold(hold *Hold) error
+	GetAllHolds() ([]*Hold, error)
+	// GetBookHolds returns the active (waiting or ready) holds of a book in queue order.
+	GetBookHolds(bookID int64) ([]*Hold, error)
+	GetUserHolds(userID int64) ([]*Hold, error)
+	// GetExpiredHolds returns ready holds whose pickup deadline is before now.
+	GetExpiredHolds(now time.Time) ([]*Hold, error)
+	// MoveBookHolds moves every hold of a book, past ones included, to
+	// another book.
+	MoveBookHolds(fromBookID, toBookID int64) error
+}
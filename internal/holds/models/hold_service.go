@@ -0,0 +1,13 @@
+package models
+
+type HoldService interface {
+	PlaceHold(bookID, userID int64) (*Hold, error)
+	CancelHold(id int64) error
+	GetHold(id int64) (*Hold, error)
+	GetAllHolds() ([]*Hold, error)
+	GetBookHolds(bookID int64) ([]*Hold, error)
+	GetUserHolds(userID int64) ([]*Hold, error)
+	// ProcessHolds expires missed pickups, rolling their copies to the next
+	// member in line, and sets aside available copies for waiting holds.
+	ProcessHolds() (int, error)
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"librarymvc/internal/holds/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+type HoldRepository struct {
+	holds  map[int64]*models.Hold
+	mu     sync.RWMutex
+	nextID int64
+}
+
+func NewHoldRepository() models.HoldRepository {
+	return &HoldRepository{
+		holds:  make(map[int64]*models.Hold),
+		nextID: 1,
+	}
+}
+
+func (h *HoldRepository) CreateHold(hold *models.Hold) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hold.ID = h.nextID
+	h.holds[hold.ID] = hold
+	h.nextID++
+
+	return nil
+}
+
+func (h *HoldRepository) GetHold(id int64) (*models.Hold, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	hold, exists := h.holds[id]
+	if !exists {
+		return nil, models.ErrHoldNotFound
+	}
+
+	return hold, nil
+}
+
+func (h *HoldRepository) UpdateHold(hold *models.Hold) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, exists := h.holds[hold.ID]
+	if !exists {
+		return models.ErrHoldNotFound
+	}
+
+	h.holds[hold.ID] = hold
+	return nil
+}
+
+func (h *HoldRepository) MoveBookHolds(fromBookID, toBookID int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, hold := range h.holds {
+		if hold.BookID == fromBookID {
+			hold.BookID = toBookID
+		}
+	}
+
+	return nil
+}
+
+// filter returns the holds matching keep in queue order.
+func (h *HoldRepository) filter(keep func(hold *models.Hold) bool) []*models.Hold {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	holds := make([]*models.Hold, 0)
+	for _, hold := range h.holds {
+		if keep(hold) {
+			holds = append(holds, hold)
+		}
+	}
+	sort.Slice(holds, func(i, j int) bool {
+		if !holds[i].PlacedAt.Equal(holds[j].PlacedAt) {
+			return holds[i].PlacedAt.Before(holds[j].PlacedAt)
+		}
+		return holds[i].ID < holds[j].ID
+	})
+
+	return holds
+}
+
+func (h *HoldRepository) GetAllHolds() ([]*models.Hold, error) {
+	return h.filter(func(*models.Hold) bool { return true }), nil
+}
+
+func (h *HoldRepository) GetBookHolds(bookID int64) ([]*models.Hold, error) {
+	return h.filter(func(hold *models.Hold) bool {
+		return hold.BookID == bookID && hold.Active()
+	}), nil
+}
+
+func (h *HoldRepository) GetUserHolds(userID int64) ([]*models.Hold, error) {
+	return h.filter(func(hold *models.Hold) bool {
+		return hold.UserID == userID
+	}), nil
+}
+
+func (h *HoldRepository) GetExpiredHolds(now time.Time) ([]*models.Hold, error) {
+	return h.filter(func(hold *models.Hold) bool {
+		return hold.Status == "ready" && hold.PickupDeadline.Before(now)
+	}), nil
+}
+
+// Snapshot copies the current state and returns a function that restores it.
+// It is used by the in-memory unit of work to roll back failed transactions.
+func (h *HoldRepository) Snapshot() func() {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	holds := make(map[int64]models.Hold, len(h.holds))
+	for id, hold := range h.holds {
+		holds[id] = *hold
+	}
+	nextID := h.nextID
+
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		h.holds = make(map[int64]*models.Hold, len(holds))
+		for id, hold := range holds {
+			hold := hold
+			h.holds[id] = &hold
+		}
+		h.nextID = nextID
+	}
+}
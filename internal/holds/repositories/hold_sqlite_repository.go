@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"librarymvc/internal/database"
+	"librarymvc/internal/holds/models"
+	"time"
+)
+
+type SQLiteHoldRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteHoldRepository(db database.DBTX) models.HoldRepository {
+	return &SQLiteHoldRepository{db: db}
+}
+
+const holdColumns = `id, book_id, user_id, copy_id, status, placed_at, ready_at, pickup_deadline, created_at, updated_at`
+
+// queueOrder is the FIFO order of a hold queue. Holds are inserted as they
+// are placed, so the id follows placed_at.
+const queueOrder = ` ORDER BY id`
+
+func scanHold(row interface{ Scan(...any) error }) (*models.Hold, error) {
+	var hold models.Hold
+	err := row.Scan(
+		&hold.ID,
+		&hold.BookID,
+		&hold.UserID,
+		&hold.CopyID,
+		&hold.Status,
+		&hold.PlacedAt,
+		&hold.ReadyAt,
+		&hold.PickupDeadline,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (h *SQLiteHoldRepository) queryHolds(query string, args ...any) ([]*models.Hold, error) {
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holds := make([]*models.Hold, 0)
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+
+	return holds, rows.Err()
+}
+
+func (h *SQLiteHoldRepository) CreateHold(hold *models.Hold) error {
+	result, err := h.db.Exec(
+		`INSERT INTO holds (book_id, user_id, copy_id, status, placed_at, ready_at, pickup_deadline, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		hold.BookID, hold.UserID, hold.CopyID, hold.Status, hold.PlacedAt, hold.ReadyAt,
+		hold.PickupDeadline, hold.CreatedAt, hold.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	hold.ID = id
+
+	return nil
+}
+
+func (h *SQLiteHoldRepository) GetHold(id int64) (*models.Hold, error) {
+	hold, err := scanHold(h.db.QueryRow(`SELECT `+holdColumns+` FROM holds WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+func (h *SQLiteHoldRepository) UpdateHold(hold *models.Hold) error {
+	result, err := h.db.Exec(
+		`UPDATE holds
+		 SET book_id = ?, user_id = ?, copy_id = ?, status = ?, placed_at = ?, ready_at = ?,
+		     pickup_deadline = ?, updated_at = ?
+		 WHERE id = ?`,
+		hold.BookID, hold.UserID, hold.CopyID, hold.Status, hold.PlacedAt, hold.ReadyAt,
+		hold.PickupDeadline, hold.UpdatedAt, hold.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrHoldNotFound
+	}
+
+	return nil
+}
+
+func (h *SQLiteHoldRepository) MoveBookHolds(fromBookID, toBookID int64) error {
+	_, err := h.db.Exec(`UPDATE holds SET book_id = ? WHERE book_id = ?`, toBookID, fromBookID)
+	return err
+}
+
+func (h *SQLiteHoldRepository) GetAllHolds() ([]*models.Hold, error) {
+	return h.queryHolds(`SELECT ` + holdColumns + ` FROM holds` + queueOrder)
+}
+
+func (h *SQLiteHoldRepository) GetBookHolds(bookID int64) ([]*models.Hold, error) {
+	return h.queryHolds(
+		`SELECT `+holdColumns+` FROM holds WHERE book_id = ? AND status IN ('waiting', 'ready')`+queueOrder,
+		bookID,
+	)
+}
+
+func (h *SQLiteHoldRepository) GetUserHolds(userID int64) ([]*models.Hold, error) {
+	return h.queryHolds(`SELECT `+holdColumns+` FROM holds WHERE user_id = ?`+queueOrder, userID)
+}
+
+func (h *SQLiteHoldRepository) GetExpiredHolds(now time.Time) ([]*models.Hold, error) {
+	ready, err := h.queryHolds(`SELECT ` + holdColumns + ` FROM holds WHERE status = 'ready'` + queueOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deadlines are compared in Go: the driver stores times as text that
+	// SQLite's date functions can't parse
+	expired := make([]*models.Hold, 0)
+	for _, hold := range ready {
+		if hold.PickupDeadline.Before(now) {
+			expired = append(expired, hold)
+		}
+	}
+
+	return expired, nil
+}
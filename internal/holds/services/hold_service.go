@@ -0,0 +1,268 @@
+package services
+
+import (
+	"librarymvc/internal/clock"
+	copyModel "librarymvc/internal/copies/models"
+	"librarymvc/internal/holds/models"
+	loanModel "librarymvc/internal/loans/models"
+	"librarymvc/internal/unitofwork"
+	"time"
+)
+
+type HoldService struct {
+	holdRepository models.HoldRepository
+	unitOfWork     unitofwork.UnitOfWork
+	clock          clock.Clock
+}
+
+func NewHoldService(
+	holdRepository models.HoldRepository,
+	unitOfWork unitofwork.UnitOfWork,
+	clock clock.Clock,
+) models.HoldService {
+	return &HoldService{
+		holdRepository: holdRepository,
+		unitOfWork:     unitOfWork,
+		clock:          clock,
+	}
+}
+
+// OfferCopy sets a copy aside for the first waiting hold on the book, giving
+// the member PickupDays to collect it. It reports whether a hold took the
+// copy; moving the copy itself to on_hold is left to the caller.
+func OfferCopy(repos *unitofwork.Repositories, copyID, bookID int64, now time.Time) (bool, error) {
+	holds, err := repos.Holds.GetBookHolds(bookID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, hold := range holds {
+		if hold.Status != "waiting" {
+			continue
+		}
+
+		hold.Status = "ready"
+		hold.CopyID = copyID
+		hold.ReadyAt = now
+		hold.PickupDeadline = now.AddDate(0, 0, models.PickupDays)
+		hold.UpdatedAt = now
+		return true, repos.Holds.UpdateHold(hold)
+	}
+
+	return false, nil
+}
+
+// ReleaseHeldCopy passes a copy that was set aside for a hold on to the next
+// member in line, or puts it back on the shelf if nobody is waiting.
+func ReleaseHeldCopy(repos *unitofwork.Repositories, copyID, bookID int64, now time.Time) error {
+	offered, err := OfferCopy(repos, copyID, bookID, now)
+	if err != nil {
+		return err
+	}
+	if offered {
+		return nil
+	}
+
+	return repos.Copies.TransitionCopy(copyID, "on_hold", "available")
+}
+
+// OfferAvailableCopy sets a copy that has just come onto the shelf other
+// than by a return (a new copy, a repaired one) aside for the first waiting
+// hold on its book, so that the queue goes before anyone at the desk.
+func OfferAvailableCopy(repos *unitofwork.Repositories, copy *copyModel.Copy, now time.Time) error {
+	offered, err := OfferCopy(repos, copy.ID, copy.BookID, now)
+	if err != nil || !offered {
+		return err
+	}
+	if err := repos.Copies.TransitionCopy(copy.ID, "available", "on_hold"); err != nil {
+		return err
+	}
+	copy.Status = "on_hold"
+	return nil
+}
+
+// OfferAvailableCopies sets the book's copies on the shelf aside for the
+// members waiting for it, in turn. It returns how many it set aside.
+func OfferAvailableCopies(repos *unitofwork.Repositories, bookID int64, now time.Time) (int, error) {
+	copies, err := repos.Copies.GetBookCopies(bookID)
+	if err != nil {
+		return 0, err
+	}
+
+	offered := 0
+	for _, copy := range copies {
+		if copy.Status != "available" {
+			continue
+		}
+		if err := OfferAvailableCopy(repos, copy, now); err != nil {
+			return offered, err
+		}
+		if copy.Status != "on_hold" {
+			break
+		}
+		offered++
+	}
+	return offered, nil
+}
+
+func (h *HoldService) PlaceHold(bookID, userID int64) (*models.Hold, error) {
+	var hold *models.Hold
+
+	err := h.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		book, err := repos.Books.GetBook(bookID)
+		if err != nil {
+			return err
+		}
+
+		policy, err := repos.Policies.GetPolicyByCode(book.BookType)
+		if err != nil {
+			return err
+		}
+		if !policy.Loanable {
+			return models.ErrNotHoldable
+		}
+
+		if _, err := repos.Users.GetUser(userID); err != nil {
+			return err
+		}
+
+		available, err := repos.Copies.CountAvailableCopies(bookID)
+		if err != nil {
+			return err
+		}
+		if available > 0 {
+			return models.ErrCopiesAvailable
+		}
+
+		holds, err := repos.Holds.GetBookHolds(bookID)
+		if err != nil {
+			return err
+		}
+		for _, existing := range holds {
+			if existing.UserID == userID {
+				return models.ErrAlreadyOnHold
+			}
+		}
+
+		loans, err := repos.Loans.GetActiveUserLoans(userID)
+		if err != nil {
+			return err
+		}
+		for _, loan := range loans {
+			if loan.BookID == bookID {
+				return loanModel.ErrAlreadyBorrowed
+			}
+		}
+
+		now := h.clock.Now()
+		hold = &models.Hold{
+			BookID:    bookID,
+			UserID:    userID,
+			Status:    "waiting",
+			PlacedAt:  now,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return repos.Holds.CreateHold(hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// CancelHold takes the member out of the queue. A copy already set aside
+// for them goes to the next member in line.
+func (h *HoldService) CancelHold(id int64) error {
+	return h.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		hold, err := repos.Holds.GetHold(id)
+		if err != nil {
+			return err
+		}
+
+		if !hold.Active() {
+			return models.ErrHoldNotActive
+		}
+
+		now := h.clock.Now()
+		wasReady := hold.Status == "ready"
+		hold.Status = "cancelled"
+		hold.UpdatedAt = now
+		if err := repos.Holds.UpdateHold(hold); err != nil {
+			return err
+		}
+
+		if wasReady {
+			return ReleaseHeldCopy(repos, hold.CopyID, hold.BookID, now)
+		}
+		return nil
+	})
+}
+
+func (h *HoldService) ProcessHolds() (int, error) {
+	processed := 0
+
+	err := h.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		now := h.clock.Now()
+
+		// Missed pickups roll to the next member in line
+		expired, err := repos.Holds.GetExpiredHolds(now)
+		if err != nil {
+			return err
+		}
+		for _, hold := range expired {
+			hold.Status = "expired"
+			hold.UpdatedAt = now
+			if err := repos.Holds.UpdateHold(hold); err != nil {
+				return err
+			}
+			if err := ReleaseHeldCopy(repos, hold.CopyID, hold.BookID, now); err != nil {
+				return err
+			}
+			processed++
+		}
+
+		// Copies that became available outside of a return and were missed
+		// are set aside for whoever is waiting
+		holds, err := repos.Holds.GetAllHolds()
+		if err != nil {
+			return err
+		}
+		waitingBooks := make(map[int64]bool)
+		for _, hold := range holds {
+			if hold.Status == "waiting" && !waitingBooks[hold.BookID] {
+				waitingBooks[hold.BookID] = true
+
+				offered, err := OfferAvailableCopies(repos, hold.BookID, now)
+				if err != nil {
+					return err
+				}
+				processed += offered
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return processed, nil
+}
+
+func (h *HoldService) GetHold(id int64) (*models.Hold, error) {
+	return h.holdRepository.GetHold(id)
+}
+
+func (h *HoldService) GetAllHolds() ([]*models.Hold, error) {
+	return h.holdRepository.GetAllHolds()
+}
+
+func (h *HoldService) GetBookHolds(bookID int64) ([]*models.Hold, error) {
+	return h.holdRepository.GetBookHolds(bookID)
+}
+
+func (h *HoldService) GetUserHolds(userID int64) ([]*models.Hold, error) {
+	return h.holdRepository.GetUserHolds(userID)
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	bookModel "librarymvc/internal/books/models"
+	copyModel "librarymvc/internal/copies/models"
+	"librarymvc/internal/holds/models"
+	loanModel "librarymvc/internal/loans/models"
+	"librarymvc/internal/testenv"
+	userModel "librarymvc/internal/users/models"
+)
+
+// fixture is a library with one loanable book whose only copy is out on
+// loan, and three members who want it.
+type fixture struct {
+	storage *testenv.Storage
+	holds   models.HoldService
+	book    *bookModel.Book
+	copy    *copyModel.Copy
+	members []*userModel.User
+}
+
+func newFixture(t *testing.T, storage *testenv.Storage) *fixture {
+	t.Helper()
+	repos := storage.Repos
+	now := storage.Clock.Now()
+
+	book := &bookModel.Book{Title: "Dom Casmurro", Author: "Machado de Assis", BookType: "emprestavel", CreatedAt: now, UpdatedAt: now}
+	if err := repos.Books.CreateBook(book); err != nil {
+		t.Fatal(err)
+	}
+	copy := &copyModel.Copy{BookID: book.ID, Barcode: "B0001", Condition: "good", Status: "on_loan", CreatedAt: now, UpdatedAt: now}
+	if err := repos.Copies.CreateCopy(copy); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fixture{
+		storage: storage,
+		holds:   NewHoldService(repos.Holds, storage.UnitOfWork, storage.Clock),
+		book:    book,
+		copy:    copy,
+	}
+	for i := 1; i <= 3; i++ {
+		member := &userModel.User{
+			Name:      fmt.Sprintf("Leitora %d", i),
+			Email:     fmt.Sprintf("leitora%d@lib.org", i),
+			Role:      userModel.RolePatron,
+			Category:  userModel.DefaultCategory,
+			Status:    userModel.StatusActive,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := repos.Users.CreateUser(member); err != nil {
+			t.Fatal(err)
+		}
+		f.members = append(f.members, member)
+	}
+	return f
+}
+
+// queue places a hold for every member, a minute apart, in order.
+func (f *fixture) queue(t *testing.T) []*models.Hold {
+	t.Helper()
+	var holds []*models.Hold
+	for _, member := range f.members {
+		hold, err := f.holds.PlaceHold(f.book.ID, member.ID)
+		if err != nil {
+			t.Fatalf("PlaceHold(%s) = %v", member.Name, err)
+		}
+		holds = append(holds, hold)
+		f.storage.Clock.Advance(time.Minute)
+	}
+	return holds
+}
+
+// shelve brings the copy back as available and lets ProcessHolds offer it,
+// as it does for copies that come back other than by a return.
+func (f *fixture) shelve(t *testing.T) {
+	t.Helper()
+	if err := f.storage.Repos.Copies.ReleaseCopy(f.copy.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.holds.ProcessHolds(); err != nil {
+		t.Fatalf("ProcessHolds() = %v", err)
+	}
+}
+
+// expect checks the status of each hold in the queue and of the copy.
+func (f *fixture) expect(t *testing.T, holds []*models.Hold, copyStatus string, statuses ...string) {
+	t.Helper()
+	for i, hold := range holds {
+		stored, err := f.holds.GetHold(hold.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != statuses[i] {
+			t.Errorf("hold %d is %q, want %q", i+1, stored.Status, statuses[i])
+		}
+		if stored.Status == "ready" && stored.CopyID != f.copy.ID {
+			t.Errorf("hold %d is ready with copy %d, want %d", i+1, stored.CopyID, f.copy.ID)
+		}
+	}
+	copy, err := f.storage.Repos.Copies.GetCopy(f.copy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copy.Status != copyStatus {
+		t.Errorf("copy is %q, want %q", copy.Status, copyStatus)
+	}
+}
+
+func TestPlaceHoldRefusals(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		f := newFixture(t, storage)
+		repos := storage.Repos
+		now := storage.Clock.Now()
+
+		if _, err := f.holds.PlaceHold(f.book.ID, f.members[0].ID); err != nil {
+			t.Fatalf("PlaceHold() = %v", err)
+		}
+
+		// The second member has the copy out
+		loan := &loanModel.Loan{BookID: f.book.ID, CopyID: f.copy.ID, UserID: f.members[1].ID, BorrowedAt: now, DueDate: now.AddDate(0, 0, 14), Status: "active", CreatedAt: now, UpdatedAt: now}
+		if err := repos.Loans.CreateLoan(loan); err != nil {
+			t.Fatal(err)
+		}
+
+		onShelf := &bookModel.Book{Title: "Quincas Borba", Author: "Machado de Assis", BookType: "emprestavel", CreatedAt: now, UpdatedAt: now}
+		reference := &bookModel.Book{Title: "Dicionário Houaiss", Author: "Antônio Houaiss", BookType: "referencia", CreatedAt: now, UpdatedAt: now}
+		for _, book := range []*bookModel.Book{onShelf, reference} {
+			if err := repos.Books.CreateBook(book); err != nil {
+				t.Fatal(err)
+			}
+			copy := &copyModel.Copy{BookID: book.ID, Barcode: fmt.Sprintf("B%04d", book.ID+1), Condition: "good", Status: "available", CreatedAt: now, UpdatedAt: now}
+			if err := repos.Copies.CreateCopy(copy); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tests := []struct {
+			name   string
+			bookID int64
+			userID int64
+			err    error
+		}{
+			{"a second hold on the same book", f.book.ID, f.members[0].ID, models.ErrAlreadyOnHold},
+			{"a hold on a book the member has out", f.book.ID, f.members[1].ID, loanModel.ErrAlreadyBorrowed},
+			{"a hold while a copy is on the shelf", onShelf.ID, f.members[2].ID, models.ErrCopiesAvailable},
+			{"a hold on reference material", reference.ID, f.members[2].ID, models.ErrNotHoldable},
+		}
+		for _, test := range tests {
+			if hold, err := f.holds.PlaceHold(test.bookID, test.userID); !errors.Is(err, test.err) {
+				t.Errorf("%s: PlaceHold() = %+v, %v; want %v", test.name, hold, err, test.err)
+			}
+		}
+	})
+}
+
+func TestHoldQueueIsFirstComeFirstServed(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		f := newFixture(t, storage)
+		holds := f.queue(t)
+		f.expect(t, holds, "on_loan", "waiting", "waiting", "waiting")
+
+		f.shelve(t)
+		f.expect(t, holds, "on_hold", "ready", "waiting", "waiting")
+
+		ready, err := f.holds.GetHold(holds[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := storage.Clock.Now()
+		if !ready.ReadyAt.Equal(now) || !ready.PickupDeadline.Equal(now.AddDate(0, 0, models.PickupDays)) {
+			t.Errorf("ready at %v until %v, want %v for %d days", ready.ReadyAt, ready.PickupDeadline, now, models.PickupDays)
+		}
+	})
+}
+
+func TestMissedPickupRollsOverToTheNextHold(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		f := newFixture(t, storage)
+		holds := f.queue(t)
+		f.shelve(t)
+
+		// Still inside the pickup window
+		storage.Clock.AdvanceDays(models.PickupDays)
+		storage.Clock.Advance(-time.Minute)
+		if processed, err := f.holds.ProcessHolds(); err != nil || processed != 0 {
+			t.Fatalf("ProcessHolds() = %d, %v; want nothing to do", processed, err)
+		}
+		f.expect(t, holds, "on_hold", "ready", "waiting", "waiting")
+
+		storage.Clock.Advance(2 * time.Minute)
+		if processed, err := f.holds.ProcessHolds(); err != nil || processed != 1 {
+			t.Fatalf("ProcessHolds() = %d, %v; want 1 expired", processed, err)
+		}
+		f.expect(t, holds, "on_hold", "expired", "ready", "waiting")
+
+		storage.Clock.AdvanceDays(models.PickupDays + 1)
+		if _, err := f.holds.ProcessHolds(); err != nil {
+			t.Fatal(err)
+		}
+		f.expect(t, holds, "on_hold", "expired", "expired", "ready")
+
+		// Nobody left in line: the copy goes back on the shelf
+		storage.Clock.AdvanceDays(models.PickupDays + 1)
+		if _, err := f.holds.ProcessHolds(); err != nil {
+			t.Fatal(err)
+		}
+		f.expect(t, holds, "available", "expired", "expired", "expired")
+	})
+}
+
+func TestCancellingAReadyHoldFreesTheCopy(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		f := newFixture(t, storage)
+		holds := f.queue(t)
+		f.shelve(t)
+
+		// Leaving the line does not touch the copy set aside for the first
+		if err := f.holds.CancelHold(holds[1].ID); err != nil {
+			t.Fatalf("CancelHold(waiting) = %v", err)
+		}
+		f.expect(t, holds, "on_hold", "ready", "cancelled", "waiting")
+
+		if err := f.holds.CancelHold(holds[0].ID); err != nil {
+			t.Fatalf("CancelHold(ready) = %v", err)
+		}
+		f.expect(t, holds, "on_hold", "cancelled", "cancelled", "ready")
+
+		if err := f.holds.CancelHold(holds[2].ID); err != nil {
+			t.Fatalf("CancelHold(ready) = %v", err)
+		}
+		f.expect(t, holds, "available", "cancelled", "cancelled", "cancelled")
+
+		if err := f.holds.CancelHold(holds[2].ID); !errors.Is(err, models.ErrHoldNotActive) {
+			t.Errorf("CancelHold(cancelled) = %v, want %v", err, models.ErrHoldNotActive)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrLength    = errors.New("an ISBN has 10 or 13 digits")
+	ErrCharacter = errors.New("an ISBN has only digits, and an X as the last digit of an ISBN-10")
+	ErrChecksum  = errors.New("ISBN check digit does not match")
+	ErrPrefix    = errors.New("an ISBN-13 starts with 978 or 979")
+)
+
+// Normalize strips the spaces and hyphens an ISBN is usually printed with,
+// along with an "ISBN" label, and uppercases the X check digit.
+func Normalize(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "ISBN-13"), "ISBN-10")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "ISBN"), ":")
+	return strings.NewReplacer("-", "", " ", "").Replace(s)
+}
+
+// Parse validates an ISBN-10 or ISBN-13 and returns it as an ISBN-13
+// without separators, the form books are stored and matched by.
+func Parse(s string) (string, error) {
+	s = Normalize(s)
+
+	switch len(s) {
+	case 10:
+		if !digits(s[:9]) || !(digits(s[9:]) || s[9] == 'X') {
+			return "", ErrCharacter
+		}
+		if check10(s[:9]) != s[9] {
+			return "", ErrChecksum
+		}
+		return To13(s), nil
+	case 13:
+		if !digits(s) {
+			return "", ErrCharacter
+		}
+		if !strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979") {
+			return "", ErrPrefix
+		}
+		if check13(s[:12]) != s[12] {
+			return "", ErrChecksum
+		}
+		return s, nil
+	default:
+		return "", ErrLength
+	}
+}
+
+// To13 converts a valid ISBN-10 to its ISBN-13.
+func To13(isbn10 string) string {
+	body := "978" + isbn10[:9]
+	return body + string(check13(body))
+}
+
+// To10 converts a valid ISBN-13 to its ISBN-10. Only ISBNs in the 978 range
+// have one.
+func To10(isbn13 string) (string, bool) {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return "", false
+	}
+	body := isbn13[3:12]
+	return body + string(check10(body)), true
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// check10 computes the ISBN-10 check digit of the first nine digits.
+func check10(body string) byte {
+	sum := 0
+	for i := range 9 {
+		sum += int(body[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// check13 computes the ISBN-13 check digit of the first twelve digits.
+func check13(body string) byte {
+	sum := 0
+	for i := range 12 {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(body[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
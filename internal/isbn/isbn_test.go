@@ -0,0 +1,75 @@
+package isbn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		err   error
+	}{
+		{"ISBN-10", "0306406152", "9780306406157", nil},
+		{"ISBN-13", "9780306406157", "9780306406157", nil},
+		{"ISBN-10 with an X check digit", "080442957X", "9780804429573", nil},
+		{"ISBN-10 with a lowercase x", "080442957x", "9780804429573", nil},
+		{"979 prefix", "9791090636071", "9791090636071", nil},
+		{"hyphens", "978-0-306-40615-7", "9780306406157", nil},
+		{"spaces", " 0 306 40615 2 ", "9780306406157", nil},
+		{"ISBN label", "ISBN 978-0-306-40615-7", "9780306406157", nil},
+		{"ISBN-10 label", "ISBN-10: 0-306-40615-2", "9780306406157", nil},
+		{"ISBN-13 label in lowercase", "isbn-13: 978-0-306-40615-7", "9780306406157", nil},
+
+		{"empty", "", "", ErrLength},
+		{"too short", "030640615", "", ErrLength},
+		{"between the two lengths", "03064061521", "", ErrLength},
+		{"too long", "97803064061571", "", ErrLength},
+		{"letter in an ISBN-10", "03064O6152", "", ErrCharacter},
+		{"X before the last digit", "03064X6152", "", ErrCharacter},
+		{"X at the end of an ISBN-13", "978030640615X", "", ErrCharacter},
+		{"letter in an ISBN-13", "978O306406157", "", ErrCharacter},
+		{"wrong ISBN-10 check digit", "0306406153", "", ErrChecksum},
+		{"wrong X check digit", "030640615X", "", ErrChecksum},
+		{"wrong ISBN-13 check digit", "9780306406158", "", ErrChecksum},
+		{"ISBN-13 outside 978 and 979", "9770306406157", "", ErrPrefix},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.input)
+		if !errors.Is(err, test.err) || got != test.want {
+			t.Errorf("%s: Parse(%q) = %q, %v; want %q, %v", test.name, test.input, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestConversion(t *testing.T) {
+	tests := []struct {
+		isbn10 string
+		isbn13 string
+	}{
+		{"0306406152", "9780306406157"},
+		{"080442957X", "9780804429573"},
+		{"8535910662", "9788535910667"},
+		{"0000000000", "9780000000002"},
+	}
+
+	for _, test := range tests {
+		if got := To13(test.isbn10); got != test.isbn13 {
+			t.Errorf("To13(%q) = %q, want %q", test.isbn10, got, test.isbn13)
+		}
+		if got, ok := To10(test.isbn13); !ok || got != test.isbn10 {
+			t.Errorf("To10(%q) = %q, %t; want %q", test.isbn13, got, ok, test.isbn10)
+		}
+	}
+}
+
+func TestTo10WithoutAnISBN10Form(t *testing.T) {
+	for _, isbn13 := range []string{"9791090636071", "9798602405453", "030640615", ""} {
+		if got, ok := To10(isbn13); ok {
+			t.Errorf("To10(%q) = %q, want no ISBN-10", isbn13, got)
+		}
+	}
+}
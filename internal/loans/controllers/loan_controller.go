@@ -1,9 +1,15 @@
 package loans
 
 import (
+	"librarymvc/internal/apperror"
+	auth "librarymvc/internal/auth/controllers"
+	authModel "librarymvc/internal/auth/models"
 	"librarymvc/internal/loans/models"
+	"librarymvc/internal/paging"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,6 +28,7 @@ func (l *LoanController) RegisterRoutes(r *gin.Engine) {
 		loans.GET("/:id", l.GetLoan)
 		loans.GET("", l.GetAllLoans)
 		loans.PUT("/:id/return", l.ReturnBook)
+		loans.PUT("/:id/renew", l.RenewLoan)
 	}
 
 	users := r.Group("/loans/users")
@@ -32,18 +39,25 @@ func (l *LoanController) RegisterRoutes(r *gin.Engine) {
 
 func (l *LoanController) CreateLoan(ctx *gin.Context) {
 	var request struct {
-		BookID int64 `json:"bookID"`
-		UserID int64 `json:"userID"`
+		BookID  int64  `json:"bookID"`
+		Barcode string `json:"barcode"` // optional: lend this specific copy
+		UserID  int64  `json:"userID"`
 	}
 
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		ctx.Error(apperror.InvalidBody(err))
 		return
 	}
 
-	loan, err := l.loanService.CreateLoan(request.BookID, request.UserID)
+	var loan *models.Loan
+	var err error
+	if request.Barcode != "" {
+		loan, err = l.loanService.CreateLoanByBarcode(request.Barcode, request.UserID)
+	} else {
+		loan, err = l.loanService.CreateLoan(request.BookID, request.UserID)
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -53,39 +67,101 @@ func (l *LoanController) CreateLoan(ctx *gin.Context) {
 func (l *LoanController) GetLoan(ctx *gin.Context) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan ID"})
+		ctx.Error(apperror.InvalidID("loan"))
 		return
 	}
 
 	book, err := l.loanService.GetLoan(id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
+	}
+
+	if err := auth.CheckSelfOr(ctx, book.UserID, authModel.ViewCirculation); err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, book)
 }
 
+// GetAllLoans lists the loans a page at a time. Besides page, limit and
+// sort it filters by status, userID, bookID and the borrowedFrom/borrowedTo
+// and dueFrom/dueTo date ranges (YYYY-MM-DD, inclusive).
 func (l *LoanController) GetAllLoans(ctx *gin.Context) {
-	books, err := l.loanService.GetAllLoans()
+	page, err := paging.FromQuery(ctx.Request.URL.Query())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, books)
+	filter, err := loanFilter(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	loans, err := l.loanService.ListLoans(filter, page)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loans)
+}
+
+func loanFilter(ctx *gin.Context) (models.LoanFilter, error) {
+	filter := models.LoanFilter{Status: ctx.Query("status")}
+
+	ids := []struct {
+		name string
+		id   *int64
+	}{
+		{"userID", &filter.UserID},
+		{"bookID", &filter.BookID},
+	}
+	for _, param := range ids {
+		if value := ctx.Query(param.name); value != "" {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return filter, apperror.InvalidID(strings.TrimSuffix(param.name, "ID"))
+			}
+			*param.id = id
+		}
+	}
+
+	dates := []struct {
+		name string
+		date *time.Time
+	}{
+		{"borrowedFrom", &filter.BorrowedFrom},
+		{"borrowedTo", &filter.BorrowedTo},
+		{"dueFrom", &filter.DueFrom},
+		{"dueTo", &filter.DueTo},
+	}
+	for _, param := range dates {
+		if value := ctx.Query(param.name); value != "" {
+			date, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				return filter, models.ErrInvalidDate.WithMessage(param.name + " must be a date as YYYY-MM-DD")
+			}
+			*param.date = date
+		}
+	}
+
+	return filter, nil
 }
 
 func (l *LoanController) GetUserLoans(ctx *gin.Context) {
 	userId, err := strconv.ParseInt(ctx.Param("userId"), 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan ID"})
+		ctx.Error(apperror.InvalidID("user"))
 		return
 	}
 
 	loan, err := l.loanService.GetUserLoans(userId)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -95,15 +171,31 @@ func (l *LoanController) GetUserLoans(ctx *gin.Context) {
 func (l *LoanController) ReturnBook(ctx *gin.Context) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan ID"})
+		ctx.Error(apperror.InvalidID("loan"))
 		return
 	}
 
 	err = l.loanService.ReturnBook(id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.Status(http.StatusOK)
 }
+
+func (l *LoanController) RenewLoan(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("loan"))
+		return
+	}
+
+	loan, err := l.loanService.RenewLoan(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, loan)
+}
@@ -0,0 +1,105 @@
+package loans
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"librarymvc/internal/apperror"
+	bookModel "librarymvc/internal/books/models"
+	copyModel "librarymvc/internal/copies/models"
+	"librarymvc/internal/loans/services"
+	"librarymvc/internal/money"
+	"librarymvc/internal/testenv"
+	userModel "librarymvc/internal/users/models"
+)
+
+func TestConcurrentLoansOfTheLastCopy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const members = 20
+
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		repos := storage.Repos
+		now := time.Now()
+
+		book := &bookModel.Book{Title: "O Alienista", Author: "Machado de Assis", BookType: "emprestavel", CreatedAt: now, UpdatedAt: now}
+		if err := repos.Books.CreateBook(book); err != nil {
+			t.Fatal(err)
+		}
+		copy := &copyModel.Copy{BookID: book.ID, Barcode: "B0001", Condition: "good", Status: "available", CreatedAt: now, UpdatedAt: now}
+		if err := repos.Copies.CreateCopy(copy); err != nil {
+			t.Fatal(err)
+		}
+
+		var userIDs []int64
+		for i := 0; i < members; i++ {
+			user := &userModel.User{
+				Name:      fmt.Sprintf("Leitor Número %02d", i),
+				Email:     fmt.Sprintf("leitor%02d@lib.org", i),
+				Role:      userModel.RolePatron,
+				Category:  userModel.DefaultCategory,
+				Status:    userModel.StatusActive,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := repos.Users.CreateUser(user); err != nil {
+				t.Fatal(err)
+			}
+			userIDs = append(userIDs, user.ID)
+		}
+
+		loanService := services.NewLoanService(repos.Loans, storage.UnitOfWork, 3, money.FromCents(1000), storage.Clock)
+		router := gin.New()
+		router.Use(apperror.Middleware())
+		router.POST("/api/loans", NewLoanController(loanService).CreateLoan)
+
+		// Every member asks for the only copy at once
+		start := make(chan struct{})
+		statuses := make(chan int, members)
+		var wg sync.WaitGroup
+		for _, userID := range userIDs {
+			wg.Add(1)
+			go func(userID int64) {
+				defer wg.Done()
+				<-start
+				body := fmt.Sprintf(`{"bookID": %d, "userID": %d}`, book.ID, userID)
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/loans", strings.NewReader(body)))
+				statuses <- recorder.Code
+			}(userID)
+		}
+		close(start)
+		wg.Wait()
+		close(statuses)
+
+		counts := make(map[int]int)
+		for status := range statuses {
+			counts[status]++
+		}
+		if counts[http.StatusCreated] != 1 || counts[http.StatusConflict] != members-1 {
+			t.Errorf("statuses = %v, want 1 × 201 and %d × 409", counts, members-1)
+		}
+
+		available, err := repos.Copies.CountAvailableCopies(book.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if available != 0 {
+			t.Errorf("available copies = %d, want 0", available)
+		}
+
+		loans, err := repos.Loans.GetActiveLoans()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(loans) != 1 {
+			t.Errorf("open loans = %d, want 1", len(loans))
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"librarymvc/internal/apperror"
+	"librarymvc/internal/money"
+)
+
+var (
+	ErrLoanNotFound        = apperror.NewNotFound("loan_not_found", "loan not found")
+	ErrNotLoanable         = apperror.NewValidation("not_loanable", "this material type cannot be borrowed")
+	ErrNotRenewable        = apperror.NewValidation("not_renewable", "this material type cannot be renewed")
+	ErrAlreadyBorrowed     = apperror.NewConflict("already_on_loan", "user already has this book on loan")
+	ErrAlreadyReturned     = apperror.NewConflict("already_returned", "book already returned")
+	ErrLoanNotOpen         = apperror.NewConflict("loan_not_open", "only active loans can be renewed")
+	ErrMaxRenewals         = apperror.NewConflict("max_renewals_reached", "maximum number of renewals reached")
+	ErrRenewalGraceExpired = apperror.NewConflict("renewal_grace_expired", "loan is overdue past the renewal grace period")
+	ErrHeldForAnother      = apperror.NewConflict("held_for_another_member", "another member has a hold on this title")
+	ErrCopyHeldForAnother  = apperror.NewConflict("copy_held_for_another_member", "copy is set aside for another member's hold")
+	ErrLoanLimitReached    = apperror.NewConflict("loan_limit_reached", "loan limit reached")
+	ErrOutstandingBalance  = apperror.NewForbidden("outstanding_balance", "blocked: outstanding balance")
+	ErrMembershipSuspended = apperror.NewForbidden("membership_suspended", "membership is suspended")
+	ErrMembershipExpired   = apperror.NewForbidden("membership_expired", "membership has expired; renew it to borrow")
+	ErrInvalidLoanStatus   = apperror.NewValidation("invalid_loan_status", "status must be active, returned or overdue")
+	ErrInvalidDate         = apperror.NewBadRequest("invalid_date", "dates must be given as YYYY-MM-DD")
+)
+
+// LoanLimitError is returned when a member already has as many active loans
+// as they are allowed to hold at once.
+type LoanLimitError struct {
+	Limit int
+}
+
+func (e *LoanLimitError) Error() string {
+	return fmt.Sprintf("loan limit reached: at most %d active loans", e.Limit)
+}
+
+func (e *LoanLimitError) Unwrap() error {
+	return ErrLoanLimitReached
+}
+
+func (e *LoanLimitError) Details() map[string]any {
+	return map[string]any{"limit": e.Limit}
+}
+
+// BalanceBlockError is returned when a member owes more in fines than the
+// library allows while still borrowing or renewing.
+type BalanceBlockError struct {
+	Balance   money.Money
+	Threshold money.Money
+}
+
+func (e *BalanceBlockError) Error() string {
+	return fmt.Sprintf("blocked: outstanding balance of %s exceeds the limit of %s", e.Balance, e.Threshold)
+}
+
+func (e *BalanceBlockError) Unwrap() error {
+	return ErrOutstandingBalance
+}
+
+func (e *BalanceBlockError) Details() map[string]any {
+	return map[string]any{"balance": e.Balance, "threshold": e.Threshold}
+}
@@ -1,16 +1,41 @@
 package models
 
-import "time"
+import (
+	"librarymvc/internal/money"
+	"time"
+)
 
 type Loan struct {
-	ID         int64     `json:"ID"`
-	BookID     int64     `json:"bookID"`
-	UserID     int64     `json:"userID"`
-	BorrowedAt time.Time `json:"borrowedAt"`
-	DueDate    time.Time `json:"dueDate"`    // Data de devolução prevista
-	ReturnedAt time.Time `json:"returnedAt"`
-	Fine       float64   `json:"fine"`   // Multa por atraso (R$ 2.00 por dia)
-	Status     string    `json:"status"` // active, returned, overdue
-	CreatedAt  time.Time `json:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt"`
+	ID         int64       `json:"ID"`
+	BookID     int64       `json:"bookID"`
+	CopyID     int64       `json:"copyID"` // Exemplar físico emprestado
+	UserID     int64       `json:"userID"`
+	BorrowedAt time.Time   `json:"borrowedAt"`
+	DueDate    time.Time   `json:"dueDate"` // Data de devolução prevista
+	ReturnedAt time.Time   `json:"returnedAt"`
+	Renewals   int         `json:"renewals"` // Quantidade de renovações realizadas
+	Fine       money.Money `json:"fine"`     // Multa por atraso (taxa diária da política do livro)
+	Status     string      `json:"status"`   // active, returned, overdue
+	CreatedAt  time.Time   `json:"createdAt"`
+	UpdatedAt  time.Time   `json:"updatedAt"`
+}
+
+// LoanFilter narrows a loan listing; zero fields match every loan. The date
+// ranges are inclusive and compare whole days.
+type LoanFilter struct {
+	Status       string // active, returned, overdue
+	UserID       int64
+	BookID       int64
+	BorrowedFrom time.Time
+	BorrowedTo   time.Time
+	DueFrom      time.Time
+	DueTo        time.Time
+}
+
+// LoanSortFields are the fields a loan listing can be sorted by.
+var LoanSortFields = []string{"id", "userID", "bookID", "borrowedAt", "dueDate", "returnedAt", "status", "fine", "createdAt"}
+
+// Open reports whether the book is still out, whether or not it is overdue.
+func (l *Loan) Open() bool {
+	return l.Status == "active" || l.Status == "overdue"
 }
@@ -1,10 +1,19 @@
 package models
 
+import "librarymvc/internal/paging"
+
 type LoanRepository interface {
 	CreateLoan(loan *Loan) error
 	UpdateLoan(loan *Loan) error
 	ReturnBook(loan *Loan) error
 	GetLoan(id int64) (*Loan, error)
+	// GetActiveUserLoans returns the user's open (active or overdue) loans.
 	GetActiveUserLoans(userId int64) ([]*Loan, error)
+	// GetActiveLoans returns every open (active or overdue) loan.
+	GetActiveLoans() ([]*Loan, error)
 	GetAllLoans() ([]*Loan, error)
+	// MoveBookLoans moves every loan of a book, returned ones included, to
+	// another book.
+	MoveBookLoans(fromBookID, toBookID int64) error
+	ListLoans(filter LoanFilter, page paging.Request) (*paging.Page[*Loan], error)
 }
@@ -1,9 +1,37 @@
 package models
 
+import (
+	"librarymvc/internal/money"
+	"librarymvc/internal/paging"
+	userModel "librarymvc/internal/users/models"
+)
+
+// DefaultLoanLimit is how many books a member may have on loan at once when
+// neither the member nor the library configuration says otherwise.
+const DefaultLoanLimit = 3
+
+// DefaultFineThreshold is the outstanding balance above which a member can
+// no longer borrow or renew until they pay.
+var DefaultFineThreshold = money.FromCents(1000)
+
 type LoanService interface {
 	CreateLoan(bookID, userID int64) (*Loan, error)
+	CreateLoanByBarcode(barcode string, userID int64) (*Loan, error)
 	ReturnBook(loanID int64) error
+	RenewLoan(loanID int64) (*Loan, error)
+	// RenewalBlock reports why the loan cannot be renewed now, or nil if
+	// it can.
+	RenewalBlock(loanID int64) error
+	// MarkOverdueLoans flags open loans past their due date as overdue and
+	// updates their accrued fines. It returns how many loans became overdue.
+	MarkOverdueLoans() (int, error)
 	GetLoan(id int64) (*Loan, error)
 	GetUserLoans(userID int64) ([]*Loan, error)
 	GetAllLoans() ([]*Loan, error)
+	ListLoans(filter LoanFilter, page paging.Request) (*paging.Page[*Loan], error)
+	// LoanLimit is the maximum number of active loans the user may have.
+	LoanLimit(user *userModel.User) int
+	// BorrowingBlock reports why the user may not borrow or renew, or nil
+	// if they may.
+	BorrowingBlock(userID int64) (*BalanceBlockError, error)
 }
@@ -1,9 +1,12 @@
 package repositories
 
 import (
-	"errors"
+	"cmp"
 	"librarymvc/internal/loans/models"
+	"librarymvc/internal/paging"
+	"slices"
 	"sync"
+	"time"
 )
 
 type LoanRepository struct {
@@ -35,7 +38,7 @@ func (l *LoanRepository) UpdateLoan(loan *models.Loan) error {
 	defer l.mu.Unlock()
 	_, exists := l.loans[loan.ID]
 	if !exists {
-		return errors.New("loan not found")
+		return models.ErrLoanNotFound
 	}
 	l.loans[loan.ID] = loan
 	return nil
@@ -47,7 +50,7 @@ func (l *LoanRepository) ReturnBook(loan *models.Loan) error {
 
 	_, exists := l.loans[loan.ID]
 	if !exists {
-		return errors.New("loan not found")
+		return models.ErrLoanNotFound
 	}
 
 	loan.Status = "returned"
@@ -62,19 +65,46 @@ func (l *LoanRepository) GetLoan(id int64) (*models.Loan, error) {
 
 	loan, exists := l.loans[id]
 	if !exists {
-		return nil, errors.New("loan not found")
+		return nil, models.ErrLoanNotFound
 	}
 
 	return loan, nil
 }
 
+func (l *LoanRepository) MoveBookLoans(fromBookID, toBookID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, loan := range l.loans {
+		if loan.BookID == fromBookID {
+			loan.BookID = toBookID
+		}
+	}
+
+	return nil
+}
+
 func (l *LoanRepository) GetActiveUserLoans(userId int64) ([]*models.Loan, error) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
 	activeLoans := make([]*models.Loan, 0)
 	for _, loan := range l.loans {
-		if loan.UserID == userId && loan.Status == "active" {
+		if loan.UserID == userId && loan.Open() {
+			activeLoans = append(activeLoans, loan)
+		}
+	}
+
+	return activeLoans, nil
+}
+
+func (l *LoanRepository) GetActiveLoans() ([]*models.Loan, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	activeLoans := make([]*models.Loan, 0)
+	for _, loan := range l.loans {
+		if loan.Open() {
 			activeLoans = append(activeLoans, loan)
 		}
 	}
@@ -86,10 +116,78 @@ func (l *LoanRepository) GetAllLoans() ([]*models.Loan, error) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
+	return l.filter(func(*models.Loan) bool { return true }), nil
+}
+
+// filter returns the loans matching keep in ID order. The caller must hold
+// the lock.
+func (l *LoanRepository) filter(keep func(loan *models.Loan) bool) []*models.Loan {
 	loans := make([]*models.Loan, 0, len(l.loans))
 	for _, loan := range l.loans {
-		loans = append(loans, loan)
+		if keep(loan) {
+			loans = append(loans, loan)
+		}
+	}
+
+	slices.SortFunc(loans, func(x, y *models.Loan) int { return cmp.Compare(x.ID, y.ID) })
+	return loans
+}
+
+var loanSorts = map[string]func(x, y *models.Loan) int{
+	"userID":     func(x, y *models.Loan) int { return cmp.Compare(x.UserID, y.UserID) },
+	"bookID":     func(x, y *models.Loan) int { return cmp.Compare(x.BookID, y.BookID) },
+	"borrowedAt": func(x, y *models.Loan) int { return x.BorrowedAt.Compare(y.BorrowedAt) },
+	"dueDate":    func(x, y *models.Loan) int { return x.DueDate.Compare(y.DueDate) },
+	"returnedAt": func(x, y *models.Loan) int { return x.ReturnedAt.Compare(y.ReturnedAt) },
+	"status":     func(x, y *models.Loan) int { return cmp.Compare(x.Status, y.Status) },
+	"fine":       func(x, y *models.Loan) int { return cmp.Compare(x.Fine, y.Fine) },
+	"createdAt":  func(x, y *models.Loan) int { return x.CreatedAt.Compare(y.CreatedAt) },
+}
+
+func (l *LoanRepository) ListLoans(filter models.LoanFilter, page paging.Request) (*paging.Page[*models.Loan], error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	loans := l.filter(func(loan *models.Loan) bool {
+		return (filter.Status == "" || loan.Status == filter.Status) &&
+			(filter.UserID == 0 || loan.UserID == filter.UserID) &&
+			(filter.BookID == 0 || loan.BookID == filter.BookID) &&
+			inDayRange(loan.BorrowedAt, filter.BorrowedFrom, filter.BorrowedTo) &&
+			inDayRange(loan.DueDate, filter.DueFrom, filter.DueTo)
+	})
+
+	return paging.Slice(loans, page, loanSorts), nil
+}
+
+// inDayRange reports whether t falls on one of the days from from to to; a
+// zero bound leaves that side open.
+func inDayRange(t, from, to time.Time) bool {
+	day := t.Format(time.DateOnly)
+	return (from.IsZero() || day >= from.Format(time.DateOnly)) &&
+		(to.IsZero() || day <= to.Format(time.DateOnly))
+}
+
+// Snapshot copies the current state and returns a function that restores it.
+// It is used by the in-memory unit of work to roll back failed transactions.
+func (l *LoanRepository) Snapshot() func() {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	loans := make(map[int64]models.Loan, len(l.loans))
+	for id, loan := range l.loans {
+		loans[id] = *loan
 	}
+	nextID := l.nextID
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
 
-	return loans, nil
+		l.loans = make(map[int64]*models.Loan, len(loans))
+		for id, loan := range loans {
+			loan := loan
+			l.loans[id] = &loan
+		}
+		l.nextID = nextID
+	}
 }
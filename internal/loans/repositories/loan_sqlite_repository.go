@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"librarymvc/internal/database"
+	"librarymvc/internal/loans/models"
+	"librarymvc/internal/paging"
+	"time"
+)
+
+type SQLiteLoanRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteLoanRepository(db database.DBTX) models.LoanRepository {
+	return &SQLiteLoanRepository{db: db}
+}
+
+const loanColumns = `id, book_id, copy_id, user_id, borrowed_at, due_date, returned_at, renewals, fine_cents, status, created_at, updated_at`
+
+func scanLoan(row interface{ Scan(...any) error }) (*models.Loan, error) {
+	var loan models.Loan
+	err := row.Scan(
+		&loan.ID,
+		&loan.BookID,
+		&loan.CopyID,
+		&loan.UserID,
+		&loan.BorrowedAt,
+		&loan.DueDate,
+		&loan.ReturnedAt,
+		&loan.Renewals,
+		&loan.Fine,
+		&loan.Status,
+		&loan.CreatedAt,
+		&loan.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+func (l *SQLiteLoanRepository) queryLoans(query string, args ...any) ([]*models.Loan, error) {
+	rows, err := l.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loans := make([]*models.Loan, 0)
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
+		}
+		loans = append(loans, loan)
+	}
+
+	return loans, rows.Err()
+}
+
+func (l *SQLiteLoanRepository) CreateLoan(loan *models.Loan) error {
+	result, err := l.db.Exec(
+		`INSERT INTO loans (book_id, copy_id, user_id, borrowed_at, due_date, returned_at, renewals, fine_cents, status, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		loan.BookID, loan.CopyID, loan.UserID, loan.BorrowedAt, loan.DueDate, loan.ReturnedAt,
+		loan.Renewals, loan.Fine, loan.Status, loan.CreatedAt, loan.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	loan.ID = id
+
+	return nil
+}
+
+func (l *SQLiteLoanRepository) UpdateLoan(loan *models.Loan) error {
+	result, err := l.db.Exec(
+		`UPDATE loans
+		 SET book_id = ?, copy_id = ?, user_id = ?, borrowed_at = ?, due_date = ?, returned_at = ?,
+		     renewals = ?, fine_cents = ?, status = ?, updated_at = ?
+		 WHERE id = ?`,
+		loan.BookID, loan.CopyID, loan.UserID, loan.BorrowedAt, loan.DueDate, loan.ReturnedAt,
+		loan.Renewals, loan.Fine, loan.Status, loan.UpdatedAt, loan.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrLoanNotFound
+	}
+
+	return nil
+}
+
+func (l *SQLiteLoanRepository) ReturnBook(loan *models.Loan) error {
+	loan.Status = "returned"
+	return l.UpdateLoan(loan)
+}
+
+func (l *SQLiteLoanRepository) GetLoan(id int64) (*models.Loan, error) {
+	row := l.db.QueryRow(`SELECT `+loanColumns+` FROM loans WHERE id = ?`, id)
+
+	loan, err := scanLoan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrLoanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return loan, nil
+}
+
+func (l *SQLiteLoanRepository) MoveBookLoans(fromBookID, toBookID int64) error {
+	_, err := l.db.Exec(`UPDATE loans SET book_id = ? WHERE book_id = ?`, toBookID, fromBookID)
+	return err
+}
+
+func (l *SQLiteLoanRepository) GetActiveUserLoans(userId int64) ([]*models.Loan, error) {
+	return l.queryLoans(
+		`SELECT `+loanColumns+` FROM loans WHERE user_id = ? AND status IN ('active', 'overdue') ORDER BY id`,
+		userId,
+	)
+}
+
+func (l *SQLiteLoanRepository) GetActiveLoans() ([]*models.Loan, error) {
+	return l.queryLoans(`SELECT ` + loanColumns + ` FROM loans WHERE status IN ('active', 'overdue') ORDER BY id`)
+}
+
+func (l *SQLiteLoanRepository) GetAllLoans() ([]*models.Loan, error) {
+	return l.queryLoans(`SELECT ` + loanColumns + ` FROM loans ORDER BY id`)
+}
+
+var loanSortColumns = map[string]string{
+	"id":         "id",
+	"userID":     "user_id",
+	"bookID":     "book_id",
+	"borrowedAt": "borrowed_at",
+	"dueDate":    "due_date",
+	"returnedAt": "returned_at",
+	"status":     "status",
+	"fine":       "fine_cents",
+	"createdAt":  "created_at",
+}
+
+func (l *SQLiteLoanRepository) ListLoans(filter models.LoanFilter, page paging.Request) (*paging.Page[*models.Loan], error) {
+	var where database.Where
+	if filter.Status != "" {
+		where.Add(`status = ?`, filter.Status)
+	}
+	if filter.UserID != 0 {
+		where.Add(`user_id = ?`, filter.UserID)
+	}
+	if filter.BookID != 0 {
+		where.Add(`book_id = ?`, filter.BookID)
+	}
+	addDayRange(&where, "borrowed_at", filter.BorrowedFrom, filter.BorrowedTo)
+	addDayRange(&where, "due_date", filter.DueFrom, filter.DueTo)
+
+	var total int
+	if err := l.db.QueryRow(`SELECT COUNT(*) FROM loans`+where.String(), where.Args()...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	loans, err := l.queryLoans(
+		`SELECT `+loanColumns+` FROM loans`+where.String()+page.OrderBy(loanSortColumns)+` LIMIT ? OFFSET ?`,
+		append(where.Args(), page.Limit, page.Offset())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(loans, total, page), nil
+}
+
+// addDayRange restricts column to the days from from to to; a zero bound
+// leaves that side open.
+func addDayRange(where *database.Where, column string, from, to time.Time) {
+	if !from.IsZero() {
+		where.Add(database.DayOf(column)+` >= ?`, from.Format(time.DateOnly))
+	}
+	if !to.IsZero() {
+		where.Add(database.DayOf(column)+` <= ?`, to.Format(time.DateOnly))
+	}
+}
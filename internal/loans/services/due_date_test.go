@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"librarymvc/internal/loans/models"
+	"librarymvc/internal/money"
+	"librarymvc/internal/testenv"
+)
+
+// The library is in São Paulo, open Monday to Saturday. The default loan
+// policy lends for 12 days at 2.00 per open day late, with no fine grace
+// and 2 days to renew after the due date. Brazil kept daylight saving time
+// until 2019: clocks went forward at midnight on 2018-11-04 and back at
+// midnight on 2019-02-17, both Sundays.
+var saoPaulo = mustLoadLocation("America/Sao_Paulo")
+
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
+
+func local(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, saoPaulo)
+}
+
+func TestDueDates(t *testing.T) {
+	tests := []struct {
+		name     string
+		borrowed time.Time
+		due      time.Time
+	}{
+		{"across the end of January", local(2026, time.January, 28, 10, 0), local(2026, time.February, 9, 10, 0)},
+		{"from the last day of January", local(2026, time.January, 31, 10, 0), local(2026, time.February, 12, 10, 0)},
+		{"across the end of the year", local(2025, time.December, 26, 10, 0), local(2026, time.January, 7, 10, 0)},
+		{"due on a Sunday moves to Monday", local(2026, time.February, 3, 10, 0), local(2026, time.February, 16, 10, 0)},
+		{"across the start of DST", local(2018, time.October, 24, 10, 0), local(2018, time.November, 5, 10, 0)},
+		{"due on the Sunday DST ends", local(2019, time.February, 5, 10, 0), local(2019, time.February, 18, 10, 0)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+				f := newFixture(t, storage, test.borrowed)
+				loan := f.borrow(t)
+
+				for _, got := range []*models.Loan{loan, f.reload(t, loan)} {
+					if !got.DueDate.Equal(test.due) {
+						t.Errorf("due date = %v, want %v", got.DueDate.In(saoPaulo), test.due)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestFinesOnReturn(t *testing.T) {
+	tests := []struct {
+		name     string
+		borrowed time.Time
+		returned time.Time
+		fine     money.Money
+	}{
+		// Due 2026-02-09 10:00
+		{"on the due date", local(2026, time.January, 28, 10, 0), local(2026, time.February, 9, 18, 0), 0},
+		{"a minute short of a day late", local(2026, time.January, 28, 10, 0), local(2026, time.February, 10, 9, 59), 0},
+		{"a day late", local(2026, time.January, 28, 10, 0), local(2026, time.February, 10, 10, 0), money.FromCents(200)},
+		{"a week late, not counting Sunday", local(2026, time.January, 28, 10, 0), local(2026, time.February, 16, 10, 0), money.FromCents(1200)},
+		// Due 2026-02-12 10:00, returned in March
+		{"across the end of February", local(2026, time.January, 31, 10, 0), local(2026, time.March, 2, 10, 0), money.FromCents(3000)},
+		// Due 2018-11-01 10:00 -03, returned after clocks went forward
+		{"across the start of DST", local(2018, time.October, 20, 10, 0), local(2018, time.November, 5, 10, 0), money.FromCents(600)},
+		{"across the start of DST, short of a day", local(2018, time.October, 20, 10, 0), local(2018, time.November, 5, 9, 30), money.FromCents(400)},
+		// Due 2019-02-13 10:00 -02, returned after clocks went back
+		{"across the end of DST", local(2019, time.February, 1, 10, 0), local(2019, time.February, 18, 10, 0), money.FromCents(800)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+				f := newFixture(t, storage, test.borrowed)
+				loan := f.borrow(t)
+
+				f.clock.Set(test.returned)
+				if err := f.loans.ReturnBook(loan.ID); err != nil {
+					t.Fatalf("ReturnBook() = %v", err)
+				}
+				if got := f.reload(t, loan).Fine; got != test.fine {
+					t.Errorf("fine = %v, want %v", got, test.fine)
+				}
+			})
+		})
+	}
+}
+
+func TestOverdueDetection(t *testing.T) {
+	tests := []struct {
+		name     string
+		borrowed time.Time
+		now      time.Time
+		status   string
+		fine     money.Money
+	}{
+		// Due 2026-02-09 10:00
+		{"a minute before the due time", local(2026, time.January, 28, 10, 0), local(2026, time.February, 9, 9, 59), "active", 0},
+		{"a minute after the due time", local(2026, time.January, 28, 10, 0), local(2026, time.February, 9, 10, 1), "overdue", 0},
+		{"two days after", local(2026, time.January, 28, 10, 0), local(2026, time.February, 11, 10, 0), "overdue", money.FromCents(400)},
+		// Due 2018-11-01 10:00 -03
+		{"the day after the start of DST", local(2018, time.October, 20, 10, 0), local(2018, time.November, 5, 10, 0), "overdue", money.FromCents(600)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+				f := newFixture(t, storage, test.borrowed)
+				loan := f.borrow(t)
+
+				f.clock.Set(test.now)
+				if _, err := f.loans.MarkOverdueLoans(); err != nil {
+					t.Fatalf("MarkOverdueLoans() = %v", err)
+				}
+				got := f.reload(t, loan)
+				if got.Status != test.status || got.Fine != test.fine {
+					t.Errorf("status %q, fine %v; want %q, %v", got.Status, got.Fine, test.status, test.fine)
+				}
+				if estimate, err := f.loans.CalculateFine(got); err != nil || estimate != test.fine {
+					t.Errorf("CalculateFine() = %v, %v; want %v", estimate, err, test.fine)
+				}
+			})
+		})
+	}
+}
+
+func TestRenewalGracePeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		borrowed time.Time
+		renewed  time.Time
+		err      error
+	}{
+		// Due Saturday 2026-01-31 10:00, renewable until Monday 2026-02-02 10:00
+		{"at the end of the grace", local(2026, time.January, 19, 10, 0), local(2026, time.February, 2, 10, 0), nil},
+		{"a minute after the grace", local(2026, time.January, 19, 10, 0), local(2026, time.February, 2, 10, 1), models.ErrRenewalGraceExpired},
+		// Due Saturday 2018-11-03 10:00 -03, renewable until Monday 2018-11-05 10:00 -02
+		{"at the end of a grace spanning the start of DST", local(2018, time.October, 22, 10, 0), local(2018, time.November, 5, 10, 0), nil},
+		{"a minute after a grace spanning the start of DST", local(2018, time.October, 22, 10, 0), local(2018, time.November, 5, 10, 1), models.ErrRenewalGraceExpired},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+				f := newFixture(t, storage, test.borrowed)
+				loan := f.borrow(t)
+
+				f.clock.Set(test.renewed)
+				_, err := f.loans.RenewLoan(loan.ID)
+				if !errors.Is(err, test.err) {
+					t.Errorf("RenewLoan() = %v, want %v", err, test.err)
+				}
+			})
+		})
+	}
+}
@@ -2,65 +2,201 @@ package services
 
 import (
 	"errors"
-	bookService "librarymvc/internal/books/models"
+	"fmt"
+	accountModel "librarymvc/internal/accounts/models"
+	bookModel "librarymvc/internal/books/models"
+	calendarModel "librarymvc/internal/calendar/models"
+	"librarymvc/internal/clock"
+	copyModel "librarymvc/internal/copies/models"
+	holdModel "librarymvc/internal/holds/models"
+	holdService "librarymvc/internal/holds/services"
 	"librarymvc/internal/loans/models"
-	userService "librarymvc/internal/users/models"
+	"librarymvc/internal/money"
+	"librarymvc/internal/paging"
+	policyModel "librarymvc/internal/policies/models"
+	"librarymvc/internal/unitofwork"
+	userModel "librarymvc/internal/users/models"
 	"time"
 )
 
 type LoanService struct {
 	loanRepository models.LoanRepository
-	bookService    bookService.BookService
-	userService    userService.UserService
+	unitOfWork     unitofwork.UnitOfWork
+	loanLimit      int
+	fineThreshold  money.Money
+	clock          clock.Clock
 }
 
+// NewLoanService creates the loan service. loanLimit is the library-wide
+// maximum of active loans per member, used for members without their own.
+// Members whose outstanding balance is above fineThreshold are blocked from
+// borrowing and renewing.
 func NewLoanService(
 	loanRepository models.LoanRepository,
-	bookService bookService.BookService,
-	userService userService.UserService,
+	unitOfWork unitofwork.UnitOfWork,
+	loanLimit int,
+	fineThreshold money.Money,
+	clock clock.Clock,
 ) models.LoanService {
+	if loanLimit <= 0 {
+		loanLimit = models.DefaultLoanLimit
+	}
 	return &LoanService{
 		loanRepository: loanRepository,
-		bookService:    bookService,
-		userService:    userService,
+		unitOfWork:     unitOfWork,
+		loanLimit:      loanLimit,
+		fineThreshold:  fineThreshold,
+		clock:          clock,
 	}
 }
 
 func (l *LoanService) CreateLoan(bookId, userId int64) (*models.Loan, error) {
-	book, err := l.bookService.GetBook(bookId)
+	var loan *models.Loan
+
+	// Loan creation and copy reservation are committed together
+	err := l.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		book, err := repos.Books.GetBook(bookId)
+		if err != nil {
+			return err
+		}
+
+		loan, err = l.checkout(repos, book, userId, func(hold *holdModel.Hold) (*copyModel.Copy, error) {
+			// Hand over the copy set aside for the member's hold, if any
+			if hold != nil && hold.Status == "ready" {
+				if err := repos.Copies.TransitionCopy(hold.CopyID, "on_hold", "on_loan"); err != nil {
+					return nil, err
+				}
+				return repos.Copies.GetCopy(hold.CopyID)
+			}
+			return repos.Copies.ReserveAvailableCopy(book.ID)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loan, nil
+}
+
+// CreateLoanByBarcode lends the specific copy scanned at the desk.
+func (l *LoanService) CreateLoanByBarcode(barcode string, userId int64) (*models.Loan, error) {
+	var loan *models.Loan
+
+	err := l.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		copy, err := repos.Copies.GetCopyByBarcode(barcode)
+		if err != nil {
+			return err
+		}
+
+		book, err := repos.Books.GetBook(copy.BookID)
+		if err != nil {
+			return err
+		}
+
+		loan, err = l.checkout(repos, book, userId, func(hold *holdModel.Hold) (*copyModel.Copy, error) {
+			if copy.Status == "on_hold" {
+				if hold == nil || hold.CopyID != copy.ID {
+					return nil, models.ErrCopyHeldForAnother
+				}
+				return copy, repos.Copies.TransitionCopy(copy.ID, "on_hold", "on_loan")
+			}
+			return copy, repos.Copies.ReserveCopy(copy.ID)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loan, nil
+}
+
+// checkout applies the lending rules and creates the loan. reserve is only
+// called once the rules pass, receives the member's active hold on the book
+// (nil if none) and must atomically put a copy on loan.
+func (l *LoanService) checkout(
+	repos *unitofwork.Repositories,
+	book *bookModel.Book,
+	userId int64,
+	reserve func(hold *holdModel.Hold) (*copyModel.Copy, error),
+) (*models.Loan, error) {
+	// Check if book can be borrowed under its material type's policy
+	policy, err := repos.Policies.GetPolicyByCode(book.BookType)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check if book can be borrowed
-	if book.BookType == "referencia" {
-		return nil, errors.New("livro de referência não pode ser emprestado - deve permanecer na biblioteca")
+	if !policy.Loanable {
+		return nil, models.ErrNotLoanable.WithMessage(fmt.Sprintf("material do tipo %q não pode ser emprestado - deve permanecer na biblioteca", policy.Name))
 	}
 
-	if book.Quantity <= 0 {
-		return nil, errors.New("book is not available")
+	user, err := repos.Users.GetUser(userId)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = l.userService.GetUser(userId)
+	if err := l.checkMembership(user); err != nil {
+		return nil, err
+	}
+
+	activeLoans, err := repos.Loans.GetActiveUserLoans(userId)
 	if err != nil {
 		return nil, err
 	}
 
-	activeLoans, err := l.loanRepository.GetActiveUserLoans(userId)
+	if limit := l.LoanLimit(user); len(activeLoans) >= limit {
+		return nil, &models.LoanLimitError{Limit: limit}
+	}
+
+	if err := l.checkBalance(repos, userId); err != nil {
+		return nil, err
+	}
+
+	for _, active := range activeLoans {
+		if active.BookID == book.ID {
+			return nil, models.ErrAlreadyBorrowed
+		}
+	}
+
+	hold, err := userHold(repos, book.ID, userId)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(activeLoans) > 0 {
-		return nil, errors.New("user has active loans")
+	// Reserve the copy with an atomic conditional update instead of
+	// checking the available quantity, which may already be stale
+	copy, err := reserve(hold)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calculate due date based on book's loan duration
-	now := time.Now()
-	dueDate := now.AddDate(0, 0, book.LoanDuration)
+	// Calculate due date based on the book's loan duration or its policy's default
+	now := l.clock.Now()
+
+	// Borrowing the title fulfils the member's hold; a different copy set
+	// aside for them goes to the next member in line
+	if hold != nil {
+		if hold.Status == "ready" && hold.CopyID != copy.ID {
+			if err := holdService.ReleaseHeldCopy(repos, hold.CopyID, hold.BookID, now); err != nil {
+				return nil, err
+			}
+		}
+		hold.Status = "fulfilled"
+		hold.UpdatedAt = now
+		if err := repos.Holds.UpdateHold(hold); err != nil {
+			return nil, err
+		}
+	}
+	calendar, err := calendarModel.LoadCalendar(repos.Calendar)
+	if err != nil {
+		return nil, err
+	}
+	dueDate := calendar.NextOpenDay(now.AddDate(0, 0, loanDays(book, policy)))
 
 	loan := &models.Loan{
-		BookID:     bookId,
+		BookID:     book.ID,
+		CopyID:     copy.ID,
 		UserID:     userId,
 		BorrowedAt: now,
 		DueDate:    dueDate,
@@ -70,69 +206,359 @@ func (l *LoanService) CreateLoan(bookId, userId int64) (*models.Loan, error) {
 		UpdatedAt:  now,
 	}
 
-	err = l.loanRepository.CreateLoan(loan)
-	if err != nil {
+	if err := repos.Loans.CreateLoan(loan); err != nil {
 		return nil, err
 	}
 
-	book.Quantity--
-	if err = l.bookService.UpdateBook(book.ID, book); err != nil {
+	return loan, nil
+}
+
+func (l *LoanService) ReturnBook(loanId int64) error {
+	return l.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		loan, err := repos.Loans.GetLoan(loanId)
+		if err != nil {
+			return err
+		}
+
+		if loan.Status == "returned" {
+			return models.ErrAlreadyReturned
+		}
+
+		policy, err := policyForBook(repos, loan.BookID)
+		if err != nil {
+			return err
+		}
+
+		now := l.clock.Now()
+		loan.Status = "returned"
+		loan.UpdatedAt = now
+		loan.ReturnedAt = now
+
+		calendar, err := calendarModel.LoadCalendar(repos.Calendar)
+		if err != nil {
+			return err
+		}
+
+		// Calculate fine if overdue, under the material type's fine policy
+		loan.Fine = fineFor(loan, policy, calendar, now)
+
+		if err := repos.Loans.UpdateLoan(loan); err != nil {
+			return err
+		}
+
+		// The fine becomes a charge on the member's account
+		if loan.Fine > 0 {
+			err := repos.Transactions.CreateTransaction(&accountModel.Transaction{
+				UserID:    loan.UserID,
+				LoanID:    loan.ID,
+				Type:      "charge",
+				Reason:    "overdue",
+				Amount:    loan.Fine,
+				Note:      fmt.Sprintf("Multa por atraso - empréstimo #%d", loan.ID),
+				CreatedAt: now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// The returned copy goes to the first member waiting for the title
+		offered, err := holdService.OfferCopy(repos, loan.CopyID, loan.BookID, now)
+		if err != nil {
+			return err
+		}
+		if offered {
+			return repos.Copies.TransitionCopy(loan.CopyID, "on_loan", "on_hold")
+		}
+
+		return repos.Copies.ReleaseCopy(loan.CopyID)
+	})
+}
+
+// RenewLoan pushes the due date forward by the loan duration, within the
+// limits of the book's loan policy, and recomputes the fine estimate.
+func (l *LoanService) RenewLoan(loanId int64) (*models.Loan, error) {
+	var loan *models.Loan
+
+	err := l.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		var err error
+		loan, err = repos.Loans.GetLoan(loanId)
+		if err != nil {
+			return err
+		}
+
+		book, policy, err := l.checkRenewal(repos, loan)
+		if err != nil {
+			return err
+		}
+
+		calendar, err := calendarModel.LoadCalendar(repos.Calendar)
+		if err != nil {
+			return err
+		}
+
+		now := l.clock.Now()
+		loan.DueDate = calendar.NextOpenDay(inZoneOf(loan.DueDate, now).AddDate(0, 0, loanDays(book, policy)))
+		loan.Renewals++
+		loan.UpdatedAt = now
+		if loan.DueDate.After(now) {
+			loan.Status = "active"
+		}
+		// The fine estimate follows the new due date, so a renewed loan that
+		// is no longer overdue doesn't keep the fine it was accruing
+		loan.Fine = fineFor(loan, policy, calendar, now)
+
+		return repos.Loans.UpdateLoan(loan)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return loan, err
+	return loan, nil
 }
 
-func (l *LoanService) ReturnBook(loanId int64) error {
-	loan, err := l.loanRepository.GetLoan(loanId)
-	if err != nil {
+func (l *LoanService) RenewalBlock(loanID int64) error {
+	return l.unitOfWork.Read(func(repos *unitofwork.Repositories) error {
+		loan, err := repos.Loans.GetLoan(loanID)
+		if err != nil {
+			return err
+		}
+
+		_, _, err = l.checkRenewal(repos, loan)
 		return err
+	})
+}
+
+// checkMembership fails unless the member's membership is active: a
+// suspended or lapsed member may neither borrow nor renew.
+func (l *LoanService) checkMembership(user *userModel.User) error {
+	switch user.MembershipStatus(l.clock.Now()) {
+	case userModel.StatusSuspended:
+		return models.ErrMembershipSuspended
+	case userModel.StatusExpired:
+		return models.ErrMembershipExpired
 	}
+	return nil
+}
 
-	if loan.Status == "returned" {
-		return errors.New("book already returned")
+// checkRenewal tells whether the loan may be renewed now, returning its
+// book and policy when it may.
+func (l *LoanService) checkRenewal(repos *unitofwork.Repositories, loan *models.Loan) (*bookModel.Book, *policyModel.LoanPolicy, error) {
+	if !loan.Open() {
+		return nil, nil, models.ErrLoanNotOpen
 	}
 
-	now := time.Now()
-	loan.Status = "returned"
-	loan.UpdatedAt = now
-	loan.ReturnedAt = now
+	book, err := repos.Books.GetBook(loan.BookID)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Calculate fine if overdue (R$ 2.00 per day)
-	if now.After(loan.DueDate) {
-		daysLate := int(now.Sub(loan.DueDate).Hours() / 24)
-		if daysLate > 0 {
-			loan.Fine = float64(daysLate) * 2.00 // R$ 2.00 por dia de atraso
+	policy, err := repos.Policies.GetPolicyByCode(book.BookType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !policy.Renewable {
+		return nil, nil, models.ErrNotRenewable
+	}
+
+	if loan.Renewals >= policy.MaxRenewals {
+		return nil, nil, models.ErrMaxRenewals
+	}
+
+	user, err := repos.Users.GetUser(loan.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := l.checkMembership(user); err != nil {
+		return nil, nil, err
+	}
+
+	if err := l.checkBalance(repos, loan.UserID); err != nil {
+		return nil, nil, err
+	}
+
+	holds, err := repos.Holds.GetBookHolds(loan.BookID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, hold := range holds {
+		if hold.UserID != loan.UserID {
+			return nil, nil, models.ErrHeldForAnother
 		}
 	}
 
-	if err := l.loanRepository.UpdateLoan(loan); err != nil {
-		return err
+	now := l.clock.Now()
+	if now.After(inZoneOf(loan.DueDate, now).AddDate(0, 0, policy.RenewalGraceDays)) {
+		return nil, nil, models.ErrRenewalGraceExpired
 	}
 
-	book, err := l.bookService.GetBook(loan.BookID)
+	return book, policy, nil
+}
+
+func (l *LoanService) MarkOverdueLoans() (int, error) {
+	marked := 0
+
+	err := l.unitOfWork.Do(func(repos *unitofwork.Repositories) error {
+		now := l.clock.Now()
+		loans, err := repos.Loans.GetActiveLoans()
+		if err != nil {
+			return err
+		}
+
+		calendar, err := calendarModel.LoadCalendar(repos.Calendar)
+		if err != nil {
+			return err
+		}
+
+		policies := make(map[int64]*policyModel.LoanPolicy)
+		for _, loan := range loans {
+			if !now.After(loan.DueDate) {
+				continue
+			}
+
+			policy, ok := policies[loan.BookID]
+			if !ok {
+				policy, err = policyForBook(repos, loan.BookID)
+				if err != nil {
+					return err
+				}
+				policies[loan.BookID] = policy
+			}
+
+			if loan.Status == "active" {
+				loan.Status = "overdue"
+				marked++
+			}
+			loan.Fine = fineFor(loan, policy, calendar, now)
+			loan.UpdatedAt = now
+
+			if err := repos.Loans.UpdateLoan(loan); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	book.Quantity++
-	return l.bookService.UpdateBook(book.ID, book)
+	return marked, nil
 }
 
 // CalculateFine calculates the fine for a given loan
-func (l *LoanService) CalculateFine(loan *models.Loan) float64 {
+func (l *LoanService) CalculateFine(loan *models.Loan) (money.Money, error) {
 	if loan.Status == "returned" {
-		return loan.Fine
+		return loan.Fine, nil
+	}
+
+	var fine money.Money
+	err := l.unitOfWork.Read(func(repos *unitofwork.Repositories) error {
+		policy, err := policyForBook(repos, loan.BookID)
+		if err != nil {
+			return err
+		}
+
+		calendar, err := calendarModel.LoadCalendar(repos.Calendar)
+		if err != nil {
+			return err
+		}
+
+		fine = fineFor(loan, policy, calendar, l.clock.Now())
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	now := time.Now()
-	if now.After(loan.DueDate) {
-		daysLate := int(now.Sub(loan.DueDate).Hours() / 24)
-		if daysLate > 0 {
-			return float64(daysLate) * 2.00
+	return fine, nil
+}
+
+// LoanLimit is the member's own limit when set, otherwise their category's
+// and, failing both, the library's.
+func (l *LoanService) LoanLimit(user *userModel.User) int {
+	if user.MaxLoans > 0 {
+		return user.MaxLoans
+	}
+	if limit := user.MemberCategory().LoanLimit; limit > 0 {
+		return limit
+	}
+	return l.loanLimit
+}
+
+func (l *LoanService) BorrowingBlock(userId int64) (*models.BalanceBlockError, error) {
+	var block *models.BalanceBlockError
+
+	err := l.unitOfWork.Read(func(repos *unitofwork.Repositories) error {
+		err := l.checkBalance(repos, userId)
+		if errors.As(err, &block) {
+			return nil
 		}
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return 0
+
+	return block, nil
+}
+
+// checkBalance fails with a BalanceBlockError when the member owes more than
+// the fine threshold.
+func (l *LoanService) checkBalance(repos *unitofwork.Repositories, userId int64) error {
+	balance, err := repos.Transactions.GetUserBalance(userId)
+	if err != nil {
+		return err
+	}
+	if balance > l.fineThreshold {
+		return &models.BalanceBlockError{Balance: balance, Threshold: l.fineThreshold}
+	}
+	return nil
+}
+
+// loanDays is the book's own loan duration when set, otherwise the policy's.
+func loanDays(book *bookModel.Book, policy *policyModel.LoanPolicy) int {
+	if book.LoanDuration > 0 {
+		return book.LoanDuration
+	}
+	return policy.LoanDays
+}
+
+// userHold is the member's active hold on the book, or nil.
+func userHold(repos *unitofwork.Repositories, bookId, userId int64) (*holdModel.Hold, error) {
+	holds, err := repos.Holds.GetBookHolds(bookId)
+	if err != nil {
+		return nil, err
+	}
+	for _, hold := range holds {
+		if hold.UserID == userId {
+			return hold, nil
+		}
+	}
+	return nil, nil
+}
+
+func policyForBook(repos *unitofwork.Repositories, bookId int64) (*policyModel.LoanPolicy, error) {
+	book, err := repos.Books.GetBook(bookId)
+	if err != nil {
+		return nil, err
+	}
+	return repos.Policies.GetPolicyByCode(book.BookType)
+}
+
+// fineFor is the fine owed for the loan at now under its material type's
+// fine policy, not counting days the library is closed. Both the running
+// estimate and the charge on return use it.
+func fineFor(loan *models.Loan, policy *policyModel.LoanPolicy, calendar *calendarModel.Calendar, now time.Time) money.Money {
+	return policy.FinePolicy(calendar).Fine(inZoneOf(loan.DueDate, now), now)
+}
+
+// inZoneOf moves a stored time into the time zone of now, the library's,
+// before counting calendar days from it. SQLite hands times back in UTC,
+// where days start at another hour and don't follow daylight saving time.
+func inZoneOf(t, now time.Time) time.Time {
+	return t.In(now.Location())
 }
 
 func (l *LoanService) GetLoan(id int64) (*models.Loan, error) {
@@ -146,3 +572,15 @@ func (l *LoanService) GetUserLoans(userId int64) ([]*models.Loan, error) {
 func (l *LoanService) GetAllLoans() ([]*models.Loan, error) {
 	return l.loanRepository.GetAllLoans()
 }
+
+func (l *LoanService) ListLoans(filter models.LoanFilter, page paging.Request) (*paging.Page[*models.Loan], error) {
+	switch filter.Status {
+	case "", "active", "returned", "overdue":
+	default:
+		return nil, models.ErrInvalidLoanStatus
+	}
+	if err := page.CheckSort(models.LoanSortFields); err != nil {
+		return nil, err
+	}
+	return l.loanRepository.ListLoans(filter, page.Normalize())
+}
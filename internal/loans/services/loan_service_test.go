@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	bookModel "librarymvc/internal/books/models"
+	"librarymvc/internal/clock"
+	copyModel "librarymvc/internal/copies/models"
+	holdModel "librarymvc/internal/holds/models"
+	holdService "librarymvc/internal/holds/services"
+	"librarymvc/internal/loans/models"
+	"librarymvc/internal/money"
+	"librarymvc/internal/testenv"
+	userModel "librarymvc/internal/users/models"
+)
+
+// fixture is a library with one loanable book, one copy of it and one
+// member, whose loan service runs on a fake clock.
+type fixture struct {
+	storage *testenv.Storage
+	clock   *clock.Fake
+	loans   *LoanService
+	book    *bookModel.Book
+	user    *userModel.User
+}
+
+func newFixture(t *testing.T, storage *testenv.Storage, now time.Time) *fixture {
+	t.Helper()
+	repos := storage.Repos
+	storage.Clock.Set(now)
+
+	book := &bookModel.Book{Title: "Dom Casmurro", Author: "Machado de Assis", BookType: "emprestavel", CreatedAt: now, UpdatedAt: now}
+	if err := repos.Books.CreateBook(book); err != nil {
+		t.Fatal(err)
+	}
+	copy := &copyModel.Copy{BookID: book.ID, Barcode: "B0001", Condition: "good", Status: "available", CreatedAt: now, UpdatedAt: now}
+	if err := repos.Copies.CreateCopy(copy); err != nil {
+		t.Fatal(err)
+	}
+	user := &userModel.User{
+		Name:      "Leitora de Teste",
+		Email:     "leitora@lib.org",
+		Role:      userModel.RolePatron,
+		Category:  userModel.DefaultCategory,
+		Status:    userModel.StatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := repos.Users.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	return &fixture{
+		storage: storage,
+		clock:   storage.Clock,
+		loans:   NewLoanService(repos.Loans, storage.UnitOfWork, 3, money.FromCents(1000), storage.Clock).(*LoanService),
+		book:    book,
+		user:    user,
+	}
+}
+
+func (f *fixture) borrow(t *testing.T) *models.Loan {
+	t.Helper()
+	loan, err := f.loans.CreateLoan(f.book.ID, f.user.ID)
+	if err != nil {
+		t.Fatalf("CreateLoan() = %v", err)
+	}
+	return loan
+}
+
+func (f *fixture) reload(t *testing.T, loan *models.Loan) *models.Loan {
+	t.Helper()
+	stored, err := f.loans.GetLoan(loan.ID)
+	if err != nil {
+		t.Fatalf("GetLoan() = %v", err)
+	}
+	return stored
+}
+
+func TestRenewingAnOverdueLoanClearsItsFine(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		// Borrowed on a Wednesday, due 12 days later on Monday the 19th
+		f := newFixture(t, storage, time.Date(2026, time.January, 7, 10, 0, 0, 0, time.UTC))
+		loan := f.borrow(t)
+
+		// A day late, inside the 2-day renewal grace
+		f.clock.Set(time.Date(2026, time.January, 20, 12, 0, 0, 0, time.UTC))
+		if _, err := f.loans.MarkOverdueLoans(); err != nil {
+			t.Fatal(err)
+		}
+		overdue := f.reload(t, loan)
+		if overdue.Status != "overdue" || overdue.Fine != money.FromCents(200) {
+			t.Fatalf("before renewal: status %q, fine %v; want overdue, 2.00", overdue.Status, overdue.Fine)
+		}
+
+		renewed, err := f.loans.RenewLoan(loan.ID)
+		if err != nil {
+			t.Fatalf("RenewLoan() = %v", err)
+		}
+		for _, got := range []*models.Loan{renewed, f.reload(t, loan)} {
+			if got.Status != "active" || got.Fine != 0 {
+				t.Errorf("after renewal: status %q, fine %v; want active, 0", got.Status, got.Fine)
+			}
+		}
+	})
+}
+
+func TestCalculateFineReportsLookupErrors(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		f := newFixture(t, storage, time.Date(2026, time.January, 7, 10, 0, 0, 0, time.UTC))
+		loan := f.borrow(t)
+
+		// A loan whose book is gone has no policy to price it by
+		orphan := *loan
+		orphan.BookID = f.book.ID + 1000
+		if fine, err := f.loans.CalculateFine(&orphan); err == nil {
+			t.Errorf("CalculateFine() = %v, nil; want an error", fine)
+		}
+	})
+}
+
+func TestMembershipBlocksCheckoutAndRenewal(t *testing.T) {
+	now := time.Date(2026, time.January, 7, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		status    string
+		expiresAt time.Time
+		err       error
+	}{
+		{"active", userModel.StatusActive, now.AddDate(0, 6, 0), nil},
+		{"suspended", userModel.StatusSuspended, now.AddDate(0, 6, 0), models.ErrMembershipSuspended},
+		{"expired", userModel.StatusExpired, now.AddDate(0, -1, 0), models.ErrMembershipExpired},
+		// Lapsed yesterday, not yet recorded as expired
+		{"lapsed", userModel.StatusActive, now.AddDate(0, 0, -1), models.ErrMembershipExpired},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+				f := newFixture(t, storage, now)
+				setMembership := func(status string, expiresAt time.Time) {
+					t.Helper()
+					f.user.Status = status
+					f.user.ExpiresAt = expiresAt
+					if err := storage.Repos.Users.UpdateUser(f.user.ID, f.user); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				setMembership(test.status, test.expiresAt)
+				loan, err := f.loans.CreateLoan(f.book.ID, f.user.ID)
+				if !errors.Is(err, test.err) {
+					t.Errorf("CreateLoan() = %v, want %v", err, test.err)
+				}
+
+				// Otherwise a loan taken while the membership was in order
+				if loan == nil {
+					setMembership(userModel.StatusActive, time.Time{})
+					loan = f.borrow(t)
+					setMembership(test.status, test.expiresAt)
+				}
+
+				if _, err := f.loans.RenewLoan(loan.ID); !errors.Is(err, test.err) {
+					t.Errorf("RenewLoan() = %v, want %v", err, test.err)
+				}
+				if err := f.loans.RenewalBlock(loan.ID); !errors.Is(err, test.err) {
+					t.Errorf("RenewalBlock() = %v, want %v", err, test.err)
+				}
+				if err := f.loans.ReturnBook(loan.ID); err != nil {
+					t.Errorf("ReturnBook() = %v, want returns to go on", err)
+				}
+			})
+		})
+	}
+}
+
+func TestReturnOffersTheCopyToTheFirstHold(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		f := newFixture(t, storage, time.Date(2026, time.January, 7, 10, 0, 0, 0, time.UTC))
+		loan := f.borrow(t)
+
+		// Two members wait for the only copy
+		holds := holdService.NewHoldService(storage.Repos.Holds, storage.UnitOfWork, storage.Clock)
+		var queue []*holdModel.Hold
+		for _, email := range []string{"primeira@lib.org", "segunda@lib.org"} {
+			now := f.clock.Now()
+			user := &userModel.User{
+				Name:      "Leitora da Fila",
+				Email:     email,
+				Role:      userModel.RolePatron,
+				Category:  userModel.DefaultCategory,
+				Status:    userModel.StatusActive,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := storage.Repos.Users.CreateUser(user); err != nil {
+				t.Fatal(err)
+			}
+			hold, err := holds.PlaceHold(f.book.ID, user.ID)
+			if err != nil {
+				t.Fatalf("PlaceHold() = %v", err)
+			}
+			queue = append(queue, hold)
+			f.clock.Advance(time.Minute)
+		}
+
+		if err := f.loans.ReturnBook(loan.ID); err != nil {
+			t.Fatalf("ReturnBook() = %v", err)
+		}
+		copy, err := storage.Repos.Copies.GetCopy(loan.CopyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if copy.Status != "on_hold" {
+			t.Errorf("returned copy is %q, want on_hold", copy.Status)
+		}
+		for i, want := range []string{"ready", "waiting"} {
+			hold, err := holds.GetHold(queue[i].ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hold.Status != want {
+				t.Errorf("hold %d is %q, want %q", i+1, hold.Status, want)
+			}
+		}
+
+		// The first in line collects it
+		collected, err := f.loans.CreateLoan(f.book.ID, queue[0].UserID)
+		if err != nil {
+			t.Fatalf("CreateLoan() = %v", err)
+		}
+		hold, err := holds.GetHold(queue[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if collected.CopyID != loan.CopyID || hold.Status != "fulfilled" {
+			t.Errorf("loan of copy %d, hold %q; want copy %d and the hold fulfilled", collected.CopyID, hold.Status, loan.CopyID)
+		}
+	})
+}
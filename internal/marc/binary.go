@@ -0,0 +1,157 @@
+package marc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+
+	leaderLength         = 24
+	directoryEntryLength = 12
+)
+
+// ReadBinary decodes ISO 2709 records. Data is taken to be UTF-8 whatever
+// leader position 09 says; MARC-8 records come through unchanged only when
+// they are plain ASCII.
+func ReadBinary(data []byte) ([]*Record, error) {
+	var records []*Record
+	for n := 1; ; n++ {
+		// Some tools put a line break between records
+		data = bytes.TrimLeft(data, "\r\n")
+		if len(data) == 0 {
+			return records, nil
+		}
+
+		if len(data) < leaderLength {
+			return nil, fmt.Errorf("record %d: truncated leader", n)
+		}
+		length, ok := number(data[:5])
+		if !ok || length < leaderLength+1 || length > len(data) {
+			return nil, fmt.Errorf("record %d: invalid record length %q", n, data[:5])
+		}
+
+		record, err := decodeRecord(data[:length])
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", n, err)
+		}
+		records = append(records, record)
+		data = data[length:]
+	}
+}
+
+func decodeRecord(data []byte) (*Record, error) {
+	base, ok := number(data[12:17])
+	if !ok || base <= leaderLength || base > len(data) {
+		return nil, fmt.Errorf("invalid base address %q", data[12:17])
+	}
+
+	record := &Record{Leader: string(data[:leaderLength])}
+	directory := bytes.TrimSuffix(data[leaderLength:base], []byte{fieldTerminator})
+	if len(directory)%directoryEntryLength != 0 {
+		return nil, fmt.Errorf("directory length %d is not a multiple of %d", len(directory), directoryEntryLength)
+	}
+
+	for entry := directory; len(entry) > 0; entry = entry[directoryEntryLength:] {
+		tag := string(entry[:3])
+		length, ok1 := number(entry[3:7])
+		start, ok2 := number(entry[7:12])
+		if !ok1 || !ok2 || length == 0 || base+start+length > len(data) {
+			return nil, fmt.Errorf("field %s: invalid directory entry %q", tag, entry[:directoryEntryLength])
+		}
+		value := bytes.TrimSuffix(data[base+start:base+start+length], []byte{fieldTerminator})
+
+		if IsControl(tag) {
+			record.Fields = append(record.Fields, &Field{Tag: tag, Value: string(value)})
+			continue
+		}
+
+		if len(value) < 2 {
+			return nil, fmt.Errorf("field %s: missing indicators", tag)
+		}
+		field := &Field{Tag: tag, Ind1: value[0], Ind2: value[1]}
+		for _, subfield := range bytes.Split(value[2:], []byte{subfieldDelimiter}) {
+			if len(subfield) == 0 {
+				continue
+			}
+			field.Subfields = append(field.Subfields, Subfield{Code: subfield[0], Value: string(subfield[1:])})
+		}
+		record.Fields = append(record.Fields, field)
+	}
+
+	return record, nil
+}
+
+// number reads a fixed-width numeric field of the leader or directory,
+// which must be all digits: no sign, no blanks.
+func number(field []byte) (int, bool) {
+	if len(field) == 0 {
+		return 0, false
+	}
+	n := 0
+	for _, b := range field {
+		if b < '0' || b > '9' {
+			return 0, false
+		}
+		n = n*10 + int(b-'0')
+	}
+	return n, true
+}
+
+// WriteBinary encodes the records in ISO 2709, filling in the record length
+// and base address of each leader and marking the data as UTF-8.
+func WriteBinary(w io.Writer, records []*Record) error {
+	for _, record := range records {
+		if _, err := w.Write(encodeRecord(record)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeRecord(record *Record) []byte {
+	var directory, fields bytes.Buffer
+	for _, field := range record.Fields {
+		start := fields.Len()
+		if IsControl(field.Tag) {
+			fields.WriteString(field.Value)
+		} else {
+			fields.WriteByte(indicator(field.Ind1))
+			fields.WriteByte(indicator(field.Ind2))
+			for _, subfield := range field.Subfields {
+				fields.WriteByte(subfieldDelimiter)
+				fields.WriteByte(subfield.Code)
+				fields.WriteString(subfield.Value)
+			}
+		}
+		fields.WriteByte(fieldTerminator)
+		fmt.Fprintf(&directory, "%3.3s%04d%05d", field.Tag, fields.Len()-start, start)
+	}
+	directory.WriteByte(fieldTerminator)
+	fields.WriteByte(recordTerminator)
+
+	base := leaderLength + directory.Len()
+	leader := []byte(fmt.Sprintf("%-24.24s", record.Leader))
+	copy(leader[0:5], fmt.Sprintf("%05d", base+fields.Len()))
+	leader[9] = 'a'
+	copy(leader[10:12], "22")
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+	copy(leader[20:24], "4500")
+
+	encoded := make([]byte, 0, base+fields.Len())
+	encoded = append(encoded, leader...)
+	encoded = append(encoded, directory.Bytes()...)
+	return append(encoded, fields.Bytes()...)
+}
+
+// indicator writes an unset indicator as a blank.
+func indicator(b byte) byte {
+	if b == 0 {
+		return ' '
+	}
+	return b
+}
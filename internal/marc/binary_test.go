@@ -0,0 +1,104 @@
+package marc
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func sampleRecords() []*Record {
+	first := &Record{Leader: "00000nam a2200000 a 4500"}
+	first.AddControl("001", "BR-0001")
+	first.AddData("020", ' ', ' ', Subfield{Code: 'a', Value: "9788535910667"})
+	first.AddData("100", '1', ' ', Subfield{Code: 'a', Value: "Assis, Machado de"})
+	first.AddData("245", '1', '0',
+		Subfield{Code: 'a', Value: "Memórias póstumas de Brás Cubas /"},
+		Subfield{Code: 'c', Value: "Machado de Assis."},
+	)
+	first.AddData("650", ' ', '4', Subfield{Code: 'a', Value: "Literatura brasileira"})
+
+	second := &Record{Leader: "00000nam a2200000 a 4500"}
+	second.AddData("245", '0', '0', Subfield{Code: 'a', Value: "Dom Casmurro"})
+
+	return []*Record{first, second}
+}
+
+// encoded is the ISO 2709 form of the first sample record.
+func encoded(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteBinary(&buf, sampleRecords()[:1]); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withBytes returns a copy of data with s written at position at.
+func withBytes(data []byte, at int, s string) []byte {
+	changed := bytes.Clone(data)
+	copy(changed[at:], s)
+	return changed
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteBinary(&buf, sampleRecords()); err != nil {
+		t.Fatalf("WriteBinary() = %v", err)
+	}
+
+	// Some tools put a line break between records
+	data := bytes.Replace(buf.Bytes(), []byte{recordTerminator}, []byte{recordTerminator, '\r', '\n'}, 1)
+
+	records, err := ReadBinary(data)
+	if err != nil {
+		t.Fatalf("ReadBinary() = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("read %d records, want 2", len(records))
+	}
+	for i, record := range records {
+		want := sampleRecords()[i]
+		if !reflect.DeepEqual(record.Fields, want.Fields) {
+			t.Errorf("record %d fields = %v, want %v", i+1, record.Fields, want.Fields)
+		}
+		if record.Leader[9] != 'a' || record.Leader[20:] != "4500" {
+			t.Errorf("record %d leader = %q, want UTF-8 and 4500", i+1, record.Leader)
+		}
+	}
+	if got := records[0].Get("245").Subfield('a'); got != "Memórias póstumas de Brás Cubas /" {
+		t.Errorf("title = %q", got)
+	}
+}
+
+func TestReadBinaryRejectsMalformedRecords(t *testing.T) {
+	data := encoded(t)
+	// The first directory entry, for 001, is at 24: tag, length at 27, start at 31
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated leader", data[:20]},
+		{"record length with a sign", withBytes(data, 0, "+0100")},
+		{"record length past the end", withBytes(data, 0, "99999")},
+		{"record length too short", withBytes(data, 0, "00010")},
+		{"base address with a sign", withBytes(data, 12, "-0001")},
+		{"base address inside the leader", withBytes(data, 12, "00010")},
+		{"base address past the end", withBytes(data, 12, "99999")},
+		{"negative field start", withBytes(data, 31, "-0005")},
+		{"field start with a plus sign", withBytes(data, 31, "+0000")},
+		{"field start with blanks", withBytes(data, 31, "   00")},
+		{"negative field length", withBytes(data, 27, "-008")},
+		{"empty field", withBytes(data, 27, "0000")},
+		{"field past the end", withBytes(data, 31, "99999")},
+		{"directory not a whole number of entries", append(withBytes(data, 12, "00030"), 0)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := ReadBinary(test.data)
+			if err == nil {
+				t.Errorf("ReadBinary() = %d records, want an error", len(records))
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Package marc reads and writes MARC 21 bibliographic records, both in the
+// ISO 2709 exchange format (.mrc) and as MARCXML.
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// Format names the serializations a catalog can be exchanged in.
+type Format string
+
+const (
+	Binary Format = "marc"    // ISO 2709
+	XML    Format = "marcxml" // MARC 21 slim
+)
+
+var ErrEmpty = errors.New("no MARC records found")
+
+// Record is a MARC record: the 24-character leader followed by its fields
+// in order.
+type Record struct {
+	Leader string
+	Fields []*Field
+}
+
+// Field is a control field (tags 001 to 009), which has only a Value, or a
+// data field with two indicators and subfields.
+type Field struct {
+	Tag       string
+	Value     string
+	Ind1      byte
+	Ind2      byte
+	Subfields []Subfield
+}
+
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// IsControl reports whether tag is a control field tag.
+func IsControl(tag string) bool {
+	return strings.HasPrefix(tag, "00")
+}
+
+// Get returns the first field with the tag, or nil.
+func (r *Record) Get(tag string) *Field {
+	for _, field := range r.Fields {
+		if field.Tag == tag {
+			return field
+		}
+	}
+	return nil
+}
+
+// GetAll returns every field with the tag, in order.
+func (r *Record) GetAll(tag string) []*Field {
+	var fields []*Field
+	for _, field := range r.Fields {
+		if field.Tag == tag {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ControlValue is the value of the control field with the tag, or "".
+func (r *Record) ControlValue(tag string) string {
+	if field := r.Get(tag); field != nil {
+		return field.Value
+	}
+	return ""
+}
+
+// AddControl appends a control field.
+func (r *Record) AddControl(tag, value string) {
+	r.Fields = append(r.Fields, &Field{Tag: tag, Value: value})
+}
+
+// AddData appends a data field.
+func (r *Record) AddData(tag string, ind1, ind2 byte, subfields ...Subfield) {
+	r.Fields = append(r.Fields, &Field{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: subfields})
+}
+
+// Subfield returns the first subfield with the code, or "".
+func (f *Field) Subfield(code byte) string {
+	for _, subfield := range f.Subfields {
+		if subfield.Code == code {
+			return subfield.Value
+		}
+	}
+	return ""
+}
+
+// Detect tells the format of data: MARCXML starts with markup, anything
+// else is taken to be ISO 2709.
+func Detect(data []byte) Format {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return XML
+	}
+	return Binary
+}
+
+// Read decodes every record in data, in whichever format it is.
+func Read(data []byte) ([]*Record, error) {
+	var (
+		records []*Record
+		err     error
+	)
+	switch Detect(data) {
+	case XML:
+		records, err = ReadXML(data)
+	default:
+		records, err = ReadBinary(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrEmpty
+	}
+	return records, nil
+}
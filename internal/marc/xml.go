@@ -0,0 +1,126 @@
+package marc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// Namespace is the MARC 21 slim schema MARCXML documents use.
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlCollection struct {
+	XMLName xml.Name     `xml:"collection"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Records []*xmlRecord `xml:"record"`
+}
+
+type xmlRecord struct {
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ReadXML decodes the records of a MARCXML collection, or a document that
+// is a single record.
+func ReadXML(data []byte) ([]*Record, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var records []*Record
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		var element xmlRecord
+		if err := decoder.DecodeElement(&element, &start); err != nil {
+			return nil, err
+		}
+		records = append(records, element.record())
+	}
+}
+
+func (x *xmlRecord) record() *Record {
+	record := &Record{Leader: x.Leader}
+	for _, field := range x.ControlFields {
+		record.AddControl(field.Tag, field.Value)
+	}
+	for _, element := range x.DataFields {
+		field := &Field{Tag: element.Tag, Ind1: firstByte(element.Ind1), Ind2: firstByte(element.Ind2)}
+		for _, subfield := range element.Subfields {
+			field.Subfields = append(field.Subfields, Subfield{Code: firstByte(subfield.Code), Value: subfield.Value})
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record
+}
+
+func firstByte(s string) byte {
+	if s == "" {
+		return ' '
+	}
+	return s[0]
+}
+
+// WriteXML encodes the records as a MARCXML collection.
+func WriteXML(w io.Writer, records []*Record) error {
+	collection := xmlCollection{Xmlns: Namespace}
+	for _, record := range records {
+		element := &xmlRecord{Leader: record.Leader}
+		for _, field := range record.Fields {
+			if IsControl(field.Tag) {
+				element.ControlFields = append(element.ControlFields, xmlControlField{Tag: field.Tag, Value: field.Value})
+				continue
+			}
+
+			data := xmlDataField{
+				Tag:  field.Tag,
+				Ind1: string(indicator(field.Ind1)),
+				Ind2: string(indicator(field.Ind2)),
+			}
+			for _, subfield := range field.Subfields {
+				data.Subfields = append(data.Subfields, xmlSubfield{Code: string(subfield.Code), Value: subfield.Value})
+			}
+			element.DataFields = append(element.DataFields, data)
+		}
+		collection.Records = append(collection.Records, element)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(collection); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
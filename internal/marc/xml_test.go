@@ -0,0 +1,118 @@
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReadXML(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		records int
+	}{
+		{"a collection", `<?xml version="1.0" encoding="UTF-8"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">
+  <record>
+    <leader>00000nam a2200000 a 4500</leader>
+    <controlfield tag="001">BR-0001</controlfield>
+    <datafield tag="245" ind1="1" ind2="0">
+      <subfield code="a">Memórias póstumas de Brás Cubas /</subfield>
+      <subfield code="c">Machado de Assis.</subfield>
+    </datafield>
+  </record>
+  <record>
+    <leader>00000nam a2200000 a 4500</leader>
+    <datafield tag="245" ind1="0" ind2="0"><subfield code="a">Dom Casmurro</subfield></datafield>
+  </record>
+</collection>`, 2},
+		{"a single record with a prefix", `<marc:record xmlns:marc="http://www.loc.gov/MARC21/slim">
+  <marc:leader>00000nam a2200000 a 4500</marc:leader>
+  <marc:controlfield tag="001">BR-0001</marc:controlfield>
+  <marc:datafield tag="245" ind1="1" ind2="0">
+    <marc:subfield code="a">Memórias póstumas de Brás Cubas /</marc:subfield>
+    <marc:subfield code="c">Machado de Assis.</marc:subfield>
+  </marc:datafield>
+</marc:record>`, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := ReadXML([]byte(test.data))
+			if err != nil {
+				t.Fatalf("ReadXML() = %v", err)
+			}
+			if len(records) != test.records {
+				t.Fatalf("read %d records, want %d", len(records), test.records)
+			}
+
+			record := records[0]
+			if record.Leader != "00000nam a2200000 a 4500" || record.ControlValue("001") != "BR-0001" {
+				t.Errorf("leader %q, 001 %q", record.Leader, record.ControlValue("001"))
+			}
+			title := record.Get("245")
+			if title == nil || title.Ind1 != '1' || title.Ind2 != '0' ||
+				title.Subfield('a') != "Memórias póstumas de Brás Cubas /" || title.Subfield('c') != "Machado de Assis." {
+				t.Errorf("245 = %+v", title)
+			}
+		})
+	}
+}
+
+func TestReadXMLRejectsBrokenMarkup(t *testing.T) {
+	data := `<collection><record><leader>00000nam a2200000 a 4500</leader><datafield tag="245">`
+	if records, err := ReadXML([]byte(data)); err == nil {
+		t.Errorf("ReadXML() = %d records, want an error", len(records))
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXML(&buf, sampleRecords()); err != nil {
+		t.Fatalf("WriteXML() = %v", err)
+	}
+
+	records, err := Read(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("read %d records, want 2", len(records))
+	}
+	for i, record := range records {
+		want := sampleRecords()[i]
+		if record.Leader != want.Leader || !reflect.DeepEqual(record.Fields, want.Fields) {
+			t.Errorf("record %d = %+v, want %+v", i+1, record, want)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	var binary, markup bytes.Buffer
+	WriteBinary(&binary, sampleRecords())
+	WriteXML(&markup, sampleRecords())
+
+	tests := []struct {
+		name   string
+		data   []byte
+		format Format
+	}{
+		{"ISO 2709", binary.Bytes(), Binary},
+		{"MARCXML", markup.Bytes(), XML},
+		{"MARCXML with a byte order mark", append([]byte("\xef\xbb\xbf"), markup.Bytes()...), XML},
+	}
+	for _, test := range tests {
+		if format := Detect(test.data); format != test.format {
+			t.Errorf("%s: Detect() = %q, want %q", test.name, format, test.format)
+		}
+		if records, err := Read(test.data); err != nil || len(records) != 2 {
+			t.Errorf("%s: Read() = %d records, %v; want 2", test.name, len(records), err)
+		}
+	}
+
+	if _, err := Read([]byte(`<collection xmlns="http://www.loc.gov/MARC21/slim"></collection>`)); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Read(empty collection) = %v, want %v", err, ErrEmpty)
+	}
+}
@@ -0,0 +1,114 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in integer cents, so sums and multiplications never
+// pick up floating-point rounding errors. In JSON it is written as a
+// decimal number with two places (e.g. 2.50).
+type Money int64
+
+// FromCents returns the amount of the given cents.
+func FromCents(cents int64) Money {
+	return Money(cents)
+}
+
+// Parse reads a decimal amount such as "12", "12.5", "12,50" or
+// "R$ 1.234,56". When both separators appear the last one is the decimal
+// separator; a separator that appears more than once groups thousands.
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "R$"))
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	dots, commas := strings.Count(s, "."), strings.Count(s, ",")
+	switch {
+	case dots > 0 && commas > 0:
+		if strings.LastIndex(s, ",") > strings.LastIndex(s, ".") {
+			s = strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), ",", ".")
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case commas == 1:
+		s = strings.ReplaceAll(s, ",", ".")
+	case commas > 1:
+		s = strings.ReplaceAll(s, ",", "")
+	case dots > 1:
+		s = strings.ReplaceAll(s, ".", "")
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, errors.New("invalid amount")
+	}
+	if len(frac) > 2 {
+		return 0, errors.New("amount cannot have more than two decimal places")
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	frac = (frac + "00")[:2]
+
+	units, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil {
+		return 0, errors.New("invalid amount")
+	}
+	if units > (math.MaxInt64-99)/100 {
+		return 0, errors.New("amount is too large")
+	}
+	cents, err := strconv.ParseUint(frac, 10, 63)
+	if err != nil {
+		return 0, errors.New("invalid amount")
+	}
+
+	m := Money(units*100 + cents)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// Times multiplies the amount by n, e.g. a daily rate by the days late.
+func (m Money) Times(n int) Money {
+	return m * Money(n)
+}
+
+// String formats the amount with two decimal places, e.g. "12.50".
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a number (2.5) or a string ("2,50").
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,73 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Money
+	}{
+		{"12", 1200},
+		{"12.5", 1250},
+		{"12,50", 1250},
+		{".5", 50},
+		{"R$ 1.234,56", 123456},
+		{"1,234.56", 123456},
+		{"1.234.567", 123456700},
+		{"1,234,567", 123456700},
+		{"-2,50", -250},
+		{"0", 0},
+		{"92233720368547757.99", 9223372036854775799},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.input)
+		if err != nil || got != test.want {
+			t.Errorf("Parse(%q) = %d, %v; want %d", test.input, got, err, test.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"only a separator", "."},
+		{"letters", "doze"},
+		{"three decimal places", "1.505"},
+		{"a sign in the decimals", "1.-5"},
+		{"past the largest amount", "92233720368547758"},
+		{"far past the largest amount", "9223372036854775807"},
+		{"past the range of the digits", "99999999999999999999"},
+	}
+
+	for _, test := range tests {
+		if got, err := Parse(test.input); err == nil {
+			t.Errorf("%s: Parse(%q) = %d, want an error", test.name, test.input, got)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var fine struct {
+		Amount Money `json:"amount"`
+	}
+	for _, data := range []string{`{"amount": 2.5}`, `{"amount": "2,50"}`} {
+		if err := json.Unmarshal([]byte(data), &fine); err != nil || fine.Amount != 250 {
+			t.Errorf("Unmarshal(%s) = %d, %v; want 250", data, fine.Amount, err)
+		}
+	}
+
+	out, err := json.Marshal(fine)
+	if err != nil || string(out) != `{"amount":2.50}` {
+		t.Errorf("Marshal() = %s, %v", out, err)
+	}
+	if got := FromCents(-1205).String(); got != "-12.05" {
+		t.Errorf("String() = %q, want -12.05", got)
+	}
+}
@@ -0,0 +1,144 @@
+package paging
+
+import (
+	"librarymvc/internal/apperror"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidPage = apperror.NewBadRequest("invalid_page", "page and limit must be positive integers")
+	ErrInvalidSort = apperror.NewValidation("invalid_sort", "cannot sort by this field")
+)
+
+// Request asks for one page of a list. Sort names the field to sort by,
+// prefixed with "-" for descending order; items with equal values stay in
+// ID order. An empty Sort sorts by ID.
+type Request struct {
+	Page  int
+	Limit int
+	Sort  string
+}
+
+// FromQuery reads page, limit and sort from a query string.
+func FromQuery(query url.Values) (Request, error) {
+	var req Request
+	var err error
+
+	if page := query.Get("page"); page != "" {
+		if req.Page, err = strconv.Atoi(page); err != nil || req.Page < 1 {
+			return Request{}, ErrInvalidPage
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if req.Limit, err = strconv.Atoi(limit); err != nil || req.Limit < 1 {
+			return Request{}, ErrInvalidPage
+		}
+	}
+	req.Sort = strings.TrimSpace(query.Get("sort"))
+
+	return req.Normalize(), nil
+}
+
+// Normalize fills in the defaults and caps the limit.
+func (r Request) Normalize() Request {
+	if r.Page < 1 {
+		r.Page = 1
+	}
+	if r.Limit < 1 {
+		r.Limit = DefaultLimit
+	}
+	if r.Limit > MaxLimit {
+		r.Limit = MaxLimit
+	}
+	return r
+}
+
+func (r Request) Offset() int {
+	return (r.Page - 1) * r.Limit
+}
+
+// SortField splits Sort into the field name and the direction.
+func (r Request) SortField() (field string, desc bool) {
+	return strings.TrimPrefix(r.Sort, "-"), strings.HasPrefix(r.Sort, "-")
+}
+
+// CheckSort fails with ErrInvalidSort unless the sort field is one of
+// fields or empty.
+func (r Request) CheckSort(fields []string) error {
+	field, _ := r.SortField()
+	if field == "" || slices.Contains(fields, field) {
+		return nil
+	}
+	return ErrInvalidSort.WithMessage("cannot sort by " + field + "; use one of " + strings.Join(fields, ", "))
+}
+
+// OrderBy builds the ORDER BY clause for the SQLite repositories from the
+// columns of the sortable fields, always ending with the ID.
+func (r Request) OrderBy(columns map[string]string) string {
+	field, desc := r.SortField()
+	column, ok := columns[field]
+	if !ok || column == "id" {
+		if desc {
+			return " ORDER BY id DESC"
+		}
+		return " ORDER BY id"
+	}
+
+	direction := ""
+	if desc {
+		direction = " DESC"
+	}
+	return " ORDER BY " + column + direction + ", id"
+}
+
+// Page is one page of a list with the metadata to fetch the others.
+type Page[T any] struct {
+	Items []T `json:"items"`
+	Total int `json:"total"`
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+	Pages int `json:"pages"`
+}
+
+func NewPage[T any](items []T, total int, req Request) *Page[T] {
+	if items == nil {
+		items = make([]T, 0)
+	}
+	return &Page[T]{
+		Items: items,
+		Total: total,
+		Page:  req.Page,
+		Limit: req.Limit,
+		Pages: (total + req.Limit - 1) / req.Limit,
+	}
+}
+
+// Slice sorts and pages a filtered list held in memory. items must be in
+// ID order; compare holds a comparison function for each sortable field.
+func Slice[T any](items []T, req Request, compare map[string]func(a, b T) int) *Page[T] {
+	if field, desc := req.SortField(); compare[field] != nil {
+		cmp := compare[field]
+		sort.SliceStable(items, func(i, j int) bool {
+			if desc {
+				return cmp(items[i], items[j]) > 0
+			}
+			return cmp(items[i], items[j]) < 0
+		})
+	} else if desc {
+		slices.Reverse(items)
+	}
+
+	total := len(items)
+	start := min(req.Offset(), total)
+	end := min(start+req.Limit, total)
+	return NewPage(items[start:end], total, req)
+}
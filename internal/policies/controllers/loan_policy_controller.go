@@ -0,0 +1,110 @@
+package policies
+
+import (
+	"librarymvc/internal/apperror"
+	"librarymvc/internal/policies/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoanPolicyController struct {
+	policyService models.LoanPolicyService
+}
+
+func NewLoanPolicyController(policyService models.LoanPolicyService) *LoanPolicyController {
+	return &LoanPolicyController{policyService: policyService}
+}
+
+func (p *LoanPolicyController) RegisterRoutes(r *gin.Engine) {
+	policies := r.Group("/policies")
+	{
+		policies.GET("", p.GetAllPolicies)
+		policies.GET("/:id", p.GetPolicy)
+		policies.POST("", p.CreatePolicy)
+		policies.PUT("/:id", p.UpdatePolicy)
+		policies.DELETE("/:id", p.DeletePolicy)
+	}
+}
+
+func (p *LoanPolicyController) CreatePolicy(ctx *gin.Context) {
+	var policy models.LoanPolicy
+
+	if err := ctx.ShouldBindJSON(&policy); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	err := p.policyService.CreatePolicy(&policy)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, policy)
+}
+
+func (p *LoanPolicyController) GetPolicy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("policy"))
+		return
+	}
+
+	policy, err := p.policyService.GetPolicy(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+func (p *LoanPolicyController) GetAllPolicies(ctx *gin.Context) {
+	policies, err := p.policyService.GetAllPolicies()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policies)
+}
+
+func (p *LoanPolicyController) UpdatePolicy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("policy"))
+		return
+	}
+
+	var policy models.LoanPolicy
+	if err := ctx.ShouldBindJSON(&policy); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	err = p.policyService.UpdatePolicy(id, &policy)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+func (p *LoanPolicyController) DeletePolicy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("policy"))
+		return
+	}
+
+	err = p.policyService.DeletePolicy(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,17 @@
+package models
+
+import "librarymvc/internal/apperror"
+
+var (
+	ErrPolicyNotFound       = apperror.NewNotFound("policy_not_found", "loan policy not found")
+	ErrPolicyCodeExists     = apperror.NewConflict("policy_code_exists", "policy code already exists")
+	ErrPolicyInUse          = apperror.NewConflict("policy_in_use", "policy is in use by books")
+	ErrCodeRequired         = apperror.NewValidation("code_required", "code is required")
+	ErrNameRequired         = apperror.NewValidation("name_required", "name is required")
+	ErrLoanDaysRequired     = apperror.NewValidation("loan_days_required", "loan days must be positive for loanable materials")
+	ErrNegativeMaxRenewals  = apperror.NewValidation("negative_max_renewals", "max renewals cannot be negative")
+	ErrNegativeRenewalGrace = apperror.NewValidation("negative_renewal_grace_days", "renewal grace days cannot be negative")
+	ErrNegativeFineRate     = apperror.NewValidation("negative_fine_rate", "fine rate cannot be negative")
+	ErrNegativeFineGrace    = apperror.NewValidation("negative_fine_grace_days", "fine grace days cannot be negative")
+	ErrNegativeMaxFine      = apperror.NewValidation("negative_max_fine", "max fine cannot be negative")
+)
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"librarymvc/internal/money"
+	"time"
+)
+
+// ClosedDays tells which days the library is closed. Days it is closed are
+// not counted as days late.
+type ClosedDays interface {
+	IsClosed(day time.Time) bool
+}
+
+// FinePolicy computes the fine for a loan returned (or still out) after its
+// due date. Both the estimate shown while a loan is overdue and the charge
+// made on return come from Fine.
+type FinePolicy struct {
+	// GraceDays late are tolerated: a loan returned within them is not fined.
+	// Past them every day late is charged, grace days included.
+	GraceDays int
+	DailyRate money.Money
+	// MaxFine caps the fine of a single loan; 0 means no cap.
+	MaxFine money.Money
+	// Closed may be nil when every day counts.
+	Closed ClosedDays
+}
+
+// FinePolicy is the fine policy of the material type, skipping the days
+// closed says the library is closed.
+func (p *LoanPolicy) FinePolicy(closed ClosedDays) *FinePolicy {
+	return &FinePolicy{
+		GraceDays: p.FineGraceDays,
+		DailyRate: p.FineRate,
+		MaxFine:   p.MaxFine,
+		Closed:    closed,
+	}
+}
+
+// Fine is the fine for a loan due at dueDate and returned at returnedAt.
+func (f *FinePolicy) Fine(dueDate, returnedAt time.Time) money.Money {
+	daysLate := f.DaysLate(dueDate, returnedAt)
+	if daysLate <= f.GraceDays {
+		return 0
+	}
+
+	fine := f.DailyRate.Times(daysLate)
+	if f.MaxFine > 0 && fine > f.MaxFine {
+		fine = f.MaxFine
+	}
+	return fine
+}
+
+// DaysLate counts the whole days from dueDate to returnedAt on which the
+// library was open.
+func (f *FinePolicy) DaysLate(dueDate, returnedAt time.Time) int {
+	days := wholeDays(dueDate, returnedAt)
+	if f.Closed == nil {
+		return days
+	}
+
+	open := 0
+	for i := 1; i <= days; i++ {
+		if !f.Closed.IsClosed(dueDate.AddDate(0, 0, i)) {
+			open++
+		}
+	}
+	return open
+}
+
+// wholeDays counts the whole days from from to to, stepping by calendar
+// day so that 23- and 25-hour days around DST changes still count as one.
+func wholeDays(from, to time.Time) int {
+	if !to.After(from) {
+		return 0
+	}
+
+	days := int(to.Sub(from).Hours() / 24)
+	for days > 0 && from.AddDate(0, 0, days).After(to) {
+		days--
+	}
+	for !from.AddDate(0, 0, days+1).After(to) {
+		days++
+	}
+	return days
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"librarymvc/internal/money"
+	"time"
+)
+
+// LoanPolicy describes the lending rules for a material type. Books refer to
+// their policy through Book.BookType, which holds the policy Code.
+type LoanPolicy struct {
+	ID               int64       `json:"ID"`
+	Code             string      `json:"code" binding:"required"` // Ex.: emprestavel, referencia, dvd
+	Name             string      `json:"name" binding:"required"`
+	Loanable         bool        `json:"loanable"`                 // false: consulta apenas no local
+	LoanDays         int         `json:"loanDays" binding:"min=0"` // prazo padrão em dias
+	Renewable        bool        `json:"renewable"`
+	MaxRenewals      int         `json:"maxRenewals" binding:"min=0"`
+	RenewalGraceDays int         `json:"renewalGraceDays" binding:"min=0"` // dias de atraso tolerados para renovar
+	FineRate         money.Money `json:"fineRate" binding:"min=0"`         // multa por dia de atraso (R$)
+	FineGraceDays    int         `json:"fineGraceDays" binding:"min=0"`    // dias de atraso sem multa
+	MaxFine          money.Money `json:"maxFine" binding:"min=0"`          // teto da multa por empréstimo (0 = sem teto)
+	CreatedAt        time.Time   `json:"createdAt"`
+	UpdatedAt        time.Time   `json:"updatedAt"`
+}
+
+// DefaultLoanPolicies are created when no policy exists yet, matching the
+// book types the system shipped with.
+var DefaultLoanPolicies = []LoanPolicy{
+	{
+		Code:             "emprestavel",
+		Name:             "Emprestável",
+		Loanable:         true,
+		LoanDays:         12,
+		Renewable:        true,
+		MaxRenewals:      2,
+		RenewalGraceDays: 2,
+		FineRate:         money.FromCents(200),
+	},
+	{
+		Code:     "referencia",
+		Name:     "Referência",
+		Loanable: false,
+	},
+}
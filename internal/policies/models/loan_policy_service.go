@@ -0,0 +1,11 @@
+package models
+
+type LoanPolicyService interface {
+	CreatePolicy(policy *LoanPolicy) error
+	GetPolicy(id int64) (*LoanPolicy, error)
+	GetPolicyByCode(code string) (*LoanPolicy, error)
+	GetAllPolicies() ([]*LoanPolicy, error)
+	UpdatePolicy(id int64, policy *LoanPolicy) error
+	DeletePolicy(id int64) error
+	EnsureDefaultPolicies() error
+}
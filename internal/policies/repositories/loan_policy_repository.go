@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"librarymvc/internal/policies/models"
+	"sort"
+	"sync"
+)
+
+type LoanPolicyRepository struct {
+	policies map[int64]*models.LoanPolicy
+	mu       sync.RWMutex
+	nextID   int64
+}
+
+func NewLoanPolicyRepository() models.LoanPolicyRepository {
+	return &LoanPolicyRepository{
+		policies: make(map[int64]*models.LoanPolicy),
+		nextID:   1,
+	}
+}
+
+func (p *LoanPolicyRepository) codeTaken(code string, exceptID int64) bool {
+	for id, policy := range p.policies {
+		if id != exceptID && policy.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *LoanPolicyRepository) CreatePolicy(policy *models.LoanPolicy) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.codeTaken(policy.Code, 0) {
+		return models.ErrPolicyCodeExists
+	}
+
+	policy.ID = p.nextID
+	p.policies[policy.ID] = policy
+	p.nextID++
+
+	return nil
+}
+
+func (p *LoanPolicyRepository) GetPolicy(id int64) (*models.LoanPolicy, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	policy, exists := p.policies[id]
+	if !exists {
+		return nil, models.ErrPolicyNotFound
+	}
+
+	return policy, nil
+}
+
+func (p *LoanPolicyRepository) GetPolicyByCode(code string) (*models.LoanPolicy, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, policy := range p.policies {
+		if policy.Code == code {
+			return policy, nil
+		}
+	}
+
+	return nil, models.ErrPolicyNotFound
+}
+
+func (p *LoanPolicyRepository) GetAllPolicies() ([]*models.LoanPolicy, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	policies := make([]*models.LoanPolicy, 0, len(p.policies))
+	for _, policy := range p.policies {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].ID < policies[j].ID })
+
+	return policies, nil
+}
+
+func (p *LoanPolicyRepository) UpdatePolicy(id int64, policy *models.LoanPolicy) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, exists := p.policies[id]
+	if !exists {
+		return models.ErrPolicyNotFound
+	}
+	if p.codeTaken(policy.Code, id) {
+		return models.ErrPolicyCodeExists
+	}
+
+	policy.ID = id
+	p.policies[id] = policy
+	return nil
+}
+
+func (p *LoanPolicyRepository) DeletePolicy(id int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, exists := p.policies[id]
+	if !exists {
+		return models.ErrPolicyNotFound
+	}
+
+	delete(p.policies, id)
+	return nil
+}
+
+// Snapshot copies the current state and returns a function that restores it.
+// It is used by the in-memory unit of work to roll back failed transactions.
+func (p *LoanPolicyRepository) Snapshot() func() {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	policies := make(map[int64]models.LoanPolicy, len(p.policies))
+	for id, policy := range p.policies {
+		policies[id] = *policy
+	}
+	nextID := p.nextID
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.policies = make(map[int64]*models.LoanPolicy, len(policies))
+		for id, policy := range policies {
+			policy := policy
+			p.policies[id] = &policy
+		}
+		p.nextID = nextID
+	}
+}
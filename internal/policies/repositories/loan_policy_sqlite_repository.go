@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"librarymvc/internal/database"
+	"librarymvc/internal/policies/models"
+	"strings"
+)
+
+type SQLiteLoanPolicyRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteLoanPolicyRepository(db database.DBTX) models.LoanPolicyRepository {
+	return &SQLiteLoanPolicyRepository{db: db}
+}
+
+const policyColumns = `id, code, name, loanable, loan_days, renewable, max_renewals, renewal_grace_days, fine_rate_cents, fine_grace_days, max_fine_cents, created_at, updated_at`
+
+func scanPolicy(row interface{ Scan(...any) error }) (*models.LoanPolicy, error) {
+	var policy models.LoanPolicy
+	err := row.Scan(
+		&policy.ID,
+		&policy.Code,
+		&policy.Name,
+		&policy.Loanable,
+		&policy.LoanDays,
+		&policy.Renewable,
+		&policy.MaxRenewals,
+		&policy.RenewalGraceDays,
+		&policy.FineRate,
+		&policy.FineGraceDays,
+		&policy.MaxFine,
+		&policy.CreatedAt,
+		&policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func (p *SQLiteLoanPolicyRepository) CreatePolicy(policy *models.LoanPolicy) error {
+	result, err := p.db.Exec(
+		`INSERT INTO loan_policies (code, name, loanable, loan_days, renewable, max_renewals, renewal_grace_days, fine_rate_cents, fine_grace_days, max_fine_cents, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		policy.Code, policy.Name, policy.Loanable, policy.LoanDays, policy.Renewable,
+		policy.MaxRenewals, policy.RenewalGraceDays, policy.FineRate, policy.FineGraceDays, policy.MaxFine,
+		policy.CreatedAt, policy.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return models.ErrPolicyCodeExists
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	policy.ID = id
+
+	return nil
+}
+
+func (p *SQLiteLoanPolicyRepository) getOne(query string, args ...any) (*models.LoanPolicy, error) {
+	policy, err := scanPolicy(p.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrPolicyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (p *SQLiteLoanPolicyRepository) GetPolicy(id int64) (*models.LoanPolicy, error) {
+	return p.getOne(`SELECT `+policyColumns+` FROM loan_policies WHERE id = ?`, id)
+}
+
+func (p *SQLiteLoanPolicyRepository) GetPolicyByCode(code string) (*models.LoanPolicy, error) {
+	return p.getOne(`SELECT `+policyColumns+` FROM loan_policies WHERE code = ?`, code)
+}
+
+func (p *SQLiteLoanPolicyRepository) GetAllPolicies() ([]*models.LoanPolicy, error) {
+	rows, err := p.db.Query(`SELECT ` + policyColumns + ` FROM loan_policies ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make([]*models.LoanPolicy, 0)
+	for rows.Next() {
+		policy, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+func (p *SQLiteLoanPolicyRepository) UpdatePolicy(id int64, policy *models.LoanPolicy) error {
+	result, err := p.db.Exec(
+		`UPDATE loan_policies
+		 SET code = ?, name = ?, loanable = ?, loan_days = ?, renewable = ?, max_renewals = ?,
+		     renewal_grace_days = ?, fine_rate_cents = ?, fine_grace_days = ?, max_fine_cents = ?, updated_at = ?
+		 WHERE id = ?`,
+		policy.Code, policy.Name, policy.Loanable, policy.LoanDays, policy.Renewable,
+		policy.MaxRenewals, policy.RenewalGraceDays, policy.FineRate, policy.FineGraceDays, policy.MaxFine,
+		policy.UpdatedAt, id,
+	)
+	if isUniqueViolation(err) {
+		return models.ErrPolicyCodeExists
+	}
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrPolicyNotFound
+	}
+
+	policy.ID = id
+	return nil
+}
+
+func (p *SQLiteLoanPolicyRepository) DeletePolicy(id int64) error {
+	result, err := p.db.Exec(`DELETE FROM loan_policies WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrPolicyNotFound
+	}
+
+	return nil
+}
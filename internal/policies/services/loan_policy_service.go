@@ -0,0 +1,148 @@
+package services
+
+import (
+	bookModel "librarymvc/internal/books/models"
+	"librarymvc/internal/clock"
+	"librarymvc/internal/policies/models"
+	"strings"
+)
+
+type LoanPolicyService struct {
+	policyRepository models.LoanPolicyRepository
+	bookRepository   bookModel.BookRepository
+	clock            clock.Clock
+}
+
+func NewLoanPolicyService(
+	policyRepository models.LoanPolicyRepository,
+	bookRepository bookModel.BookRepository,
+	clock clock.Clock,
+) models.LoanPolicyService {
+	return &LoanPolicyService{
+		policyRepository: policyRepository,
+		bookRepository:   bookRepository,
+		clock:            clock,
+	}
+}
+
+func validatePolicy(policy *models.LoanPolicy) error {
+	if policy.Code == "" {
+		return models.ErrCodeRequired
+	}
+	if policy.Name == "" {
+		return models.ErrNameRequired
+	}
+	if policy.Loanable && policy.LoanDays <= 0 {
+		return models.ErrLoanDaysRequired
+	}
+	if policy.MaxRenewals < 0 {
+		return models.ErrNegativeMaxRenewals
+	}
+	if policy.RenewalGraceDays < 0 {
+		return models.ErrNegativeRenewalGrace
+	}
+	if policy.FineRate < 0 {
+		return models.ErrNegativeFineRate
+	}
+	if policy.FineGraceDays < 0 {
+		return models.ErrNegativeFineGrace
+	}
+	if policy.MaxFine < 0 {
+		return models.ErrNegativeMaxFine
+	}
+	return nil
+}
+
+// normalize clears settings that don't apply to the policy.
+func normalize(policy *models.LoanPolicy) {
+	policy.Code = strings.ToLower(strings.TrimSpace(policy.Code))
+	policy.Name = strings.TrimSpace(policy.Name)
+	if !policy.Loanable {
+		policy.LoanDays = 0
+		policy.Renewable = false
+	}
+	if !policy.Renewable {
+		policy.MaxRenewals = 0
+		policy.RenewalGraceDays = 0
+	}
+}
+
+func (p LoanPolicyService) CreatePolicy(policy *models.LoanPolicy) error {
+	normalize(policy)
+	if err := validatePolicy(policy); err != nil {
+		return err
+	}
+
+	policy.CreatedAt = p.clock.Now()
+	policy.UpdatedAt = policy.CreatedAt
+	return p.policyRepository.CreatePolicy(policy)
+}
+
+func (p LoanPolicyService) GetPolicy(id int64) (*models.LoanPolicy, error) {
+	return p.policyRepository.GetPolicy(id)
+}
+
+func (p LoanPolicyService) GetPolicyByCode(code string) (*models.LoanPolicy, error) {
+	return p.policyRepository.GetPolicyByCode(code)
+}
+
+func (p LoanPolicyService) GetAllPolicies() ([]*models.LoanPolicy, error) {
+	return p.policyRepository.GetAllPolicies()
+}
+
+// UpdatePolicy keeps the original code, since books refer to it.
+func (p LoanPolicyService) UpdatePolicy(id int64, policy *models.LoanPolicy) error {
+	existing, err := p.policyRepository.GetPolicy(id)
+	if err != nil {
+		return err
+	}
+
+	policy.Code = existing.Code
+	normalize(policy)
+	if err := validatePolicy(policy); err != nil {
+		return err
+	}
+
+	policy.CreatedAt = existing.CreatedAt
+	policy.UpdatedAt = p.clock.Now()
+	return p.policyRepository.UpdatePolicy(id, policy)
+}
+
+func (p LoanPolicyService) DeletePolicy(id int64) error {
+	policy, err := p.policyRepository.GetPolicy(id)
+	if err != nil {
+		return err
+	}
+
+	books, err := p.bookRepository.GetAllBooks()
+	if err != nil {
+		return err
+	}
+	for _, book := range books {
+		if book.BookType == policy.Code {
+			return models.ErrPolicyInUse
+		}
+	}
+
+	return p.policyRepository.DeletePolicy(id)
+}
+
+// EnsureDefaultPolicies seeds models.DefaultLoanPolicies on a fresh install.
+func (p LoanPolicyService) EnsureDefaultPolicies() error {
+	policies, err := p.policyRepository.GetAllPolicies()
+	if err != nil {
+		return err
+	}
+	if len(policies) > 0 {
+		return nil
+	}
+
+	for _, policy := range models.DefaultLoanPolicies {
+		policy := policy
+		if err := p.CreatePolicy(&policy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
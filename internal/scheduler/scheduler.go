@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a unit of periodic work. Jobs read the time from the clock of the
+// service they call, so they can be run against a fake clock.
+type Job struct {
+	Name string
+	Run  func() error
+}
+
+// Scheduler runs its jobs one after another every interval.
+type Scheduler struct {
+	interval time.Duration
+	jobs     []Job
+}
+
+func NewScheduler(interval time.Duration) *Scheduler {
+	return &Scheduler{interval: interval}
+}
+
+func (s *Scheduler) Add(name string, run func() error) {
+	s.jobs = append(s.jobs, Job{Name: name, Run: run})
+}
+
+// RunOnce runs every job once. A failing job is logged and does not stop
+// the others.
+func (s *Scheduler) RunOnce() {
+	for _, job := range s.jobs {
+		if err := job.Run(); err != nil {
+			log.Printf("scheduler: job %s failed: %v", job.Name, err)
+		}
+	}
+}
+
+// Start runs the jobs right away and then every interval in the background,
+// until the returned stop function is called.
+func (s *Scheduler) Start() (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.RunOnce()
+		for {
+			select {
+			case <-ticker.C:
+				s.RunOnce()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
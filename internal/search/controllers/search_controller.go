@@ -0,0 +1,39 @@
+package search
+
+import (
+	"librarymvc/internal/paging"
+	"librarymvc/internal/search/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchController struct {
+	searchService models.SearchService
+}
+
+func NewSearchController(searchService models.SearchService) *SearchController {
+	return &SearchController{searchService: searchService}
+}
+
+func (s *SearchController) RegisterRoutes(r *gin.Engine) {
+	r.GET("/books/search", s.SearchBooks)
+}
+
+// SearchBooks finds books by the words in q, most relevant first, a page at
+// a time.
+func (s *SearchController) SearchBooks(ctx *gin.Context) {
+	page, err := paging.FromQuery(ctx.Request.URL.Query())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	results, err := s.searchService.SearchBooks(ctx.Query("q"), page)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	bookModel "librarymvc/internal/books/models"
+	"librarymvc/internal/isbn"
+)
+
+// Field weights: a word in the title counts more than one in the author's
+// name or a subject, and an ISBN identifies the book outright.
+const (
+	TitleWeight   = 3.0
+	AuthorWeight  = 2.0
+	SubjectWeight = 1.5
+	ISBNWeight    = 5.0
+)
+
+// Document is what the index knows about a book: the words of each
+// searchable field with the weight of the field.
+type Document struct {
+	ID     int64
+	Fields []Field
+}
+
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// NewDocument lists the searchable fields of a book. The ISBN is indexed
+// in both its 13- and 10-digit forms.
+func NewDocument(book *bookModel.Book) *Document {
+	doc := &Document{
+		ID: book.ID,
+		Fields: []Field{
+			{Text: book.Title, Weight: TitleWeight},
+			{Text: book.Author, Weight: AuthorWeight},
+			{Text: book.ISBN, Weight: ISBNWeight},
+		},
+	}
+	for _, subject := range book.Subjects {
+		doc.Fields = append(doc.Fields, Field{Text: subject.Name, Weight: SubjectWeight})
+	}
+	if isbn10, ok := isbn.To10(book.ISBN); ok {
+		doc.Fields = append(doc.Fields, Field{Text: isbn10, Weight: ISBNWeight})
+	}
+	return doc
+}
+
+// Hit is a document matching a query, with its relevance.
+type Hit struct {
+	ID    int64
+	Score float64
+}
+
+// Result is a book found by a search.
+type Result struct {
+	*bookModel.Book
+	Score float64 `json:"score"`
+}
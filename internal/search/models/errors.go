@@ -0,0 +1,5 @@
+package models
+
+import "librarymvc/internal/apperror"
+
+var ErrEmptyQuery = apperror.NewValidation("empty_query", "search query must contain at least one word")
@@ -0,0 +1,12 @@
+package models
+
+// SearchIndex is an inverted index over the catalog.
+type SearchIndex interface {
+	// Index adds the document, replacing an earlier version of it.
+	Index(doc *Document)
+	Remove(id int64)
+	// Search returns the documents matching every word of the query, most
+	// relevant first. Words also match as prefixes, ranked below whole-word
+	// matches, so results show up while the user is still typing.
+	Search(query string) []Hit
+}
@@ -0,0 +1,9 @@
+package models
+
+import "librarymvc/internal/paging"
+
+type SearchService interface {
+	SearchBooks(query string, page paging.Request) (*paging.Page[*Result], error)
+	// Rebuild indexes the whole catalog again.
+	Rebuild() error
+}
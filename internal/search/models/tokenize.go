@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"librarymvc/internal/isbn"
+)
+
+// stopWords are Portuguese articles, prepositions and conjunctions too
+// common to tell titles apart.
+var stopWords = map[string]bool{
+	"a": true, "o": true, "as": true, "os": true, "um": true, "uma": true,
+	"de": true, "da": true, "do": true, "das": true, "dos": true,
+	"em": true, "na": true, "no": true, "nas": true, "nos": true,
+	"e": true, "ou": true, "com": true, "por": true, "para": true,
+}
+
+// Fold lowercases text and strips its accents, so "Coração" and "coracao"
+// compare equal.
+func Fold(text string) string {
+	fold := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(fold, text)
+	if err != nil {
+		folded = text
+	}
+	return strings.ToLower(folded)
+}
+
+// Tokenize splits text into folded words, dropping punctuation.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(Fold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// QueryTerms tokenizes a search query, dropping the stop words unless the
+// query has nothing else. A hyphenated ISBN is kept whole.
+func QueryTerms(query string) []string {
+	if isbn13, err := isbn.Parse(query); err == nil {
+		return []string{isbn13}
+	}
+
+	words := Tokenize(query)
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if !stopWords[word] {
+			terms = append(terms, word)
+		}
+	}
+	if len(terms) == 0 {
+		return words
+	}
+	return terms
+}
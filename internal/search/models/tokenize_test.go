@@ -0,0 +1,65 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Coração", "coracao"},
+		{"ÁGUA VIVA", "agua viva"},
+		{"Memórias Póstumas de Brás Cubas", "memorias postumas de bras cubas"},
+		{"Pinóquio, à noite!", "pinoquio, a noite!"},
+		{"já está", "ja esta"},
+	}
+
+	for _, test := range tests {
+		if got := Fold(test.input); got != test.want {
+			t.Errorf("Fold(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"O Cortiço", []string{"o", "cortico"}},
+		{"Grande Sertão: Veredas", []string{"grande", "sertao", "veredas"}},
+		{"Vidas secas (1938), 2ª ed.", []string{"vidas", "secas", "1938", "2ª", "ed"}},
+		{"Machado de Assis; Gustavo Franco", []string{"machado", "de", "assis", "gustavo", "franco"}},
+		{"d'Ávila — São Paulo/SP", []string{"d", "avila", "sao", "paulo", "sp"}},
+		{"  ...  ", nil},
+	}
+
+	for _, test := range tests {
+		if got := Tokenize(test.input); !slices.Equal(got, test.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"O Cortiço", []string{"cortico"}},
+		{"memórias de um sargento", []string{"memorias", "sargento"}},
+		{"o a", []string{"o", "a"}},
+		{"978-0-306-40615-7", []string{"9780306406157"}},
+		{"0-306-40615-2", []string{"9780306406157"}},
+		{"978-0-306-40615-8", []string{"978", "0", "306", "40615", "8"}},
+	}
+
+	for _, test := range tests {
+		if got := QueryTerms(test.input); !slices.Equal(got, test.want) {
+			t.Errorf("QueryTerms(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"cmp"
+	"librarymvc/internal/search/models"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// prefixFactor scales the score of a word matched only as a prefix.
+const prefixFactor = 0.5
+
+// SearchIndex keeps the inverted index in memory; it is rebuilt from the
+// catalog on startup.
+type SearchIndex struct {
+	mu sync.RWMutex
+	// postings holds, for each term, the weight it has in each document.
+	postings map[string]map[int64]float64
+	// terms lists the postings keys in order, for prefix lookups.
+	terms []string
+	docs  map[int64][]string
+}
+
+func NewSearchIndex() models.SearchIndex {
+	return &SearchIndex{
+		postings: make(map[string]map[int64]float64),
+		docs:     make(map[int64][]string),
+	}
+}
+
+func (s *SearchIndex) Index(doc *models.Document) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(doc.ID)
+
+	var terms []string
+	for _, field := range doc.Fields {
+		for _, term := range models.Tokenize(field.Text) {
+			posting, exists := s.postings[term]
+			if !exists {
+				posting = make(map[int64]float64)
+				s.postings[term] = posting
+				i, _ := slices.BinarySearch(s.terms, term)
+				s.terms = slices.Insert(s.terms, i, term)
+			}
+			if _, seen := posting[doc.ID]; !seen {
+				terms = append(terms, term)
+			}
+			posting[doc.ID] += field.Weight
+		}
+	}
+	s.docs[doc.ID] = terms
+}
+
+func (s *SearchIndex) Remove(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(id)
+}
+
+// remove drops a document from the index. The caller must hold the lock.
+func (s *SearchIndex) remove(id int64) {
+	for _, term := range s.docs[id] {
+		posting := s.postings[term]
+		delete(posting, id)
+		if len(posting) == 0 {
+			delete(s.postings, term)
+			if i, found := slices.BinarySearch(s.terms, term); found {
+				s.terms = slices.Delete(s.terms, i, i+1)
+			}
+		}
+	}
+	delete(s.docs, id)
+}
+
+func (s *SearchIndex) Search(query string) []models.Hit {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var scores map[int64]float64
+	for _, word := range models.QueryTerms(query) {
+		matches := s.match(word)
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		// Every word must match
+		for id, score := range scores {
+			if match, ok := matches[id]; ok {
+				scores[id] = score + match
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]models.Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, models.Hit{ID: id, Score: math.Round(score*1000) / 1000})
+	}
+	slices.SortFunc(hits, func(x, y models.Hit) int {
+		if c := cmp.Compare(y.Score, x.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.ID, y.ID)
+	})
+	return hits
+}
+
+// match scores the documents containing word, either whole or, for words of
+// two letters or more, as the start of a longer term. Rarer terms score
+// higher. A document counts only its best matching term.
+func (s *SearchIndex) match(word string) map[int64]float64 {
+	scores := make(map[int64]float64)
+	add := func(term string, factor float64) {
+		posting := s.postings[term]
+		idf := 1 + math.Log(float64(len(s.docs))/float64(len(posting)))
+		for id, weight := range posting {
+			scores[id] = max(scores[id], weight*idf*factor)
+		}
+	}
+
+	start, found := slices.BinarySearch(s.terms, word)
+	if found {
+		add(word, 1)
+		start++
+	}
+	if len([]rune(word)) < 2 {
+		return scores
+	}
+	for _, term := range s.terms[start:] {
+		if !strings.HasPrefix(term, word) {
+			break
+		}
+		add(term, prefixFactor)
+	}
+	return scores
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"slices"
+	"testing"
+
+	bookModel "librarymvc/internal/books/models"
+	"librarymvc/internal/search/models"
+)
+
+func document(id int64, title, author string, subjects ...string) *models.Document {
+	book := &bookModel.Book{ID: id, Title: title, Author: author}
+	for _, subject := range subjects {
+		book.Subjects = append(book.Subjects, &bookModel.Subject{Name: subject})
+	}
+	return models.NewDocument(book)
+}
+
+func ids(hits []models.Hit) []int64 {
+	found := make([]int64, 0, len(hits))
+	for _, hit := range hits {
+		found = append(found, hit.ID)
+	}
+	return found
+}
+
+func newCatalogIndex() models.SearchIndex {
+	index := NewSearchIndex()
+	index.Index(document(1, "Dom Casmurro", "Machado de Assis", "Romance brasileiro"))
+	index.Index(document(2, "Casa-Grande & Senzala", "Gilberto Freyre", "Sociologia"))
+	index.Index(document(3, "O Alienista", "Machado de Assis"))
+	index.Index(document(4, "Machado de Assis: um mestre na periferia", "Roberto Schwarz", "Crítica literária"))
+	index.Index(document(5, "A Casa", "Natércia Campos", "Romance brasileiro"))
+	index.Index(document(6, "Obra poética", "Cecília Meireles", "Romanceiro"))
+	return index
+}
+
+func TestSearch(t *testing.T) {
+	index := newCatalogIndex()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int64
+	}{
+		{"a word in any field", "gilberto", []int64{2}},
+		{"accents and case are ignored", "CRITICA Literaria", []int64{4}},
+		{"every word must match", "machado alienista", []int64{3}},
+		{"stop words are dropped", "o alienista", []int64{3}},
+		{"no match", "saramago", []int64{}},
+		{"a prefix", "senz", []int64{2}},
+		{"a prefix of one letter is not enough", "s", []int64{}},
+		{"a whole word ranks above a prefix", "romance", []int64{1, 5, 6}},
+		{"the title weighs more than the author", "machado", []int64{4, 1, 3}},
+		{"ties go in ID order", "casa", []int64{2, 5}},
+	}
+
+	for _, test := range tests {
+		if got := ids(index.Search(test.query)); !slices.Equal(got, test.want) {
+			t.Errorf("%s: Search(%q) = %v, want %v", test.name, test.query, got, test.want)
+		}
+	}
+}
+
+func TestSearchByISBN(t *testing.T) {
+	index := NewSearchIndex()
+	index.Index(models.NewDocument(&bookModel.Book{ID: 1, Title: "Dom Casmurro", ISBN: "9788535911640"}))
+	index.Index(models.NewDocument(&bookModel.Book{ID: 2, Title: "Quincas Borba", ISBN: "9791090636071"}))
+
+	for _, query := range []string{"978-85-359-1164-0", "8535911642", "85-359-1164-2", "9788535911640"} {
+		if got := ids(index.Search(query)); !slices.Equal(got, []int64{1}) {
+			t.Errorf("Search(%q) = %v, want [1]", query, got)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	index := newCatalogIndex()
+	index.Remove(1)
+	index.Remove(99)
+
+	if got := ids(index.Search("machado")); !slices.Equal(got, []int64{4, 3}) {
+		t.Errorf("Search(machado) = %v, want [4 3]", got)
+	}
+	// Words only the removed book had are gone, whole or as prefixes
+	for _, query := range []string{"casmurro", "casm"} {
+		if got := index.Search(query); len(got) != 0 {
+			t.Errorf("Search(%q) = %v, want nothing", query, ids(got))
+		}
+	}
+
+	// Indexing a book again replaces what was known about it
+	index.Index(document(2, "Sobrados e Mucambos", "Gilberto Freyre"))
+	if got := ids(index.Search("senzala")); len(got) != 0 {
+		t.Errorf("Search(senzala) = %v, want nothing", got)
+	}
+	if got := ids(index.Search("mucambos")); !slices.Equal(got, []int64{2}) {
+		t.Errorf("Search(mucambos) = %v, want [2]", got)
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	bookModel "librarymvc/internal/books/models"
+	"librarymvc/internal/paging"
+	"librarymvc/internal/search/models"
+)
+
+type SearchService struct {
+	searchIndex    models.SearchIndex
+	bookRepository bookModel.BookRepository
+	bookService    bookModel.BookService
+}
+
+func NewSearchService(
+	searchIndex models.SearchIndex,
+	bookRepository bookModel.BookRepository,
+	bookService bookModel.BookService,
+) models.SearchService {
+	return &SearchService{
+		searchIndex:    searchIndex,
+		bookRepository: bookRepository,
+		bookService:    bookService,
+	}
+}
+
+// SearchBooks pages through the books matching query by relevance; the
+// sort order of page is ignored.
+func (s *SearchService) SearchBooks(query string, page paging.Request) (*paging.Page[*models.Result], error) {
+	if len(models.QueryTerms(query)) == 0 {
+		return nil, models.ErrEmptyQuery
+	}
+
+	page.Sort = ""
+	hits := paging.Slice(s.searchIndex.Search(query), page.Normalize(), nil)
+
+	results := make([]*models.Result, 0, len(hits.Items))
+	for _, hit := range hits.Items {
+		book, err := s.bookService.GetBook(hit.ID)
+		if errors.Is(err, bookModel.ErrBookNotFound) {
+			// Deleted while we were searching
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, &models.Result{Book: book, Score: hit.Score})
+	}
+
+	return paging.NewPage(results, hits.Total, page.Normalize()), nil
+}
+
+func (s *SearchService) Rebuild() error {
+	books, err := s.bookRepository.GetAllBooks()
+	if err != nil {
+		return err
+	}
+
+	for _, book := range books {
+		s.searchIndex.Index(models.NewDocument(book))
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Package testenv builds the repositories of each storage driver for tests,
+// so a test can run the same checks against memory and SQLite.
+package testenv
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	accountRepository "librarymvc/internal/accounts/repositories"
+	authModel "librarymvc/internal/auth/models"
+	authRepository "librarymvc/internal/auth/repositories"
+	bookRepository "librarymvc/internal/books/repositories"
+	calendarRepository "librarymvc/internal/calendar/repositories"
+	calendarService "librarymvc/internal/calendar/services"
+	"librarymvc/internal/clock"
+	copyRepository "librarymvc/internal/copies/repositories"
+	"librarymvc/internal/database"
+	holdRepository "librarymvc/internal/holds/repositories"
+	loanRepository "librarymvc/internal/loans/repositories"
+	policyRepository "librarymvc/internal/policies/repositories"
+	policyService "librarymvc/internal/policies/services"
+	"librarymvc/internal/unitofwork"
+	userRepository "librarymvc/internal/users/repositories"
+)
+
+// Storage is one driver's repositories, seeded with the default loan
+// policies and opening hours like a fresh install. Clock starts at the
+// current time; tests move it and hand it to the services they build.
+type Storage struct {
+	Driver      string
+	Clock       *clock.Fake
+	Repos       unitofwork.Repositories
+	UnitOfWork  unitofwork.UnitOfWork
+	Credentials authModel.CredentialRepository
+	Sessions    authModel.SessionRepository
+	Tokens      authModel.TokenRepository
+}
+
+// Memory keeps everything in memory.
+func Memory(t testing.TB) *Storage {
+	clk := clock.NewFake(time.Now())
+	repos := unitofwork.Repositories{
+		Books:        bookRepository.NewBookRepository(),
+		Authors:      bookRepository.NewAuthorRepository(),
+		Subjects:     bookRepository.NewSubjectRepository(),
+		Copies:       copyRepository.NewCopyRepository(clk),
+		Users:        userRepository.NewUserRepository(),
+		Loans:        loanRepository.NewLoanRepository(),
+		Policies:     policyRepository.NewLoanPolicyRepository(),
+		Holds:        holdRepository.NewHoldRepository(),
+		Transactions: accountRepository.NewTransactionRepository(),
+		Calendar:     calendarRepository.NewCalendarRepository(),
+	}
+	return seed(t, &Storage{
+		Driver:      "memory",
+		Clock:       clk,
+		Repos:       repos,
+		UnitOfWork:  unitofwork.NewMemoryUnitOfWork(repos),
+		Credentials: authRepository.NewCredentialRepository(),
+		Sessions:    authRepository.NewSessionRepository(),
+		Tokens:      authRepository.NewTokenRepository(),
+	})
+}
+
+// SQLite uses a fresh database file in the test's temporary directory.
+func SQLite(t testing.TB) *Storage {
+	db, err := database.Open(filepath.Join(t.TempDir(), "library.db"))
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	clk := clock.NewFake(time.Now())
+	return seed(t, &Storage{
+		Driver: "sqlite",
+		Clock:  clk,
+		Repos: unitofwork.Repositories{
+			Books:        bookRepository.NewSQLiteBookRepository(db),
+			Authors:      bookRepository.NewSQLiteAuthorRepository(db),
+			Subjects:     bookRepository.NewSQLiteSubjectRepository(db),
+			Copies:       copyRepository.NewSQLiteCopyRepository(db, clk),
+			Users:        userRepository.NewSQLiteUserRepository(db),
+			Loans:        loanRepository.NewSQLiteLoanRepository(db),
+			Policies:     policyRepository.NewSQLiteLoanPolicyRepository(db),
+			Holds:        holdRepository.NewSQLiteHoldRepository(db),
+			Transactions: accountRepository.NewSQLiteTransactionRepository(db),
+			Calendar:     calendarRepository.NewSQLiteCalendarRepository(db),
+		},
+		UnitOfWork:  unitofwork.NewSQLiteUnitOfWork(db, clk),
+		Credentials: authRepository.NewSQLiteCredentialRepository(db),
+		Sessions:    authRepository.NewSQLiteSessionRepository(db),
+		Tokens:      authRepository.NewSQLiteTokenRepository(db),
+	})
+}
+
+// Each runs test once with each storage driver.
+func Each(t *testing.T, test func(t *testing.T, storage *Storage)) {
+	for _, open := range []func(testing.TB) *Storage{Memory, SQLite} {
+		storage := open(t)
+		t.Run(storage.Driver, func(t *testing.T) {
+			test(t, storage)
+		})
+	}
+}
+
+func seed(t testing.TB, storage *Storage) *Storage {
+	policies := policyService.NewLoanPolicyService(storage.Repos.Policies, storage.Repos.Books, storage.Clock)
+	if err := policies.EnsureDefaultPolicies(); err != nil {
+		t.Fatalf("seeding loan policies: %v", err)
+	}
+	calendar := calendarService.NewCalendarService(storage.Repos.Calendar, storage.Clock)
+	if err := calendar.EnsureDefaultHours(); err != nil {
+		t.Fatalf("seeding opening hours: %v", err)
+	}
+	return storage
+}
@@ -0,0 +1,448 @@
+package unitofwork
+
+import (
+	accountModel "librarymvc/internal/accounts/models"
+	bookModel "librarymvc/internal/books/models"
+	calendarModel "librarymvc/internal/calendar/models"
+	copyModel "librarymvc/internal/copies/models"
+	holdModel "librarymvc/internal/holds/models"
+	loanModel "librarymvc/internal/loans/models"
+	"librarymvc/internal/money"
+	"librarymvc/internal/paging"
+	policyModel "librarymvc/internal/policies/models"
+	userModel "librarymvc/internal/users/models"
+	"time"
+)
+
+// tracker snapshots an in-memory repository the first time a unit of work
+// uses it, so only the repositories the unit touches are copied and
+// restored. Reads count too: the in-memory repositories hand out the
+// records they store, which callers may change before saving them.
+type tracker struct {
+	repo    snapshotter
+	restore func()
+}
+
+func (t *tracker) touch() {
+	if t.restore == nil {
+		t.restore = t.repo.Snapshot()
+	}
+}
+
+// track wraps the in-memory repositories so that every call goes through
+// their tracker. Repositories that can't snapshot are used as they are.
+func track(repos Repositories) (Repositories, []*tracker) {
+	var trackers []*tracker
+	newTracker := func(repo any) *tracker {
+		s, ok := repo.(snapshotter)
+		if !ok {
+			return nil
+		}
+		t := &tracker{repo: s}
+		trackers = append(trackers, t)
+		return t
+	}
+
+	if t := newTracker(repos.Books); t != nil {
+		repos.Books = trackedBooks{repos.Books, t}
+	}
+	if t := newTracker(repos.Authors); t != nil {
+		repos.Authors = trackedAuthors{repos.Authors, t}
+	}
+	if t := newTracker(repos.Subjects); t != nil {
+		repos.Subjects = trackedSubjects{repos.Subjects, t}
+	}
+	if t := newTracker(repos.Copies); t != nil {
+		repos.Copies = trackedCopies{repos.Copies, t}
+	}
+	if t := newTracker(repos.Users); t != nil {
+		repos.Users = trackedUsers{repos.Users, t}
+	}
+	if t := newTracker(repos.Loans); t != nil {
+		repos.Loans = trackedLoans{repos.Loans, t}
+	}
+	if t := newTracker(repos.Policies); t != nil {
+		repos.Policies = trackedPolicies{repos.Policies, t}
+	}
+	if t := newTracker(repos.Holds); t != nil {
+		repos.Holds = trackedHolds{repos.Holds, t}
+	}
+	if t := newTracker(repos.Transactions); t != nil {
+		repos.Transactions = trackedTransactions{repos.Transactions, t}
+	}
+	if t := newTracker(repos.Calendar); t != nil {
+		repos.Calendar = trackedCalendar{repos.Calendar, t}
+	}
+
+	return repos, trackers
+}
+
+type trackedBooks struct {
+	bookModel.BookRepository
+	*tracker
+}
+
+func (r trackedBooks) CreateBook(book *bookModel.Book) error {
+	r.touch()
+	return r.BookRepository.CreateBook(book)
+}
+
+func (r trackedBooks) GetBook(id int64) (*bookModel.Book, error) {
+	r.touch()
+	return r.BookRepository.GetBook(id)
+}
+
+func (r trackedBooks) GetBookByISBN(isbn string) (*bookModel.Book, error) {
+	r.touch()
+	return r.BookRepository.GetBookByISBN(isbn)
+}
+
+func (r trackedBooks) GetAllBooks() ([]*bookModel.Book, error) {
+	r.touch()
+	return r.BookRepository.GetAllBooks()
+}
+
+func (r trackedBooks) ListBooks(filter bookModel.BookFilter, page paging.Request) (*paging.Page[*bookModel.Book], error) {
+	r.touch()
+	return r.BookRepository.ListBooks(filter, page)
+}
+
+func (r trackedBooks) UpdateBook(id int64, book *bookModel.Book) error {
+	r.touch()
+	return r.BookRepository.UpdateBook(id, book)
+}
+
+func (r trackedBooks) DeleteBook(id int64) error {
+	r.touch()
+	return r.BookRepository.DeleteBook(id)
+}
+
+type trackedAuthors struct {
+	bookModel.AuthorRepository
+	*tracker
+}
+
+func (r trackedAuthors) FindOrCreateAuthor(author *bookModel.Author) error {
+	r.touch()
+	return r.AuthorRepository.FindOrCreateAuthor(author)
+}
+
+func (r trackedAuthors) GetAuthor(id int64) (*bookModel.Author, error) {
+	r.touch()
+	return r.AuthorRepository.GetAuthor(id)
+}
+
+func (r trackedAuthors) GetAllAuthors() ([]*bookModel.Author, error) {
+	r.touch()
+	return r.AuthorRepository.GetAllAuthors()
+}
+
+type trackedSubjects struct {
+	bookModel.SubjectRepository
+	*tracker
+}
+
+func (r trackedSubjects) FindOrCreateSubject(subject *bookModel.Subject) error {
+	r.touch()
+	return r.SubjectRepository.FindOrCreateSubject(subject)
+}
+
+func (r trackedSubjects) GetSubject(id int64) (*bookModel.Subject, error) {
+	r.touch()
+	return r.SubjectRepository.GetSubject(id)
+}
+
+func (r trackedSubjects) GetAllSubjects() ([]*bookModel.Subject, error) {
+	r.touch()
+	return r.SubjectRepository.GetAllSubjects()
+}
+
+type trackedCopies struct {
+	copyModel.CopyRepository
+	*tracker
+}
+
+func (r trackedCopies) CreateCopy(copy *copyModel.Copy) error {
+	r.touch()
+	return r.CopyRepository.CreateCopy(copy)
+}
+
+func (r trackedCopies) GetCopy(id int64) (*copyModel.Copy, error) {
+	r.touch()
+	return r.CopyRepository.GetCopy(id)
+}
+
+func (r trackedCopies) GetCopyByBarcode(barcode string) (*copyModel.Copy, error) {
+	r.touch()
+	return r.CopyRepository.GetCopyByBarcode(barcode)
+}
+
+func (r trackedCopies) GetBookCopies(bookID int64) ([]*copyModel.Copy, error) {
+	r.touch()
+	return r.CopyRepository.GetBookCopies(bookID)
+}
+
+func (r trackedCopies) GetAllCopies() ([]*copyModel.Copy, error) {
+	r.touch()
+	return r.CopyRepository.GetAllCopies()
+}
+
+func (r trackedCopies) UpdateCopy(id int64, copy *copyModel.Copy) error {
+	r.touch()
+	return r.CopyRepository.UpdateCopy(id, copy)
+}
+
+func (r trackedCopies) DeleteCopy(id int64) error {
+	r.touch()
+	return r.CopyRepository.DeleteCopy(id)
+}
+
+func (r trackedCopies) CountAvailableCopies(bookID int64) (int, error) {
+	r.touch()
+	return r.CopyRepository.CountAvailableCopies(bookID)
+}
+
+func (r trackedCopies) ReserveCopy(id int64) error {
+	r.touch()
+	return r.CopyRepository.ReserveCopy(id)
+}
+
+func (r trackedCopies) ReserveAvailableCopy(bookID int64) (*copyModel.Copy, error) {
+	r.touch()
+	return r.CopyRepository.ReserveAvailableCopy(bookID)
+}
+
+func (r trackedCopies) ReleaseCopy(id int64) error {
+	r.touch()
+	return r.CopyRepository.ReleaseCopy(id)
+}
+
+func (r trackedCopies) TransitionCopy(id int64, from, to string) error {
+	r.touch()
+	return r.CopyRepository.TransitionCopy(id, from, to)
+}
+
+type trackedUsers struct {
+	userModel.UserRepository
+	*tracker
+}
+
+func (r trackedUsers) CreateUser(user *userModel.User) error {
+	r.touch()
+	return r.UserRepository.CreateUser(user)
+}
+
+func (r trackedUsers) GetUser(id int64) (*userModel.User, error) {
+	r.touch()
+	return r.UserRepository.GetUser(id)
+}
+
+func (r trackedUsers) GetUsersByEmail(email string) ([]*userModel.User, error) {
+	r.touch()
+	return r.UserRepository.GetUsersByEmail(email)
+}
+
+func (r trackedUsers) GetUserByCardNumber(cardNumber string) (*userModel.User, error) {
+	r.touch()
+	return r.UserRepository.GetUserByCardNumber(cardNumber)
+}
+
+func (r trackedUsers) GetAllUsers() ([]*userModel.User, error) {
+	r.touch()
+	return r.UserRepository.GetAllUsers()
+}
+
+func (r trackedUsers) ListUsers(filter userModel.UserFilter, page paging.Request) (*paging.Page[*userModel.User], error) {
+	r.touch()
+	return r.UserRepository.ListUsers(filter, page)
+}
+
+func (r trackedUsers) UpdateUser(id int64, user *userModel.User) error {
+	r.touch()
+	return r.UserRepository.UpdateUser(id, user)
+}
+
+func (r trackedUsers) DeleteUser(id int64) error {
+	r.touch()
+	return r.UserRepository.DeleteUser(id)
+}
+
+type trackedLoans struct {
+	loanModel.LoanRepository
+	*tracker
+}
+
+func (r trackedLoans) CreateLoan(loan *loanModel.Loan) error {
+	r.touch()
+	return r.LoanRepository.CreateLoan(loan)
+}
+
+func (r trackedLoans) UpdateLoan(loan *loanModel.Loan) error {
+	r.touch()
+	return r.LoanRepository.UpdateLoan(loan)
+}
+
+func (r trackedLoans) ReturnBook(loan *loanModel.Loan) error {
+	r.touch()
+	return r.LoanRepository.ReturnBook(loan)
+}
+
+func (r trackedLoans) GetLoan(id int64) (*loanModel.Loan, error) {
+	r.touch()
+	return r.LoanRepository.GetLoan(id)
+}
+
+func (r trackedLoans) GetActiveUserLoans(userId int64) ([]*loanModel.Loan, error) {
+	r.touch()
+	return r.LoanRepository.GetActiveUserLoans(userId)
+}
+
+func (r trackedLoans) GetActiveLoans() ([]*loanModel.Loan, error) {
+	r.touch()
+	return r.LoanRepository.GetActiveLoans()
+}
+
+func (r trackedLoans) GetAllLoans() ([]*loanModel.Loan, error) {
+	r.touch()
+	return r.LoanRepository.GetAllLoans()
+}
+
+func (r trackedLoans) MoveBookLoans(fromBookID, toBookID int64) error {
+	r.touch()
+	return r.LoanRepository.MoveBookLoans(fromBookID, toBookID)
+}
+
+func (r trackedLoans) ListLoans(filter loanModel.LoanFilter, page paging.Request) (*paging.Page[*loanModel.Loan], error) {
+	r.touch()
+	return r.LoanRepository.ListLoans(filter, page)
+}
+
+type trackedPolicies struct {
+	policyModel.LoanPolicyRepository
+	*tracker
+}
+
+func (r trackedPolicies) CreatePolicy(policy *policyModel.LoanPolicy) error {
+	r.touch()
+	return r.LoanPolicyRepository.CreatePolicy(policy)
+}
+
+func (r trackedPolicies) GetPolicy(id int64) (*policyModel.LoanPolicy, error) {
+	r.touch()
+	return r.LoanPolicyRepository.GetPolicy(id)
+}
+
+func (r trackedPolicies) GetPolicyByCode(code string) (*policyModel.LoanPolicy, error) {
+	r.touch()
+	return r.LoanPolicyRepository.GetPolicyByCode(code)
+}
+
+func (r trackedPolicies) GetAllPolicies() ([]*policyModel.LoanPolicy, error) {
+	r.touch()
+	return r.LoanPolicyRepository.GetAllPolicies()
+}
+
+func (r trackedPolicies) UpdatePolicy(id int64, policy *policyModel.LoanPolicy) error {
+	r.touch()
+	return r.LoanPolicyRepository.UpdatePolicy(id, policy)
+}
+
+func (r trackedPolicies) DeletePolicy(id int64) error {
+	r.touch()
+	return r.LoanPolicyRepository.DeletePolicy(id)
+}
+
+type trackedHolds struct {
+	holdModel.HoldRepository
+	*tracker
+}
+
+func (r trackedHolds) CreateHold(hold *holdModel.Hold) error {
+	r.touch()
+	return r.HoldRepository.CreateHold(hold)
+}
+
+func (r trackedHolds) GetHold(id int64) (*holdModel.Hold, error) {
+	r.touch()
+	return r.HoldRepository.GetHold(id)
+}
+
+func (r trackedHolds) UpdateHold(hold *holdModel.Hold) error {
+	r.touch()
+	return r.HoldRepository.UpdateHold(hold)
+}
+
+func (r trackedHolds) GetAllHolds() ([]*holdModel.Hold, error) {
+	r.touch()
+	return r.HoldRepository.GetAllHolds()
+}
+
+func (r trackedHolds) GetBookHolds(bookID int64) ([]*holdModel.Hold, error) {
+	r.touch()
+	return r.HoldRepository.GetBookHolds(bookID)
+}
+
+func (r trackedHolds) GetUserHolds(userID int64) ([]*holdModel.Hold, error) {
+	r.touch()
+	return r.HoldRepository.GetUserHolds(userID)
+}
+
+func (r trackedHolds) GetExpiredHolds(now time.Time) ([]*holdModel.Hold, error) {
+	r.touch()
+	return r.HoldRepository.GetExpiredHolds(now)
+}
+
+func (r trackedHolds) MoveBookHolds(fromBookID, toBookID int64) error {
+	r.touch()
+	return r.HoldRepository.MoveBookHolds(fromBookID, toBookID)
+}
+
+type trackedTransactions struct {
+	accountModel.TransactionRepository
+	*tracker
+}
+
+func (r trackedTransactions) CreateTransaction(transaction *accountModel.Transaction) error {
+	r.touch()
+	return r.TransactionRepository.CreateTransaction(transaction)
+}
+
+func (r trackedTransactions) GetUserTransactions(userID int64) ([]*accountModel.Transaction, error) {
+	r.touch()
+	return r.TransactionRepository.GetUserTransactions(userID)
+}
+
+func (r trackedTransactions) GetUserBalance(userID int64) (money.Money, error) {
+	r.touch()
+	return r.TransactionRepository.GetUserBalance(userID)
+}
+
+type trackedCalendar struct {
+	calendarModel.CalendarRepository
+	*tracker
+}
+
+func (r trackedCalendar) GetOpeningHours() ([]*calendarModel.OpeningHours, error) {
+	r.touch()
+	return r.CalendarRepository.GetOpeningHours()
+}
+
+func (r trackedCalendar) SaveOpeningHours(hours *calendarModel.OpeningHours) error {
+	r.touch()
+	return r.CalendarRepository.SaveOpeningHours(hours)
+}
+
+func (r trackedCalendar) CreateClosure(closure *calendarModel.Closure) error {
+	r.touch()
+	return r.CalendarRepository.CreateClosure(closure)
+}
+
+func (r trackedCalendar) DeleteClosure(id int64) error {
+	r.touch()
+	return r.CalendarRepository.DeleteClosure(id)
+}
+
+func (r trackedCalendar) GetClosures() ([]*calendarModel.Closure, error) {
+	r.touch()
+	return r.CalendarRepository.GetClosures()
+}
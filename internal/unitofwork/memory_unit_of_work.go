@@ -0,0 +1,55 @@
+package unitofwork
+
+import "sync"
+
+// snapshotter is implemented by the in-memory repositories.
+type snapshotter interface {
+	Snapshot() func()
+}
+
+// MemoryUnitOfWork serializes units of work and rolls back by restoring a
+// snapshot of each repository the unit used, taken when it first used it.
+// Writes made outside of Do to one of those repositories while the unit is
+// running may be lost on rollback.
+type MemoryUnitOfWork struct {
+	mu    sync.Mutex
+	repos Repositories
+}
+
+func NewMemoryUnitOfWork(repos Repositories) UnitOfWork {
+	return &MemoryUnitOfWork{repos: repos}
+}
+
+func (u *MemoryUnitOfWork) Do(fn func(repos *Repositories) error) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	repos, trackers := track(u.repos)
+	rollback := func() {
+		for _, t := range trackers {
+			if t.restore != nil {
+				t.restore()
+			}
+		}
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&repos); err != nil {
+		rollback()
+		return err
+	}
+
+	return nil
+}
+
+// Read hands fn the repositories as they are; each one guards itself.
+func (u *MemoryUnitOfWork) Read(fn func(repos *Repositories) error) error {
+	repos := u.repos
+	return fn(&repos)
+}
@@ -0,0 +1,117 @@
+package unitofwork
+
+import (
+	"errors"
+	"testing"
+
+	accountRepository "librarymvc/internal/accounts/repositories"
+	bookRepository "librarymvc/internal/books/repositories"
+	calendarRepository "librarymvc/internal/calendar/repositories"
+	"librarymvc/internal/clock"
+	copyRepository "librarymvc/internal/copies/repositories"
+	holdRepository "librarymvc/internal/holds/repositories"
+	loanModel "librarymvc/internal/loans/models"
+	loanRepository "librarymvc/internal/loans/repositories"
+	policyRepository "librarymvc/internal/policies/repositories"
+	userModel "librarymvc/internal/users/models"
+	userRepository "librarymvc/internal/users/repositories"
+)
+
+var errAbort = errors.New("abort")
+
+func memoryRepositories() Repositories {
+	return Repositories{
+		Books:        bookRepository.NewBookRepository(),
+		Authors:      bookRepository.NewAuthorRepository(),
+		Subjects:     bookRepository.NewSubjectRepository(),
+		Copies:       copyRepository.NewCopyRepository(clock.New()),
+		Users:        userRepository.NewUserRepository(),
+		Loans:        loanRepository.NewLoanRepository(),
+		Policies:     policyRepository.NewLoanPolicyRepository(),
+		Holds:        holdRepository.NewHoldRepository(),
+		Transactions: accountRepository.NewTransactionRepository(),
+		Calendar:     calendarRepository.NewCalendarRepository(),
+	}
+}
+
+func TestMemoryUnitOfWorkRollsBackWrites(t *testing.T) {
+	repos := memoryRepositories()
+	uow := NewMemoryUnitOfWork(repos)
+
+	err := uow.Do(func(tx *Repositories) error {
+		if err := tx.Users.CreateUser(&userModel.User{Name: "Leitora Teste", Email: "l@lib.org"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Do() = %v, want %v", err, errAbort)
+	}
+
+	users, _ := repos.Users.GetAllUsers()
+	if len(users) != 0 {
+		t.Errorf("users after rollback = %d, want 0", len(users))
+	}
+}
+
+func TestMemoryUnitOfWorkRollsBackChangesToReadRecords(t *testing.T) {
+	repos := memoryRepositories()
+	loan := &loanModel.Loan{BookID: 1, UserID: 1, Status: "active"}
+	repos.Loans.CreateLoan(loan)
+	uow := NewMemoryUnitOfWork(repos)
+
+	uow.Do(func(tx *Repositories) error {
+		stored, err := tx.Loans.GetLoan(loan.ID)
+		if err != nil {
+			return err
+		}
+		stored.Status = "returned"
+		if err := tx.Loans.UpdateLoan(stored); err != nil {
+			return err
+		}
+		return errAbort
+	})
+
+	stored, _ := repos.Loans.GetLoan(loan.ID)
+	if stored.Status != "active" {
+		t.Errorf("status after rollback = %q, want active", stored.Status)
+	}
+}
+
+func TestMemoryUnitOfWorkKeepsUntouchedRepositories(t *testing.T) {
+	repos := memoryRepositories()
+	uow := NewMemoryUnitOfWork(repos)
+
+	uow.Do(func(tx *Repositories) error {
+		// A write made outside the unit to a repository it never used
+		repos.Users.CreateUser(&userModel.User{Name: "Leitora Teste", Email: "l@lib.org"})
+		if err := tx.Loans.CreateLoan(&loanModel.Loan{BookID: 1, UserID: 1, Status: "active"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+
+	users, _ := repos.Users.GetAllUsers()
+	if len(users) != 1 {
+		t.Errorf("users after rollback = %d, want 1", len(users))
+	}
+	loans, _ := repos.Loans.GetAllLoans()
+	if len(loans) != 0 {
+		t.Errorf("loans after rollback = %d, want 0", len(loans))
+	}
+}
+
+func TestMemoryUnitOfWorkReadCopiesNothing(t *testing.T) {
+	repos := memoryRepositories()
+	uow := NewMemoryUnitOfWork(repos)
+
+	err := uow.Read(func(tx *Repositories) error {
+		if _, ok := tx.Users.(trackedUsers); ok {
+			t.Error("Read() handed out a tracked repository")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+}
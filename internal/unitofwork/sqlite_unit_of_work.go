@@ -0,0 +1,66 @@
+package unitofwork
+
+import (
+	"database/sql"
+
+	accountRepository "librarymvc/internal/accounts/repositories"
+	bookRepository "librarymvc/internal/books/repositories"
+	calendarRepository "librarymvc/internal/calendar/repositories"
+	"librarymvc/internal/clock"
+	copyRepository "librarymvc/internal/copies/repositories"
+	"librarymvc/internal/database"
+	holdRepository "librarymvc/internal/holds/repositories"
+	loanRepository "librarymvc/internal/loans/repositories"
+	policyRepository "librarymvc/internal/policies/repositories"
+	userRepository "librarymvc/internal/users/repositories"
+)
+
+type SQLiteUnitOfWork struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+func NewSQLiteUnitOfWork(db *sql.DB, clock clock.Clock) UnitOfWork {
+	return &SQLiteUnitOfWork{db: db, clock: clock}
+}
+
+func (u *SQLiteUnitOfWork) Do(fn func(repos *Repositories) error) error {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(u.repositories(tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Read runs fn outside of a transaction, for lookups that change nothing.
+func (u *SQLiteUnitOfWork) Read(fn func(repos *Repositories) error) error {
+	return fn(u.repositories(u.db))
+}
+
+func (u *SQLiteUnitOfWork) repositories(db database.DBTX) *Repositories {
+	return &Repositories{
+		Books:        bookRepository.NewSQLiteBookRepository(db),
+		Authors:      bookRepository.NewSQLiteAuthorRepository(db),
+		Subjects:     bookRepository.NewSQLiteSubjectRepository(db),
+		Copies:       copyRepository.NewSQLiteCopyRepository(db, u.clock),
+		Users:        userRepository.NewSQLiteUserRepository(db),
+		Loans:        loanRepository.NewSQLiteLoanRepository(db),
+		Policies:     policyRepository.NewSQLiteLoanPolicyRepository(db),
+		Holds:        holdRepository.NewSQLiteHoldRepository(db),
+		Transactions: accountRepository.NewSQLiteTransactionRepository(db),
+		Calendar:     calendarRepository.NewSQLiteCalendarRepository(db),
+	}
+}
@@ -0,0 +1,35 @@
+package unitofwork
+
+import (
+	accountModel "librarymvc/internal/accounts/models"
+	bookModel "librarymvc/internal/books/models"
+	calendarModel "librarymvc/internal/calendar/models"
+	copyModel "librarymvc/internal/copies/models"
+	holdModel "librarymvc/internal/holds/models"
+	loanModel "librarymvc/internal/loans/models"
+	policyModel "librarymvc/internal/policies/models"
+	userModel "librarymvc/internal/users/models"
+)
+
+// Repositories groups the repositories that take part in a unit of work.
+// Inside Do they are bound to the running transaction.
+type Repositories struct {
+	Books        bookModel.BookRepository
+	Authors      bookModel.AuthorRepository
+	Subjects     bookModel.SubjectRepository
+	Copies       copyModel.CopyRepository
+	Users        userModel.UserRepository
+	Loans        loanModel.LoanRepository
+	Policies     policyModel.LoanPolicyRepository
+	Holds        holdModel.HoldRepository
+	Transactions accountModel.TransactionRepository
+	Calendar     calendarModel.CalendarRepository
+}
+
+// UnitOfWork runs fn atomically: if fn returns an error (or panics) every
+// change made through repos is discarded, otherwise all of them are kept.
+type UnitOfWork interface {
+	Do(fn func(repos *Repositories) error) error
+	// Read runs fn without a transaction, for lookups that change nothing.
+	Read(fn func(repos *Repositories) error) error
+}
@@ -1,9 +1,15 @@
 package users
 
 import (
+	"librarymvc/internal/apperror"
+	auth "librarymvc/internal/auth/controllers"
+	authModel "librarymvc/internal/auth/models"
+	"librarymvc/internal/paging"
 	"librarymvc/internal/users/models"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"github.com/gin-gonic/gin"
 )
 
@@ -30,13 +36,22 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 	var user models.User
 
 	if err := ctx.ShouldBindJSON(&user); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		ctx.Error(apperror.InvalidBody(err))
 		return
 	}
 
+	// Only those who manage roles can create staff
+	if user.Role != "" && user.Role != models.RolePatron {
+		if err := auth.Check(ctx, authModel.ManageRoles); err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
 	err := c.userService.CreateUser(&user)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
 	}
 
 	ctx.JSON(http.StatusCreated, user)
@@ -45,23 +60,45 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 func (c *UserController) GetUser(ctx *gin.Context) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		ctx.Error(apperror.InvalidID("user"))
 		return
 	}
 
 	user, err := c.userService.GetUser(id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
 	}
 
 	ctx.JSON(http.StatusOK, user)
 }
 
+// GetAllUsers lists the users a page at a time. Besides page, limit and
+// sort it filters by q (name, email or card number), status and expiresBy
+// (memberships expiring on or before the date, as YYYY-MM-DD).
 func (c *UserController) GetAllUsers(ctx *gin.Context) {
+	page, err := paging.FromQuery(ctx.Request.URL.Query())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	filter := models.UserFilter{
+		Query:  strings.TrimSpace(ctx.Query("q")),
+		Status: ctx.Query("status"),
+	}
+	if value := ctx.Query("expiresBy"); value != "" {
+		filter.ExpiresBy, err = time.Parse(time.DateOnly, value)
+		if err != nil {
+			ctx.Error(models.ErrInvalidDate.WithMessage("expiresBy must be a date as YYYY-MM-DD"))
+			return
+		}
+	}
 
-	users, err := c.userService.GetAllUsers()
+	users, err := c.userService.ListUsers(filter, page)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
 	}
 
 	ctx.JSON(http.StatusOK, users)
@@ -71,33 +108,88 @@ func (c *UserController) GetAllUsers(ctx *gin.Context) {
 func (c *UserController) UpdateUser(ctx *gin.Context) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		ctx.Error(apperror.InvalidID("user"))
 		return
 	}
 
 	var user models.User
+	if err := ctx.ShouldBindJSON(&user); err != nil {
+		ctx.Error(apperror.InvalidBody(err))
+		return
+	}
+
+	// Only those who manage roles can change a user's role
+	if user.Role != "" {
+		existing, err := c.userService.GetUser(id)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		if user.Role != existing.Role {
+			if err := auth.Check(ctx, authModel.ManageRoles); err != nil {
+				ctx.Error(err)
+				return
+			}
+		}
+	}
 
 	err = c.userService.UpdateUser(id, &user)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
 	}
-	ctx.JSON(http.StatusOK, nil)
 
+	ctx.JSON(http.StatusOK, user)
 }
 
 func (c *UserController) DeleteUser(ctx *gin.Context) {
 
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		ctx.Error(apperror.InvalidID("user"))
 		return
 	}
 
 	err = c.userService.DeleteUser(id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// RenewMembership extends the user's membership by the length of their
+// category.
+func (c *UserController) RenewMembership(ctx *gin.Context) {
+	c.changeMembership(ctx, c.userService.RenewMembership)
+}
+
+func (c *UserController) SuspendMembership(ctx *gin.Context) {
+	c.changeMembership(ctx, c.userService.SuspendMembership)
+}
+
+func (c *UserController) ReinstateMembership(ctx *gin.Context) {
+	c.changeMembership(ctx, c.userService.ReinstateMembership)
+}
+
+func (c *UserController) changeMembership(ctx *gin.Context, change func(id int64) (*models.User, error)) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.Error(apperror.InvalidID("user"))
+		return
 	}
 
-	ctx.JSON(http.StatusOK, nil)
+	user, err := change(id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
 
+// GetCategories lists the member categories.
+func (c *UserController) GetCategories(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.Categories)
 }
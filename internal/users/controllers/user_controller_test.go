@@ -0,0 +1,254 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"librarymvc/internal/apperror"
+	auth "librarymvc/internal/auth/controllers"
+	"librarymvc/internal/testenv"
+	"librarymvc/internal/users/models"
+	"librarymvc/internal/users/services"
+)
+
+func newRouter(storage *testenv.Storage) (*gin.Engine, models.UserService) {
+	gin.SetMode(gin.TestMode)
+	userService := services.NewUserService(storage.Repos.Users, storage.Clock)
+	controller := NewUserController(userService)
+
+	router := gin.New()
+	router.Use(apperror.Middleware())
+	router.GET("/api/users/:id", controller.GetUser)
+	router.PUT("/api/users/:id", controller.UpdateUser)
+	router.DELETE("/api/users/:id", controller.DeleteUser)
+	return router, userService
+}
+
+func serve(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return recorder
+}
+
+func createUser(t *testing.T, userService models.UserService) *models.User {
+	t.Helper()
+	user := &models.User{Name: "Leitora de Teste", Email: "leitora@lib.org"}
+	if err := userService.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser() = %v", err)
+	}
+	return user
+}
+
+// decode fails the test unless the body is exactly one JSON value, so an
+// error followed by a second response shows up.
+func decode(t *testing.T, recorder *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	decoder := json.NewDecoder(recorder.Body)
+	if err := decoder.Decode(v); err != nil {
+		t.Fatalf("decoding %q: %v", recorder.Body.String(), err)
+	}
+	if decoder.More() {
+		t.Errorf("body has more than one value: %q", recorder.Body.String())
+	}
+}
+
+func TestUserErrorsStopTheHandler(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		router, _ := newRouter(storage)
+
+		tests := []struct {
+			method string
+			body   string
+			status int
+		}{
+			{http.MethodGet, "", http.StatusNotFound},
+			{http.MethodPut, `{"name": "Leitora de Teste", "email": "leitora@lib.org"}`, http.StatusNotFound},
+			{http.MethodDelete, "", http.StatusNotFound},
+		}
+		for _, test := range tests {
+			recorder := serve(router, test.method, "/api/users/999", test.body)
+			if recorder.Code != test.status {
+				t.Errorf("%s status = %d, want %d", test.method, recorder.Code, test.status)
+			}
+			var body map[string]any
+			decode(t, recorder, &body)
+		}
+	})
+}
+
+func TestUpdateUser(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		router, userService := newRouter(storage)
+		user := createUser(t, userService)
+		path := userPath(user)
+
+		recorder := serve(router, http.MethodPut, path, `{"name": "Leitora Renomeada", "email": "renomeada@lib.org", "maxLoans": 5}`)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", recorder.Code, recorder.Body)
+		}
+		var updated models.User
+		decode(t, recorder, &updated)
+		if updated.ID != user.ID || updated.Name != "Leitora Renomeada" || updated.MaxLoans != 5 {
+			t.Errorf("response = %+v, want the renamed user", updated)
+		}
+
+		stored, err := userService.GetUser(user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Name != "Leitora Renomeada" || stored.Email != "renomeada@lib.org" || stored.MaxLoans != 5 {
+			t.Errorf("stored = %+v, want the renamed user", stored)
+		}
+	})
+}
+
+func TestUpdateUserRejectsABadBody(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		router, userService := newRouter(storage)
+		user := createUser(t, userService)
+		path := userPath(user)
+
+		tests := []struct {
+			body   string
+			status int
+		}{
+			{``, http.StatusBadRequest},
+			{`{"name": `, http.StatusBadRequest},
+			{`{}`, http.StatusUnprocessableEntity},
+			{`{"name": "", "email": "leitora@lib.org"}`, http.StatusUnprocessableEntity},
+			{`{"name": "Leitora de Teste"}`, http.StatusUnprocessableEntity},
+		}
+		for _, test := range tests {
+			recorder := serve(router, http.MethodPut, path, test.body)
+			if recorder.Code != test.status {
+				t.Errorf("PUT %q status = %d, want %d", test.body, recorder.Code, test.status)
+			}
+		}
+
+		stored, err := userService.GetUser(user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Name != user.Name || stored.Email != user.Email {
+			t.Errorf("stored = %+v, want it unchanged", stored)
+		}
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		router, userService := newRouter(storage)
+		user := createUser(t, userService)
+
+		recorder := serve(router, http.MethodDelete, userPath(user), "")
+		if recorder.Code != http.StatusNoContent || recorder.Body.Len() != 0 {
+			t.Errorf("response = %d %q, want 204 and no body", recorder.Code, recorder.Body)
+		}
+		if _, err := userService.GetUser(user.ID); err == nil {
+			t.Error("user still there after delete")
+		}
+	})
+}
+
+func userPath(user *models.User) string {
+	return "/api/users/" + strconv.FormatInt(user.ID, 10)
+}
+
+func TestChangingARoleNeedsManageRoles(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		userService := services.NewUserService(storage.Repos.Users, storage.Clock)
+		controller := NewUserController(userService)
+		user := createUser(t, userService)
+
+		tests := []struct {
+			name   string
+			actor  string
+			body   string
+			status int
+			role   string
+		}{
+			{"librarian promoting", models.RoleLibrarian, `{"name": "Leitora de Teste", "email": "leitora@lib.org", "role": "librarian"}`, http.StatusForbidden, models.RolePatron},
+			{"librarian keeping the role", models.RoleLibrarian, `{"name": "Leitora de Teste", "email": "leitora@lib.org", "role": "patron"}`, http.StatusOK, models.RolePatron},
+			{"librarian leaving the role out", models.RoleLibrarian, `{"name": "Leitora de Teste", "email": "leitora@lib.org"}`, http.StatusOK, models.RolePatron},
+			{"admin promoting", models.RoleAdmin, `{"name": "Leitora de Teste", "email": "leitora@lib.org", "role": "librarian"}`, http.StatusOK, models.RoleLibrarian},
+		}
+		for _, test := range tests {
+			router := gin.New()
+			router.Use(apperror.Middleware(), func(ctx *gin.Context) {
+				auth.SetCurrentUser(ctx, &models.User{ID: user.ID + 1, Role: test.actor})
+			})
+			router.PUT("/api/users/:id", controller.UpdateUser)
+
+			recorder := serve(router, http.MethodPut, userPath(user), test.body)
+			if recorder.Code != test.status {
+				t.Errorf("%s: status = %d, want %d", test.name, recorder.Code, test.status)
+			}
+			stored, err := userService.GetUser(user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Role != test.role {
+				t.Errorf("%s: role = %q, want %q", test.name, stored.Role, test.role)
+			}
+		}
+	})
+}
+
+func TestUpdateUserSetsCategoryAndExpiry(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		storage.Clock.Set(time.Date(2026, time.March, 10, 10, 0, 0, 0, time.UTC))
+		router, userService := newRouter(storage)
+		user := createUser(t, userService)
+
+		tests := []struct {
+			name      string
+			category  string
+			expiresAt time.Time
+			status    string
+		}{
+			{"a student until the end of the year", "student", time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC), models.StatusActive},
+			{"faculty with a lapsed membership", "faculty", time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC), models.StatusExpired},
+			{"renewed by hand", "faculty", time.Date(2027, time.March, 9, 0, 0, 0, 0, time.UTC), models.StatusActive},
+		}
+		for _, test := range tests {
+			body := fmt.Sprintf(`{"name": %q, "email": %q, "category": %q, "expiresAt": %q}`,
+				user.Name, user.Email, test.category, test.expiresAt.Format(time.RFC3339))
+			recorder := serve(router, http.MethodPut, userPath(user), body)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("%s: PUT status = %d, want 200: %s", test.name, recorder.Code, recorder.Body)
+			}
+
+			recorder = serve(router, http.MethodGet, userPath(user), "")
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("%s: GET status = %d, want 200: %s", test.name, recorder.Code, recorder.Body)
+			}
+			var got models.User
+			decode(t, recorder, &got)
+			if got.Category != test.category || !got.ExpiresAt.Equal(test.expiresAt) || got.Status != test.status {
+				t.Errorf("%s: category %q, expires %v, status %q; want %q, %v, %q",
+					test.name, got.Category, got.ExpiresAt, got.Status, test.category, test.expiresAt, test.status)
+			}
+		}
+
+		// An unknown category is refused and leaves the user alone
+		recorder := serve(router, http.MethodPut, userPath(user), `{"name": "Leitora de Teste", "email": "leitora@lib.org", "category": "visitor"}`)
+		if recorder.Code != http.StatusUnprocessableEntity {
+			t.Errorf("unknown category: status = %d, want 422", recorder.Code)
+		}
+		stored, err := userService.GetUser(user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Category != "faculty" {
+			t.Errorf("category after refused update = %q, want faculty", stored.Category)
+		}
+	})
+}
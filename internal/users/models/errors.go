@@ -0,0 +1,16 @@
+package models
+
+import "librarymvc/internal/apperror"
+
+var (
+	ErrUserNotFound      = apperror.NewNotFound("user_not_found", "user not found")
+	ErrNameRequired      = apperror.NewValidation("name_required", "name is required")
+	ErrEmailRequired     = apperror.NewValidation("email_required", "email is required")
+	ErrNegativeLoanLimit = apperror.NewValidation("negative_max_loans", "max loans cannot be negative")
+	ErrInvalidRole       = apperror.NewValidation("invalid_role", "role must be patron, librarian or admin")
+	ErrInvalidCategory   = apperror.NewValidation("invalid_category", "category must be community, student, faculty or staff")
+	ErrInvalidStatus     = apperror.NewValidation("invalid_membership_status", "status must be active, suspended or expired")
+	ErrCardNumberTaken   = apperror.NewConflict("card_number_taken", "card number belongs to another user")
+	ErrNotSuspended      = apperror.NewConflict("membership_not_suspended", "membership is not suspended")
+	ErrInvalidDate       = apperror.NewBadRequest("invalid_date", "dates must be given as YYYY-MM-DD")
+)
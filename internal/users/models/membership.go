@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Membership statuses. Only staff suspend and reinstate members; an active
+// membership becomes expired once its expiry date has passed.
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusExpired   = "expired"
+)
+
+// ValidStatus reports whether status is a membership status.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusSuspended, StatusExpired:
+		return true
+	}
+	return false
+}
+
+// Category is a kind of member. It decides how many books they may have on
+// loan and how long their membership lasts before it must be renewed.
+type Category struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	LoanLimit int    `json:"loanLimit"` // 0 usa o padrão da biblioteca
+	Months    int    `json:"months"`    // duração da inscrição e de cada renovação
+}
+
+// DefaultCategory is given to members registered without one.
+const DefaultCategory = "community"
+
+// Categories lists the member categories.
+var Categories = []Category{
+	{Code: "community", Name: "Comunidade", LoanLimit: 0, Months: 12},
+	{Code: "student", Name: "Estudante", LoanLimit: 5, Months: 12},
+	{Code: "faculty", Name: "Docente", LoanLimit: 10, Months: 24},
+	{Code: "staff", Name: "Funcionário", LoanLimit: 5, Months: 24},
+}
+
+// CategoryByCode finds the category with the code.
+func CategoryByCode(code string) (Category, bool) {
+	for _, category := range Categories {
+		if category.Code == code {
+			return category, true
+		}
+	}
+	return Category{}, false
+}
+
+// MemberCategory is the user's category, or the default one when the code
+// is unknown.
+func (u *User) MemberCategory() Category {
+	if category, ok := CategoryByCode(u.Category); ok {
+		return category
+	}
+	category, _ := CategoryByCode(DefaultCategory)
+	return category
+}
+
+// MembershipStatus is the status at now, counting an active membership
+// as expired from the day after its expiry date, even before that is
+// recorded.
+func (u *User) MembershipStatus(now time.Time) string {
+	if u.Status == StatusActive && !u.ExpiresAt.IsZero() &&
+		now.Format(time.DateOnly) > u.ExpiresAt.Format(time.DateOnly) {
+		return StatusExpired
+	}
+	return u.Status
+}
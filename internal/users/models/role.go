@@ -0,0 +1,21 @@
+package models
+
+// Roles a user can have. Patrons borrow; librarians run the desk and the
+// catalog; administrators also delete records and configure the library.
+const (
+	RolePatron    = "patron"
+	RoleLibrarian = "librarian"
+	RoleAdmin     = "admin"
+)
+
+// Roles lists the roles from least to most privileged.
+var Roles = []string{RolePatron, RoleLibrarian, RoleAdmin}
+
+// ValidRole reports whether role is one of Roles.
+func ValidRole(role string) bool {
+	switch role {
+	case RolePatron, RoleLibrarian, RoleAdmin:
+		return true
+	}
+	return false
+}
@@ -4,8 +4,25 @@ import "time"
 
 type User struct {
 	ID		int64     `json:"ID"`
-	Name	string    `json:"name"  binding:"requiredn min=10, max=200"`
-	Email	string    `json:"email" binding:"required.email"`
+	Name	string    `json:"name"  binding:"required,min=10,max=200"`
+	Email	string    `json:"email" binding:"required,email"`
+	MaxLoans	int       `json:"maxLoans"` // Limite de empréstimos simultâneos; 0 usa o padrão da biblioteca
+	Role	string    `json:"role"`     // patron, librarian ou admin; vazio na alteração mantém o atual
+	CardNumber	string    `json:"cardNumber"`  // Número da carteirinha; gerado a partir do ID se vazio
+	Category	string    `json:"category"`    // community, student, faculty ou staff
+	Status	string    `json:"status"`      // active, suspended ou expired
+	MemberSince	time.Time `json:"memberSince"` // Início da inscrição
+	ExpiresAt	time.Time `json:"expiresAt"`   // Último dia da inscrição; zero se não vence
 	CreatedAt	time.Time `json:"createdAt"`
 	UpdatedAt	time.Time `json:"updatedAt"`
-}
\ No newline at end of file
+}
+
+// UserFilter narrows a user listing; an empty Query matches every user.
+type UserFilter struct {
+	Query     string    // parte do nome, do email ou do número da carteirinha
+	Status    string    // active, suspended ou expired
+	ExpiresBy time.Time // inscrições que vencem até este dia, inclusive
+}
+
+// UserSortFields are the fields a user listing can be sorted by.
+var UserSortFields = []string{"id", "name", "email", "cardNumber", "expiresAt", "createdAt", "updatedAt"}
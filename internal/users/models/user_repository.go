@@ -1,9 +1,15 @@
 package models
 
+import "librarymvc/internal/paging"
+
 type UserRepository interface {
 	CreateUser(user *User) error
 	GetUser(id int64) (*User, error)
+	// GetUsersByEmail finds the users with the email, ignoring case.
+	GetUsersByEmail(email string) ([]*User, error)
+	GetUserByCardNumber(cardNumber string) (*User, error)
 	GetAllUsers() ([]*User, error)
+	ListUsers(filter UserFilter, page paging.Request) (*paging.Page[*User], error)
 	UpdateUser(id int64, user *User) error
 	DeleteUser(id int64) error
 }
@@ -1,9 +1,18 @@
 package models
 
+import "librarymvc/internal/paging"
+
 type UserService interface {
 	CreateUser(user *User) error
 	GetUser(id int64) (*User, error)
 	GetAllUsers() ([]*User, error)
+	ListUsers(filter UserFilter, page paging.Request) (*paging.Page[*User], error)
 	UpdateUser(id int64, user *User) error
 	DeleteUser(id int64) error
+	RenewMembership(id int64) (*User, error)
+	SuspendMembership(id int64) (*User, error)
+	ReinstateMembership(id int64) (*User, error)
+	// ExpireMemberships records as expired the active memberships whose
+	// expiry date has passed. It returns how many there were.
+	ExpireMemberships() (int, error)
 }
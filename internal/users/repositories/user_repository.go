@@ -1,9 +1,13 @@
 package repositories
 
 import (
-	"errors"
+	"cmp"
+	"librarymvc/internal/paging"
 	"librarymvc/internal/users/models"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 )
 
 type UserRepository struct {
@@ -35,22 +39,77 @@ func (u *UserRepository) GetUser(id int64) (*models.User, error) {
 	defer u.mu.RUnlock()
 	user, exists := u.users[id]
 	if !exists {
-		return nil, errors.New("user not found")
+		return nil, models.ErrUserNotFound
 	}
 
 	return user, nil
 }
 
+func (u *UserRepository) GetUsersByEmail(email string) ([]*models.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return u.filter(func(user *models.User) bool { return strings.EqualFold(user.Email, email) }), nil
+}
+
+func (u *UserRepository) GetUserByCardNumber(cardNumber string) (*models.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	users := u.filter(func(user *models.User) bool { return user.CardNumber == cardNumber })
+	if len(users) == 0 {
+		return nil, models.ErrUserNotFound
+	}
+
+	return users[0], nil
+}
+
 func (u *UserRepository) GetAllUsers() ([]*models.User, error) {
 	u.mu.RLock()
 	defer u.mu.RUnlock()
 
+	return u.filter(func(*models.User) bool { return true }), nil
+}
+
+// filter returns the users matching keep in ID order. The caller must hold
+// the lock.
+func (u *UserRepository) filter(keep func(user *models.User) bool) []*models.User {
 	users := make([]*models.User, 0, len(u.users))
 	for _, user := range u.users {
-		users = append(users, user)
+		if keep(user) {
+			users = append(users, user)
+		}
 	}
 
-	return users, nil
+	slices.SortFunc(users, func(x, y *models.User) int { return cmp.Compare(x.ID, y.ID) })
+	return users
+}
+
+var userSorts = map[string]func(x, y *models.User) int{
+	"name":       func(x, y *models.User) int { return cmp.Compare(strings.ToLower(x.Name), strings.ToLower(y.Name)) },
+	"email":      func(x, y *models.User) int { return cmp.Compare(strings.ToLower(x.Email), strings.ToLower(y.Email)) },
+	"cardNumber": func(x, y *models.User) int { return cmp.Compare(x.CardNumber, y.CardNumber) },
+	"expiresAt":  func(x, y *models.User) int { return x.ExpiresAt.Compare(y.ExpiresAt) },
+	"createdAt":  func(x, y *models.User) int { return x.CreatedAt.Compare(y.CreatedAt) },
+	"updatedAt":  func(x, y *models.User) int { return x.UpdatedAt.Compare(y.UpdatedAt) },
+}
+
+func (u *UserRepository) ListUsers(filter models.UserFilter, page paging.Request) (*paging.Page[*models.User], error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	query := strings.ToLower(filter.Query)
+	expiresBy := filter.ExpiresBy.Format(time.DateOnly)
+	users := u.filter(func(user *models.User) bool {
+		return (query == "" ||
+			strings.Contains(strings.ToLower(user.Name), query) ||
+			strings.Contains(strings.ToLower(user.Email), query) ||
+			strings.Contains(strings.ToLower(user.CardNumber), query)) &&
+			(filter.Status == "" || user.Status == filter.Status) &&
+			(filter.ExpiresBy.IsZero() || !user.ExpiresAt.IsZero() && user.ExpiresAt.Format(time.DateOnly) <= expiresBy)
+	})
+
+	return paging.Slice(users, page, userSorts), nil
 }
 
 func (u *UserRepository) UpdateUser(id int64, user *models.User) error {
@@ -58,7 +117,7 @@ func (u *UserRepository) UpdateUser(id int64, user *models.User) error {
 	defer u.mu.Unlock()
 	existingUser, exists := u.users[id]
 	if !exists {
-		return errors.New("user not found")
+		return models.ErrUserNotFound
 	}
 
 	user.ID = existingUser.ID
@@ -73,9 +132,34 @@ func (u *UserRepository) DeleteUser(id int64) error {
 
 	_, exists := u.users[id]
 	if !exists {
-		return errors.New("user not found")
+		return models.ErrUserNotFound
 	}
 
 	delete(u.users, id)
 	return nil
 }
+
+// Snapshot copies the current state and returns a function that restores it.
+// It is used by the in-memory unit of work to roll back failed transactions.
+func (u *UserRepository) Snapshot() func() {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	users := make(map[int64]models.User, len(u.users))
+	for id, user := range u.users {
+		users[id] = *user
+	}
+	nextID := u.nextID
+
+	return func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+
+		u.users = make(map[int64]*models.User, len(users))
+		for id, user := range users {
+			user := user
+			u.users[id] = &user
+		}
+		u.nextID = nextID
+	}
+}
@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"librarymvc/internal/database"
+	"librarymvc/internal/paging"
+	"librarymvc/internal/users/models"
+	"time"
+)
+
+type SQLiteUserRepository struct {
+	db database.DBTX
+}
+
+func NewSQLiteUserRepository(db database.DBTX) models.UserRepository {
+	return &SQLiteUserRepository{db: db}
+}
+
+const userColumns = `id, name, email, max_loans, role, card_number, category, status, member_since, expires_at, created_at, updated_at`
+
+func scanUser(row interface{ Scan(...any) error }) (*models.User, error) {
+	var (
+		user      models.User
+		expiresAt sql.NullTime
+	)
+	err := row.Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.MaxLoans,
+		&user.Role,
+		&user.CardNumber,
+		&user.Category,
+		&user.Status,
+		&user.MemberSince,
+		&expiresAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	user.ExpiresAt = expiresAt.Time
+	return &user, nil
+}
+
+// nullTime stores the zero time as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func (u *SQLiteUserRepository) CreateUser(user *models.User) error {
+	result, err := u.db.Exec(
+		`INSERT INTO users (name, email, max_loans, role, card_number, category, status, member_since, expires_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		user.Name, user.Email, user.MaxLoans, user.Role, user.CardNumber, user.Category, user.Status,
+		user.MemberSince, nullTime(user.ExpiresAt), user.CreatedAt, user.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	user.ID = id
+
+	return nil
+}
+
+func (u *SQLiteUserRepository) GetUser(id int64) (*models.User, error) {
+	row := u.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = ?`, id)
+
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (u *SQLiteUserRepository) queryUsers(query string, args ...any) ([]*models.User, error) {
+	rows, err := u.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (u *SQLiteUserRepository) GetUsersByEmail(email string) ([]*models.User, error) {
+	return u.queryUsers(`SELECT `+userColumns+` FROM users WHERE email = ? COLLATE NOCASE ORDER BY id`, email)
+}
+
+func (u *SQLiteUserRepository) GetUserByCardNumber(cardNumber string) (*models.User, error) {
+	row := u.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE card_number = ?`, cardNumber)
+
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (u *SQLiteUserRepository) GetAllUsers() ([]*models.User, error) {
+	return u.queryUsers(`SELECT ` + userColumns + ` FROM users ORDER BY id`)
+}
+
+var userSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name COLLATE NOCASE",
+	"email":      "email COLLATE NOCASE",
+	"cardNumber": "card_number",
+	"expiresAt":  "expires_at",
+	"createdAt":  "created_at",
+	"updatedAt":  "updated_at",
+}
+
+func (u *SQLiteUserRepository) ListUsers(filter models.UserFilter, page paging.Request) (*paging.Page[*models.User], error) {
+	var where database.Where
+	if filter.Query != "" {
+		pattern := database.Contains(filter.Query)
+		where.Add(`(name LIKE ? ESCAPE '\' OR email LIKE ? ESCAPE '\' OR card_number LIKE ? ESCAPE '\')`, pattern, pattern, pattern)
+	}
+	if filter.Status != "" {
+		where.Add(`status = ?`, filter.Status)
+	}
+	if !filter.ExpiresBy.IsZero() {
+		where.Add(database.DayOf("expires_at")+` <= ?`, filter.ExpiresBy.Format(time.DateOnly))
+	}
+
+	var total int
+	if err := u.db.QueryRow(`SELECT COUNT(*) FROM users`+where.String(), where.Args()...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	users, err := u.queryUsers(
+		`SELECT `+userColumns+` FROM users`+where.String()+page.OrderBy(userSortColumns)+` LIMIT ? OFFSET ?`,
+		append(where.Args(), page.Limit, page.Offset())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return paging.NewPage(users, total, page), nil
+}
+
+func (u *SQLiteUserRepository) UpdateUser(id int64, user *models.User) error {
+	result, err := u.db.Exec(
+		`UPDATE users SET name = ?, email = ?, max_loans = ?, role = ?, card_number = ?, category = ?, status = ?,
+		member_since = ?, expires_at = ?, updated_at = ? WHERE id = ?`,
+		user.Name, user.Email, user.MaxLoans, user.Role, user.CardNumber, user.Category, user.Status,
+		user.MemberSince, nullTime(user.ExpiresAt), user.UpdatedAt, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	user.ID = id
+	return nil
+}
+
+func (u *SQLiteUserRepository) DeleteUser(id int64) error {
+	result, err := u.db.Exec(`DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
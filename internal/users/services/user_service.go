@@ -1,37 +1,277 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"librarymvc/internal/clock"
+	"librarymvc/internal/paging"
 	"librarymvc/internal/users/models"
+	"strings"
 	"time"
 )
 
 type UserService struct {
 	userRepo models.UserRepository
+	clock    clock.Clock
 }
 
-func NewUserService(userRepo models.UserRepository) models.UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(userRepo models.UserRepository, clock clock.Clock) models.UserService {
+	return &UserService{userRepo: userRepo, clock: clock}
 }
 
+func validateUser(user *models.User) error {
+	if strings.TrimSpace(user.Name) == "" {
+		return models.ErrNameRequired
+	}
+	if strings.TrimSpace(user.Email) == "" {
+		return models.ErrEmailRequired
+	}
+	if user.MaxLoans < 0 {
+		return models.ErrNegativeLoanLimit
+	}
+	if !models.ValidRole(user.Role) {
+		return models.ErrInvalidRole
+	}
+	if _, ok := models.CategoryByCode(user.Category); !ok {
+		return models.ErrInvalidCategory
+	}
+	if !models.ValidStatus(user.Status) {
+		return models.ErrInvalidStatus
+	}
+	return nil
+}
+
+// checkCardNumber fails with ErrCardNumberTaken when another user has the
+// card number.
+func (u UserService) checkCardNumber(id int64, cardNumber string) error {
+	if cardNumber == "" {
+		return nil
+	}
+	owner, err := u.userRepo.GetUserByCardNumber(cardNumber)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if owner.ID != id {
+		return models.ErrCardNumberTaken
+	}
+	return nil
+}
+
+// CreateUser registers the user. The membership starts now unless given,
+// in the default category, and lasts as long as the category says; without
+// a card number the user gets one made from their ID.
 func (u UserService) CreateUser(user *models.User) error {
-	user.CreatedAt = time.Now()
-	user.UpdatedAt = time.Now()
-	return u.userRepo.CreateUser(user)
+	now := u.clock.Now()
+	if user.Role == "" {
+		user.Role = models.RolePatron
+	}
+	if user.Category == "" {
+		user.Category = models.DefaultCategory
+	}
+	if user.Status == "" {
+		user.Status = models.StatusActive
+	}
+	if err := validateUser(user); err != nil {
+		return err
+	}
+	if err := u.checkCardNumber(0, user.CardNumber); err != nil {
+		return err
+	}
+	if user.MemberSince.IsZero() {
+		user.MemberSince = now
+	}
+	if user.ExpiresAt.IsZero() {
+		user.ExpiresAt = user.MemberSince.AddDate(0, user.MemberCategory().Months, 0)
+	}
+	user.Status = user.MembershipStatus(now)
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	if err := u.userRepo.CreateUser(user); err != nil {
+		return err
+	}
+
+	if user.CardNumber == "" {
+		user.CardNumber = fmt.Sprintf("%06d", user.ID)
+		return u.userRepo.UpdateUser(user.ID, user)
+	}
+	return nil
+}
+
+// current shows the membership of a user as it is now, expired once its
+// expiry date has passed. It returns a copy, leaving what's stored alone.
+func (u UserService) current(user *models.User) *models.User {
+	shown := *user
+	shown.Status = user.MembershipStatus(u.clock.Now())
+	return &shown
 }
 
 func (u UserService) GetUser(id int64) (*models.User, error) {
-	return u.userRepo.GetUser(id)
+	user, err := u.userRepo.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+	return u.current(user), nil
 }
 
 func (u UserService) GetAllUsers() ([]*models.User, error) {
-	return u.userRepo.GetAllUsers()
+	users, err := u.userRepo.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	for i, user := range users {
+		users[i] = u.current(user)
+	}
+	return users, nil
+}
+
+func (u UserService) ListUsers(filter models.UserFilter, page paging.Request) (*paging.Page[*models.User], error) {
+	if filter.Status != "" && !models.ValidStatus(filter.Status) {
+		return nil, models.ErrInvalidStatus
+	}
+	if err := page.CheckSort(models.UserSortFields); err != nil {
+		return nil, err
+	}
+	users, err := u.userRepo.ListUsers(filter, page.Normalize())
+	if err != nil {
+		return nil, err
+	}
+	for i, user := range users.Items {
+		users.Items[i] = u.current(user)
+	}
+	return users, nil
 }
 
+// UpdateUser saves the user's details. Empty role, membership and card
+// fields keep their current values. The status is saved as of now, so an
+// expired membership given a later expiry date is active again and one
+// given a past date is expired.
 func (u UserService) UpdateUser(id int64, user *models.User) error {
-	user.UpdatedAt = time.Now()
+	existing, err := u.userRepo.GetUser(id)
+	if err != nil {
+		return err
+	}
+	if user.Role == "" {
+		user.Role = existing.Role
+	}
+	if user.CardNumber == "" {
+		user.CardNumber = existing.CardNumber
+	}
+	if user.Category == "" {
+		user.Category = existing.Category
+	}
+	if user.Status == "" {
+		user.Status = existing.Status
+	}
+	if user.MemberSince.IsZero() {
+		user.MemberSince = existing.MemberSince
+	}
+	if user.ExpiresAt.IsZero() {
+		user.ExpiresAt = existing.ExpiresAt
+	}
+	now := u.clock.Now()
+	if user.Status == models.StatusExpired {
+		user.Status = models.StatusActive
+	}
+	user.Status = user.MembershipStatus(now)
+	if err := validateUser(user); err != nil {
+		return err
+	}
+	if err := u.checkCardNumber(id, user.CardNumber); err != nil {
+		return err
+	}
+	user.ID = id
+	user.CreatedAt = existing.CreatedAt
+	user.UpdatedAt = now
 	return u.userRepo.UpdateUser(id, user)
 }
 
+// RenewMembership extends the membership by the length of the member's
+// category, counting from its expiry date or, once lapsed, from today. An
+// expired membership becomes active again; a suspended one stays suspended.
+func (u UserService) RenewMembership(id int64) (*models.User, error) {
+	return u.changeMembership(id, func(user *models.User, now time.Time) error {
+		from := now
+		if user.ExpiresAt.After(now) {
+			from = user.ExpiresAt
+		}
+		user.ExpiresAt = from.AddDate(0, user.MemberCategory().Months, 0)
+		if user.Status == models.StatusExpired {
+			user.Status = models.StatusActive
+		}
+		return nil
+	})
+}
+
+// SuspendMembership stops the member from borrowing until reinstated.
+func (u UserService) SuspendMembership(id int64) (*models.User, error) {
+	return u.changeMembership(id, func(user *models.User, now time.Time) error {
+		user.Status = models.StatusSuspended
+		return nil
+	})
+}
+
+// ReinstateMembership lifts a suspension. A membership that lapsed in the
+// meantime comes back expired.
+func (u UserService) ReinstateMembership(id int64) (*models.User, error) {
+	return u.changeMembership(id, func(user *models.User, now time.Time) error {
+		if user.Status != models.StatusSuspended {
+			return models.ErrNotSuspended
+		}
+		user.Status = models.StatusActive
+		user.Status = user.MembershipStatus(now)
+		return nil
+	})
+}
+
+func (u UserService) changeMembership(id int64, change func(user *models.User, now time.Time) error) (*models.User, error) {
+	user, err := u.userRepo.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := u.clock.Now()
+	changed := *user
+	changed.Status = user.MembershipStatus(now)
+	if err := change(&changed, now); err != nil {
+		return nil, err
+	}
+	changed.UpdatedAt = now
+	if err := u.userRepo.UpdateUser(id, &changed); err != nil {
+		return nil, err
+	}
+
+	return &changed, nil
+}
+
+// ExpireMemberships records as expired the active memberships whose expiry
+// date has passed. It returns how many there were.
+func (u UserService) ExpireMemberships() (int, error) {
+	users, err := u.userRepo.GetAllUsers()
+	if err != nil {
+		return 0, err
+	}
+
+	now := u.clock.Now()
+	expired := 0
+	for _, user := range users {
+		if user.Status != models.StatusActive || user.MembershipStatus(now) != models.StatusExpired {
+			continue
+		}
+		changed := *user
+		changed.Status = models.StatusExpired
+		changed.UpdatedAt = now
+		if err := u.userRepo.UpdateUser(user.ID, &changed); err != nil {
+			return expired, err
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
 func (u UserService) DeleteUser(id int64) error {
 	return u.userRepo.DeleteUser(id)
 }
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"librarymvc/internal/testenv"
+	"librarymvc/internal/users/models"
+)
+
+func TestUsersNeedANameAndEmail(t *testing.T) {
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		users := NewUserService(storage.Repos.Users, storage.Clock)
+		user := &models.User{Name: "Leitora de Teste", Email: "leitora@lib.org"}
+		if err := users.CreateUser(user); err != nil {
+			t.Fatalf("CreateUser() = %v", err)
+		}
+
+		tests := []struct {
+			name  string
+			email string
+			err   error
+		}{
+			{"", "leitora@lib.org", models.ErrNameRequired},
+			{"   ", "leitora@lib.org", models.ErrNameRequired},
+			{"Leitora de Teste", "", models.ErrEmailRequired},
+		}
+		for _, test := range tests {
+			created := &models.User{Name: test.name, Email: test.email}
+			if err := users.CreateUser(created); !errors.Is(err, test.err) {
+				t.Errorf("CreateUser(%q, %q) = %v, want %v", test.name, test.email, err, test.err)
+			}
+			updated := &models.User{Name: test.name, Email: test.email}
+			if err := users.UpdateUser(user.ID, updated); !errors.Is(err, test.err) {
+				t.Errorf("UpdateUser(%q, %q) = %v, want %v", test.name, test.email, err, test.err)
+			}
+		}
+	})
+}
@@ -0,0 +1,322 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	accountModel "librarymvc/internal/accounts/models"
+	auth "librarymvc/internal/auth/controllers"
+	authModel "librarymvc/internal/auth/models"
+	bookModel "librarymvc/internal/books/models"
+	holdModel "librarymvc/internal/holds/models"
+	loanModel "librarymvc/internal/loans/models"
+	"librarymvc/internal/paging"
+	userModel "librarymvc/internal/users/models"
+)
+
+// O portal é a área do leitor: cada usuário logado vê ali os próprios
+// empréstimos, reservas e multas, usando os mesmos serviços da equipe.
+
+// home is where the user lands after logging in: the dashboard for staff,
+// the portal for everyone else.
+func home(user *userModel.User) string {
+	if user != nil && authModel.Can(user.Role, authModel.ViewCirculation) {
+		return "/"
+	}
+	return "/portal"
+}
+
+// renderPortal renders a page of the portal, which has its own layout.
+func (wc *WebController) renderPortal(c *gin.Context, data PageData) {
+	setCurrentUser(c, &data)
+	data.CSRFToken = wc.csrfToken(c)
+	c.HTML(http.StatusOK, "portal", data)
+}
+
+// booksOf maps the IDs of the books the items refer to to the books, for
+// showing titles; books that can't be loaded are left out.
+func (wc *WebController) booksOf(bookIDs []int64) map[int64]*bookModel.Book {
+	books := make(map[int64]*bookModel.Book, len(bookIDs))
+	for _, id := range bookIDs {
+		if _, ok := books[id]; ok {
+			continue
+		}
+		if book, err := wc.bookService.GetBook(id); err == nil {
+			books[id] = book
+		}
+	}
+	return books
+}
+
+// renewalReason explains to the member why a loan can't be renewed.
+func renewalReason(err error) string {
+	var block *loanModel.BalanceBlockError
+	switch {
+	case errors.As(err, &block):
+		return "Saldo devedor de R$ " + block.Balance.String() + " acima do limite de R$ " + block.Threshold.String()
+	case errors.Is(err, loanModel.ErrNotRenewable):
+		return "Este tipo de material não pode ser renovado"
+	case errors.Is(err, loanModel.ErrMaxRenewals):
+		return "Limite de renovações atingido"
+	case errors.Is(err, loanModel.ErrHeldForAnother):
+		return "Outro leitor reservou este título"
+	case errors.Is(err, loanModel.ErrRenewalGraceExpired):
+		return "Atrasado além do prazo para renovação; devolva o livro na biblioteca"
+	case errors.Is(err, loanModel.ErrLoanNotOpen):
+		return "Empréstimo encerrado"
+	default:
+		return err.Error()
+	}
+}
+
+func (wc *WebController) PortalHome(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	message, flashType := wc.getFlash(c)
+
+	loans, err := wc.loanService.GetUserLoans(user.ID)
+	if err != nil {
+		loans = []*loanModel.Loan{}
+		message, flashType = "Erro ao carregar empréstimos: "+err.Error(), "error"
+	}
+	slices.SortFunc(loans, func(x, y *loanModel.Loan) int {
+		return x.DueDate.Compare(y.DueDate)
+	})
+
+	renewalBlocks := make(map[int64]string)
+	for _, loan := range loans {
+		if err := wc.loanService.RenewalBlock(loan.ID); err != nil {
+			renewalBlocks[loan.ID] = renewalReason(err)
+		}
+	}
+
+	allHolds, err := wc.holdService.GetUserHolds(user.ID)
+	if err != nil {
+		allHolds = []*holdModel.Hold{}
+	}
+	var holds []*holdModel.Hold
+	for _, hold := range allHolds {
+		if hold.Active() {
+			holds = append(holds, hold)
+		}
+	}
+
+	var bookIDs []int64
+	for _, loan := range loans {
+		bookIDs = append(bookIDs, loan.BookID)
+	}
+	for _, hold := range holds {
+		bookIDs = append(bookIDs, hold.BookID)
+	}
+
+	account, err := wc.accountService.GetAccount(user.ID)
+	if err != nil {
+		account = &accountModel.Account{UserID: user.ID}
+	}
+	block, _ := wc.loanService.BorrowingBlock(user.ID)
+	// Com a situação da inscrição de hoje, mesmo antes de a vencida ser gravada
+	if member, err := wc.userService.GetUser(user.ID); err == nil {
+		user = member
+	}
+
+	data := PageData{
+		Title:           "Minha Área - Sistema de Biblioteca",
+		ActiveSection:   "portal",
+		FlashMessage:    message,
+		FlashType:       flashType,
+		User:            user,
+		Loans:           loans,
+		Holds:           holds,
+		BooksMap:        wc.booksOf(bookIDs),
+		Account:         account,
+		LoanLimit:       wc.loanService.LoanLimit(user),
+		ActiveLoanCount: len(loans),
+		BorrowingBlock:  block,
+		RenewalBlocks:   renewalBlocks,
+	}
+
+	wc.renderPortal(c, data)
+}
+
+func (wc *WebController) PortalCatalog(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	page := pageRequest(c)
+	filter := bookModel.BookFilter{Query: strings.TrimSpace(c.Query("q"))}
+
+	message, flashType := wc.getFlash(c)
+
+	books, err := wc.bookService.ListBooks(filter, page)
+	if err != nil {
+		books = paging.NewPage([]*bookModel.Book{}, 0, page.Normalize())
+		message, flashType = "Erro ao listar livros: "+err.Error(), "error"
+	}
+
+	// Livros que o leitor já tem emprestados ou reservados não oferecem
+	// reserva
+	onLoan := make(map[int64]bool)
+	if loans, err := wc.loanService.GetUserLoans(user.ID); err == nil {
+		for _, loan := range loans {
+			onLoan[loan.BookID] = true
+		}
+	}
+	onHold := make(map[int64]bool)
+	if holds, err := wc.holdService.GetUserHolds(user.ID); err == nil {
+		for _, hold := range holds {
+			if hold.Active() {
+				onHold[hold.BookID] = true
+			}
+		}
+	}
+
+	policies, _ := wc.policyService.GetAllPolicies()
+	loanable := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		loanable[policy.Code] = policy.Loanable
+	}
+
+	data := PageData{
+		Title:         "Catálogo - Sistema de Biblioteca",
+		ActiveSection: "portal-catalog",
+		FlashMessage:  message,
+		FlashType:     flashType,
+		Books:         books.Items,
+		SearchQuery:   filter.Query,
+		Pagination:    newPagination(c, books),
+		LoanableTypes: loanable,
+		OnLoan:        onLoan,
+		OnHold:        onHold,
+	}
+
+	wc.renderPortal(c, data)
+}
+
+func (wc *WebController) PortalHistory(c *gin.Context) {
+	user := auth.CurrentUser(c)
+	page := pageRequest(c)
+	page.Sort = "-returnedAt"
+
+	message, flashType := wc.getFlash(c)
+
+	loans, err := wc.loanService.ListLoans(loanModel.LoanFilter{UserID: user.ID, Status: "returned"}, page)
+	if err != nil {
+		loans = paging.NewPage([]*loanModel.Loan{}, 0, page.Normalize())
+		message, flashType = "Erro ao carregar histórico: "+err.Error(), "error"
+	}
+
+	var bookIDs []int64
+	for _, loan := range loans.Items {
+		bookIDs = append(bookIDs, loan.BookID)
+	}
+
+	account, err := wc.accountService.GetAccount(user.ID)
+	if err != nil {
+		account = &accountModel.Account{UserID: user.ID}
+	}
+
+	data := PageData{
+		Title:         "Histórico - Sistema de Biblioteca",
+		ActiveSection: "portal-history",
+		FlashMessage:  message,
+		FlashType:     flashType,
+		Loans:         loans.Items,
+		BooksMap:      wc.booksOf(bookIDs),
+		Account:       account,
+		Pagination:    newPagination(c, loans),
+	}
+
+	wc.renderPortal(c, data)
+}
+
+func (wc *WebController) PortalRenew(c *gin.Context) {
+	user := auth.CurrentUser(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/portal")
+		return
+	}
+
+	// Empréstimos de outros leitores são tratados como inexistentes
+	loan, err := wc.loanService.GetLoan(id)
+	if err != nil || loan.UserID != user.ID {
+		wc.setFlash(c, "Empréstimo não encontrado", "error")
+		c.Redirect(http.StatusFound, "/portal")
+		return
+	}
+
+	loan, err = wc.loanService.RenewLoan(id)
+	if err != nil {
+		wc.setFlash(c, "Não foi possível renovar: "+renewalReason(err), "error")
+	} else {
+		wc.setFlash(c, "Empréstimo renovado até "+loan.DueDate.Format("02/01/2006")+"!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/portal")
+}
+
+func (wc *WebController) PortalHoldCreate(c *gin.Context) {
+	user := auth.CurrentUser(c)
+
+	// Volta à página do catálogo em que o leitor estava
+	back := "/portal/catalog"
+	if referer, err := url.Parse(c.Request.Referer()); err == nil && referer.Path == back {
+		back = referer.RequestURI()
+	}
+
+	bookId, err := strconv.ParseInt(c.PostForm("book_id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID do livro inválido", "error")
+		c.Redirect(http.StatusFound, back)
+		return
+	}
+
+	_, err = wc.holdService.PlaceHold(bookId, user.ID)
+	switch {
+	case err == nil:
+		wc.setFlash(c, "Reserva feita! Avisaremos quando um exemplar estiver separado para você.", "success")
+	case errors.Is(err, holdModel.ErrCopiesAvailable):
+		wc.setFlash(c, "Há exemplares disponíveis deste livro; retire-o na biblioteca", "warning")
+	case errors.Is(err, holdModel.ErrAlreadyOnHold):
+		wc.setFlash(c, "Você já reservou este livro", "warning")
+	case errors.Is(err, loanModel.ErrAlreadyBorrowed):
+		wc.setFlash(c, "Você já está com este livro emprestado", "warning")
+	case errors.Is(err, holdModel.ErrNotHoldable):
+		wc.setFlash(c, "Este tipo de material não pode ser emprestado nem reservado", "error")
+	default:
+		wc.setFlash(c, "Erro ao criar reserva: "+err.Error(), "error")
+	}
+
+	c.Redirect(http.StatusFound, back)
+}
+
+func (wc *WebController) PortalHoldCancel(c *gin.Context) {
+	user := auth.CurrentUser(c)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/portal")
+		return
+	}
+
+	hold, err := wc.holdService.GetHold(id)
+	if err != nil || hold.UserID != user.ID {
+		wc.setFlash(c, "Reserva não encontrada", "error")
+		c.Redirect(http.StatusFound, "/portal")
+		return
+	}
+
+	if err := wc.holdService.CancelHold(id); err != nil {
+		wc.setFlash(c, "Erro ao cancelar reserva: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Reserva cancelada com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/portal")
+}
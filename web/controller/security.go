@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	auth "librarymvc/internal/auth/controllers"
+)
+
+// Os formulários levam um token CSRF ligado à sessão e as mensagens flash
+// viajam cifradas, ambos com chaves derivadas do segredo da aplicação.
+
+const (
+	// csrfField is the form field, and csrfHeader the header for scripts,
+	// carrying the CSRF token.
+	csrfField  = "csrf_token"
+	csrfHeader = "X-CSRF-Token"
+	// anonymousCookie ties the CSRF token of visitors without a session,
+	// who can only post the login form, to their browser.
+	anonymousCookie = "csrf"
+	flashCookie     = "flash"
+)
+
+var errSealed = errors.New("invalid sealed value")
+
+// deriveKey makes a key for one use out of the application secret, so
+// that the CSRF tokens and the flash cookies never share a key.
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// csrfToken is the token the forms of the page must send back. It is the
+// MAC of the session token, so it changes with every login and is worthless
+// without the session cookie it belongs to.
+func (wc *WebController) csrfToken(c *gin.Context) string {
+	mac := hmac.New(sha256.New, wc.csrfKey)
+	mac.Write([]byte(wc.csrfBinding(c)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// csrfBinding is the value the CSRF token is tied to: the session token
+// for logged-in users and a random cookie, created on demand, for visitors.
+func (wc *WebController) csrfBinding(c *gin.Context) string {
+	if auth.CurrentUser(c) != nil {
+		if token, err := c.Cookie(sessionCookie); err == nil && token != "" {
+			return "session:" + token
+		}
+	}
+
+	value, err := c.Cookie(anonymousCookie)
+	if err != nil || value == "" {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			panic(err)
+		}
+		value = base64.RawURLEncoding.EncodeToString(random)
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     anonymousCookie,
+			Value:    value,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   c.Request.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		// Vale já nesta requisição, antes de o navegador devolvê-lo
+		c.Request.AddCookie(&http.Cookie{Name: anonymousCookie, Value: value})
+	}
+	return "anonymous:" + value
+}
+
+// verifyForm rejects form posts whose CSRF token doesn't match the session
+// that sends them, sending the browser back to the form with a warning.
+func (wc *WebController) verifyForm(c *gin.Context) {
+	if auth.SafeMethod(c.Request.Method) {
+		return
+	}
+
+	sent := c.GetHeader(csrfHeader)
+	if sent == "" {
+		sent = c.PostForm(csrfField)
+	}
+	if sent != "" && hmac.Equal([]byte(sent), []byte(wc.csrfToken(c))) {
+		return
+	}
+
+	back := home(auth.CurrentUser(c))
+	if auth.CurrentUser(c) == nil {
+		back = "/login"
+	}
+	if referer, err := url.Parse(c.Request.Referer()); err == nil && referer.Path != "" {
+		if path := localPath(referer.RequestURI()); path != "" {
+			back = path
+		}
+	}
+	wc.setFlash(c, "O formulário expirou ou não veio desta página; confira os dados e envie de novo", "warning")
+	c.Redirect(http.StatusFound, back)
+	c.Abort()
+}
+
+// seal encrypts and authenticates value for a cookie, so the browser can
+// neither read nor change it.
+func (wc *WebController) seal(value string) string {
+	gcm := newGCM(wc.flashKey)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(value), nil))
+}
+
+// unseal opens a value made by seal, failing when it was tampered with or
+// sealed with another secret.
+func (wc *WebController) unseal(sealed string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", errSealed
+	}
+
+	gcm := newGCM(wc.flashKey)
+	if len(data) < gcm.NonceSize() {
+		return "", errSealed
+	}
+	value, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errSealed
+	}
+	return string(value), nil
+}
+
+func newGCM(key []byte) cipher.AEAD {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return gcm
+}
+
+func (wc *WebController) setFlash(c *gin.Context, message string, flashType string) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     flashCookie,
+		Value:    wc.seal(flashType + "\n" + message),
+		Path:     "/",
+		MaxAge:   3600,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// getFlash returns the message set by the previous request and clears it.
+// Messages that weren't sealed by this server are dropped.
+func (wc *WebController) getFlash(c *gin.Context) (string, string) {
+	sealed, err := c.Cookie(flashCookie)
+	if err != nil || sealed == "" {
+		return "", ""
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     flashCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	value, err := wc.unseal(sealed)
+	if err != nil {
+		return "", ""
+	}
+	flashType, message, _ := strings.Cut(value, "\n")
+	return message, flashType
+}
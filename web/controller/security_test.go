@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"librarymvc/internal/auth/services"
+	"librarymvc/internal/testenv"
+	userModel "librarymvc/internal/users/models"
+)
+
+const testSecret = "um segredo de teste com mais de 32 caracteres"
+
+// securityRouter serves a form behind authenticate and verifyForm, a page
+// that hands out its CSRF token and pages that set and read the flash.
+func securityRouter(wc *WebController) *gin.Engine {
+	router := gin.New()
+	router.Use(wc.authenticate)
+	router.GET("/token", func(c *gin.Context) { c.String(http.StatusOK, wc.csrfToken(c)) })
+	router.POST("/form", wc.verifyForm, func(c *gin.Context) { c.String(http.StatusOK, "saved") })
+	router.GET("/flash/set", func(c *gin.Context) {
+		wc.setFlash(c, "Livro salvo", "success")
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/flash", func(c *gin.Context) {
+		message, flashType := wc.getFlash(c)
+		c.String(http.StatusOK, flashType+":"+message)
+	})
+	return router
+}
+
+func serve(router *gin.Engine, request *http.Request, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func cookie(recorder *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// tamper changes one character in the middle of a sealed value, keeping it
+// valid base64.
+func tamper(sealed string) string {
+	changed := []byte(sealed)
+	if middle := len(changed) / 2; changed[middle] == 'A' {
+		changed[middle] = 'B'
+	} else {
+		changed[middle] = 'A'
+	}
+	return string(changed)
+}
+
+// session logs a new patron in and returns their session cookie.
+func session(t *testing.T, storage *testenv.Storage, wc *WebController, email string) *http.Cookie {
+	t.Helper()
+	now := storage.Clock.Now()
+	user := &userModel.User{
+		Name:      "Leitora de Teste",
+		Email:     email,
+		Role:      userModel.RolePatron,
+		Category:  userModel.DefaultCategory,
+		Status:    userModel.StatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := storage.Repos.Users.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := wc.authService.SetPassword(user.ID, "senha-de-teste"); err != nil {
+		t.Fatal(err)
+	}
+	session, err := wc.authService.Login(email, "senha-de-teste")
+	if err != nil {
+		t.Fatalf("Login() = %v", err)
+	}
+	return &http.Cookie{Name: sessionCookie, Value: session.Token}
+}
+
+func TestVerifyForm(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testenv.Each(t, func(t *testing.T, storage *testenv.Storage) {
+		authService := services.NewAuthService(storage.Repos.Users, storage.Credentials, storage.Sessions, storage.Tokens, time.Hour, time.Hour, storage.Clock)
+		wc := NewWebController(nil, nil, nil, nil, nil, nil, nil, nil, nil, authService, []byte(testSecret), storage.Clock)
+		router := securityRouter(wc)
+
+		mine := session(t, storage, wc, "leitora@lib.org")
+		other := session(t, storage, wc, "outra@lib.org")
+		tokenOf := func(cookies ...*http.Cookie) string {
+			return serve(router, httptest.NewRequest(http.MethodGet, "/token", nil), cookies...).Body.String()
+		}
+		token := tokenOf(mine)
+
+		// A visitor's token is tied to their csrf cookie
+		visitor := serve(router, httptest.NewRequest(http.MethodGet, "/token", nil))
+		anonymous := cookie(visitor, anonymousCookie)
+		if anonymous == nil {
+			t.Fatal("no csrf cookie for the visitor")
+		}
+
+		tests := []struct {
+			name    string
+			token   string
+			header  bool
+			cookies []*http.Cookie
+			ok      bool
+		}{
+			{"the session's token", token, false, []*http.Cookie{mine}, true},
+			{"the session's token in the header", token, true, []*http.Cookie{mine}, true},
+			{"a visitor's own token", visitor.Body.String(), false, []*http.Cookie{anonymous}, true},
+			{"no token", "", false, []*http.Cookie{mine}, false},
+			{"a wrong token", strings.Repeat("A", len(token)), false, []*http.Cookie{mine}, false},
+			{"a truncated token", token[:len(token)-1], false, []*http.Cookie{mine}, false},
+			{"a token from another session", tokenOf(other), false, []*http.Cookie{mine}, false},
+			{"a visitor token with a session", visitor.Body.String(), false, []*http.Cookie{mine, anonymous}, false},
+			{"a token without its session", token, false, nil, false},
+		}
+		for _, test := range tests {
+			form := url.Values{}
+			if !test.header {
+				form.Set(csrfField, test.token)
+			}
+			request := httptest.NewRequest(http.MethodPost, "/form", strings.NewReader(form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			request.Header.Set("Referer", "http://biblioteca.local/books?page=2")
+			if test.header {
+				request.Header.Set(csrfHeader, test.token)
+			}
+			recorder := serve(router, request, test.cookies...)
+
+			if test.ok {
+				if recorder.Code != http.StatusOK || recorder.Body.String() != "saved" {
+					t.Errorf("%s: status %d, body %q; want the form saved", test.name, recorder.Code, recorder.Body.String())
+				}
+				continue
+			}
+			if recorder.Code != http.StatusFound || recorder.Body.String() == "saved" {
+				t.Errorf("%s: status %d, body %q; want the form refused", test.name, recorder.Code, recorder.Body.String())
+				continue
+			}
+			if location := recorder.Header().Get("Location"); location != "/books?page=2" {
+				t.Errorf("%s: sent to %q, want back to the form", test.name, location)
+			}
+			flash := cookie(recorder, flashCookie)
+			if flash == nil {
+				t.Errorf("%s: no warning", test.name)
+				continue
+			}
+			if value, err := wc.unseal(flash.Value); err != nil || !strings.HasPrefix(value, "warning\n") {
+				t.Errorf("%s: flash %q, %v; want a warning", test.name, value, err)
+			}
+		}
+
+		// Without a referer the member goes back to their home page
+		request := httptest.NewRequest(http.MethodPost, "/form", nil)
+		if recorder := serve(router, request, mine); recorder.Header().Get("Location") != "/portal" {
+			t.Errorf("refused without a referer: sent to %q, want /portal", recorder.Header().Get("Location"))
+		}
+	})
+}
+
+func TestFlashCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	wc := NewWebController(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, []byte(testSecret), nil)
+	router := securityRouter(wc)
+	sealed := cookie(serve(router, httptest.NewRequest(http.MethodGet, "/flash/set", nil)), flashCookie).Value
+
+	elsewhere := NewWebController(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, []byte("outro segredo, também com mais de 32 caracteres"), nil)
+
+	tests := []struct {
+		name   string
+		sealed string
+		want   string
+	}{
+		{"as sealed", sealed, "success:Livro salvo"},
+		{"tampered", tamper(sealed), ":"},
+		{"truncated", sealed[:len(sealed)-4], ":"},
+		{"shorter than the nonce", sealed[:8], ":"},
+		{"not base64", "não é base64!", ":"},
+		{"plain text", "success\nLivro salvo", ":"},
+		{"sealed with another secret", elsewhere.seal("success\nLivro salvo"), ":"},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/flash", nil)
+		recorder := serve(router, request, &http.Cookie{Name: flashCookie, Value: url.QueryEscape(test.sealed)})
+		if recorder.Body.String() != test.want {
+			t.Errorf("%s: flash %q, want %q", test.name, recorder.Body.String(), test.want)
+		}
+		// The cookie is cleared either way
+		if cleared := cookie(recorder, flashCookie); cleared == nil || cleared.MaxAge >= 0 {
+			t.Errorf("%s: flash cookie not cleared", test.name)
+		}
+	}
+}
+
+func TestUnseal(t *testing.T) {
+	wc := NewWebController(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, []byte(testSecret), nil)
+	elsewhere := NewWebController(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, []byte("outro segredo, também com mais de 32 caracteres"), nil)
+
+	sealed := wc.seal("success\nLivro salvo")
+	if value, err := wc.unseal(sealed); err != nil || value != "success\nLivro salvo" {
+		t.Fatalf("unseal(seal()) = %q, %v", value, err)
+	}
+	if again := wc.seal("success\nLivro salvo"); again == sealed {
+		t.Error("seal() gave the same value twice, want a fresh nonce")
+	}
+
+	for name, bad := range map[string]string{
+		"empty":                      "",
+		"not base64":                 "***",
+		"shorter than the nonce":     sealed[:8],
+		"nonce only":                 sealed[:16],
+		"truncated":                  sealed[:len(sealed)-1],
+		"tampered":                   tamper(sealed),
+		"sealed with another secret": elsewhere.seal("success\nLivro salvo"),
+	} {
+		if value, err := wc.unseal(bad); !errors.Is(err, errSealed) {
+			t.Errorf("%s: unseal() = %q, %v; want %v", name, value, err, errSealed)
+		}
+	}
+}
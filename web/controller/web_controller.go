@@ -1,23 +1,51 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
 
+	accountModel "librarymvc/internal/accounts/models"
+	auth "librarymvc/internal/auth/controllers"
+	authModel "librarymvc/internal/auth/models"
 	bookModel "librarymvc/internal/books/models"
+	calendarModel "librarymvc/internal/calendar/models"
+	"librarymvc/internal/clock"
+	copyModel "librarymvc/internal/copies/models"
+	holdModel "librarymvc/internal/holds/models"
 	loanModel "librarymvc/internal/loans/models"
+	"librarymvc/internal/money"
+	"librarymvc/internal/paging"
+	policyModel "librarymvc/internal/policies/models"
+	searchModel "librarymvc/internal/search/models"
 	userModel "librarymvc/internal/users/models"
 )
 
 type WebController struct {
-	bookService bookModel.BookService
-	userService userModel.UserService
-	loanService loanModel.LoanService
+	bookService     bookModel.BookService
+	userService     userModel.UserService
+	loanService     loanModel.LoanService
+	copyService     copyModel.CopyService
+	policyService   policyModel.LoanPolicyService
+	holdService     holdModel.HoldService
+	accountService  accountModel.AccountService
+	calendarService calendarModel.CalendarService
+	searchService   searchModel.SearchService
+	authService     authModel.AuthService
+	csrfKey         []byte
+	flashKey        []byte
+	clock           clock.Clock
 }
 
+// sessionCookie holds the token of the web session.
+const sessionCookie = "session"
+
 type DashboardStats struct {
 	TotalBooks     int
 	TotalUsers     int
@@ -27,91 +55,439 @@ type DashboardStats struct {
 }
 
 type PageData struct {
-	Title         string
-	ActiveSection string
-	FlashMessage  string
-	FlashType     string
-	Stats         *DashboardStats
-	Books         []*bookModel.Book
-	Users         []*userModel.User
-	Loans         []*loanModel.Loan
-	Book          *bookModel.Book
-	User          *userModel.User
-	Loan          *loanModel.Loan
-	IsEdit        bool
-	ShowUserLoans bool
-	SearchQuery   string
-	StatusFilter  string
-	BooksMap      map[int64]*bookModel.Book
+	Title           string
+	ActiveSection   string
+	FlashMessage    string
+	FlashType       string
+	Stats           *DashboardStats
+	Books           []*bookModel.Book
+	Users           []*userModel.User
+	Loans           []*loanModel.Loan
+	Copies          []*copyModel.Copy
+	Policies        []*policyModel.LoanPolicy
+	Holds           []*holdModel.Hold
+	Book            *bookModel.Book
+	User            *userModel.User
+	Loan            *loanModel.Loan
+	Policy          *policyModel.LoanPolicy
+	Account         *accountModel.Account
+	Calendar        *calendarModel.Calendar
+	WeekdayNames    []string
+	IsEdit          bool
+	ShowUserLoans   bool
+	ShowCopies      bool
+	ShowAccount     bool
+	SearchQuery     string
+	BrowseTitle     string
+	StatusFilter    string
+	LoanFilter      loanModel.LoanFilter
+	SortOrder       string
+	Pagination      *Pagination
+	BooksMap        map[int64]*bookModel.Book
+	UsersMap        map[int64]*userModel.User
+	LoanLimit       int
+	ActiveLoanCount int
+	BorrowingBlock  *loanModel.BalanceBlockError
+	LoanableTypes   map[string]bool
+	CurrentUser     *userModel.User
+	UserInitials    string
+	Can             map[string]bool // permissões do usuário logado
+	Next            string          // página para onde voltar depois do login
+	HasPassword     bool
+	CSRFToken       string // vai em todo formulário POST da página
+	Categories      []userModel.Category
+	RenewalBlocks   map[int64]string // por que cada empréstimo não pode ser renovado
+	OnLoan          map[int64]bool   // livros emprestados ao usuário logado
+	OnHold          map[int64]bool   // livros reservados pelo usuário logado
+}
+
+// Pagination links the pages of a list, keeping the filters and the sort
+// order of the current request.
+type Pagination struct {
+	Page    int
+	Pages   int
+	Total   int
+	PrevURL string
+	NextURL string
+}
+
+func newPagination[T any](c *gin.Context, page *paging.Page[T]) *Pagination {
+	pageURL := func(number int) string {
+		query := c.Request.URL.Query()
+		query.Set("page", strconv.Itoa(number))
+		return c.Request.URL.Path + "?" + query.Encode()
+	}
+
+	pagination := &Pagination{Page: page.Page, Pages: page.Pages, Total: page.Total}
+	if page.Page > 1 {
+		pagination.PrevURL = pageURL(min(page.Page-1, max(page.Pages, 1)))
+	}
+	if page.Page < page.Pages {
+		pagination.NextURL = pageURL(page.Page + 1)
+	}
+	return pagination
+}
+
+// pageRequest reads the page and sort order of a list page, falling back to
+// the first page when the parameters are not numbers.
+func pageRequest(c *gin.Context) paging.Request {
+	page, err := paging.FromQuery(c.Request.URL.Query())
+	if err != nil {
+		return paging.Request{Sort: c.Query("sort")}.Normalize()
+	}
+	return page
 }
 
 func NewWebController(
 	bookService bookModel.BookService,
 	userService userModel.UserService,
 	loanService loanModel.LoanService,
+	copyService copyModel.CopyService,
+	policyService policyModel.LoanPolicyService,
+	holdService holdModel.HoldService,
+	accountService accountModel.AccountService,
+	calendarService calendarModel.CalendarService,
+	searchService searchModel.SearchService,
+	authService authModel.AuthService,
+	secret []byte,
+	clock clock.Clock,
 ) *WebController {
 	return &WebController{
-		bookService: bookService,
-		userService: userService,
-		loanService: loanService,
+		bookService:     bookService,
+		userService:     userService,
+		loanService:     loanService,
+		copyService:     copyService,
+		policyService:   policyService,
+		holdService:     holdService,
+		accountService:  accountService,
+		calendarService: calendarService,
+		searchService:   searchService,
+		authService:     authService,
+		csrfKey:         deriveKey(secret, "csrf"),
+		flashKey:        deriveKey(secret, "flash"),
+		clock:           clock,
 	}
 }
 
-func (wc *WebController) RegisterRoutes(r *gin.Engine) {
+func (wc *WebController) RegisterRoutes(engine *gin.Engine) {
 	// Configurar templates
-	r.LoadHTMLGlob("templates/*.html")
+	engine.LoadHTMLGlob("templates/*.html")
 
 	// Servir arquivos estáticos
-	r.Static("/static", "./static")
+	engine.Static("/static", "./static")
+
+	// Login, aberto a visitantes
+	engine.GET("/login", wc.authenticate, wc.LoginForm)
+	engine.POST("/login", wc.verifyForm, wc.Login)
+
+	// As demais páginas identificam o usuário da sessão; formulários exigem
+	// login e o token CSRF da sessão, e cada rota restrita, a permissão do
+	// papel do usuário
+	r := engine.Group("/", wc.authenticate, wc.verifyForm, wc.protectForms)
+	r.POST("/logout", wc.Logout)
+
+	// Portal do leitor: cada usuário logado cuida dos próprios empréstimos
+	// e reservas
+	r.GET("/portal", wc.requireUser, wc.PortalHome)
+	r.GET("/portal/catalog", wc.requireUser, wc.PortalCatalog)
+	r.GET("/portal/history", wc.requireUser, wc.PortalHistory)
+	r.POST("/portal/loans/:id/renew", wc.require(authModel.RenewOwnLoans), wc.PortalRenew)
+	r.POST("/portal/holds", wc.require(authModel.PlaceHolds), wc.PortalHoldCreate)
+	r.POST("/portal/holds/:id/cancel", wc.require(authModel.PlaceHolds), wc.PortalHoldCancel)
 
 	// Rotas principais
-	r.GET("/", wc.Dashboard)
+	r.GET("/", wc.require(authModel.ViewCirculation), wc.Dashboard)
 	r.GET("/books", wc.BooksList)
-	r.GET("/users", wc.UsersList)
-	r.GET("/loans", wc.LoansList)
+	r.GET("/users", wc.require(authModel.ViewUsers), wc.UsersList)
+	r.GET("/loans", wc.require(authModel.ViewCirculation), wc.LoansList)
 
 	// Rotas de livros
 	r.GET("/books/search", wc.BooksSearch)
-	r.GET("/books/:id/edit", wc.BookEditForm)
-	r.POST("/books/:id/edit", wc.BookUpdate)
-	r.POST("/books/:id/delete", wc.BookDelete)
-	r.POST("/books", wc.BookCreate)
-	r.POST("/books/create", wc.BookCreate)
+	r.GET("/books/:id/edit", wc.require(authModel.ManageCatalog), wc.BookEditForm)
+	r.POST("/books/:id/edit", wc.require(authModel.ManageCatalog), wc.BookUpdate)
+	r.POST("/books/:id/delete", wc.require(authModel.DeleteBooks), wc.BookDelete)
+	r.POST("/books/:id/merge", wc.require(authModel.ManageCatalog), wc.BookMerge)
+	r.POST("/books", wc.require(authModel.ManageCatalog), wc.BookCreate)
+	r.POST("/books/create", wc.require(authModel.ManageCatalog), wc.BookCreate)
+
+	// Rotas de exemplares
+	r.GET("/books/:id/copies", wc.BookCopies)
+	r.POST("/books/:id/copies", wc.require(authModel.ManageCatalog), wc.CopyCreate)
+	r.POST("/copies/:id/edit", wc.require(authModel.ManageCatalog), wc.CopyUpdate)
+	r.POST("/copies/:id/delete", wc.require(authModel.ManageCatalog), wc.CopyDelete)
 
 	// Rotas de usuários
-	r.GET("/users/search", wc.UsersSearch)
-	r.GET("/users/:id/edit", wc.UserEditForm)
-	r.GET("/users/:id/loans", wc.UserLoans)
-	r.POST("/users/:id/edit", wc.UserUpdate)
-	r.POST("/users/:id/delete", wc.UserDelete)
-	r.POST("/users", wc.UserCreate)
-	r.POST("/users/create", wc.UserCreate)
+	r.GET("/users/search", wc.require(authModel.ViewUsers), wc.UsersSearch)
+	r.GET("/users/:id/edit", wc.requireSelfOr("id", authModel.ManageUsers), wc.UserEditForm)
+	r.GET("/users/:id/loans", wc.requireSelfOr("id", authModel.ViewCirculation), wc.UserLoans)
+	r.GET("/users/:id/account", wc.requireSelfOr("id", authModel.ViewCirculation), wc.UserAccount)
+	r.POST("/users/:id/account/payments", wc.require(authModel.ManageAccounts), wc.AccountPay)
+	r.POST("/users/:id/account/waivers", wc.require(authModel.ManageAccounts), wc.AccountWaive)
+	r.POST("/users/:id/account/charges", wc.require(authModel.ManageAccounts), wc.AccountCharge)
+	r.POST("/users/:id/edit", wc.require(authModel.ManageUsers), wc.UserUpdate)
+	r.POST("/users/:id/password", wc.requireSelfOr("id", authModel.SetPasswords), wc.UserPassword)
+	r.POST("/users/:id/delete", wc.require(authModel.DeleteUsers), wc.UserDelete)
+	r.POST("/users/:id/membership/renew", wc.require(authModel.ManageUsers), wc.MembershipRenew)
+	r.POST("/users/:id/membership/suspend", wc.require(authModel.ManageUsers), wc.MembershipSuspend)
+	r.POST("/users/:id/membership/reinstate", wc.require(authModel.ManageUsers), wc.MembershipReinstate)
+	r.POST("/users", wc.require(authModel.ManageUsers), wc.UserCreate)
+	r.POST("/users/create", wc.require(authModel.ManageUsers), wc.UserCreate)
 
 	// Rotas de empréstimos
-	r.GET("/loans/search", wc.LoansSearch)
-	r.POST("/loans/:id/return", wc.LoanReturn)
-	r.POST("/loans", wc.LoanCreate)
-	r.POST("/loans/create", wc.LoanCreate)
+	r.GET("/loans/search", wc.require(authModel.ViewCirculation), wc.LoansSearch)
+	r.POST("/loans/:id/return", wc.require(authModel.Circulate), wc.LoanReturn)
+	r.POST("/loans/:id/renew", wc.require(authModel.Circulate), wc.LoanRenew)
+	r.POST("/loans", wc.require(authModel.Circulate), wc.LoanCreate)
+	r.POST("/loans/create", wc.require(authModel.Circulate), wc.LoanCreate)
+
+	// Rotas de políticas de empréstimo (tipos de material)
+	r.GET("/policies", wc.PoliciesList)
+	r.GET("/policies/:id/edit", wc.require(authModel.ManagePolicies), wc.PolicyEditForm)
+	r.POST("/policies/:id/edit", wc.require(authModel.ManagePolicies), wc.PolicyUpdate)
+	r.POST("/policies/:id/delete", wc.require(authModel.ManagePolicies), wc.PolicyDelete)
+	r.POST("/policies", wc.require(authModel.ManagePolicies), wc.PolicyCreate)
+
+	// Rotas de reservas
+	r.GET("/holds", wc.require(authModel.ViewCirculation), wc.HoldsList)
+	r.POST("/holds", wc.require(authModel.Circulate), wc.HoldCreate)
+	r.POST("/holds/:id/cancel", wc.require(authModel.Circulate), wc.HoldCancel)
+	r.POST("/holds/process", wc.require(authModel.Circulate), wc.HoldsProcess)
+
+	// Rotas do calendário
+	r.GET("/calendar", wc.CalendarPage)
+	r.POST("/calendar/hours/:weekday", wc.require(authModel.ManageCalendar), wc.OpeningHoursUpdate)
+	r.POST("/calendar/closures", wc.require(authModel.ManageCalendar), wc.ClosureCreate)
+	r.POST("/calendar/closures/:id/delete", wc.require(authModel.ManageCalendar), wc.ClosureDelete)
 }
 
 func (wc *WebController) renderTemplate(c *gin.Context, template string, data PageData) {
+	// Os modais de livros e empréstimos precisam dos tipos de material em todas as páginas
+	if data.Policies == nil {
+		policies, err := wc.policyService.GetAllPolicies()
+		if err != nil {
+			policies = []*policyModel.LoanPolicy{}
+		}
+		data.Policies = policies
+	}
+	data.LoanableTypes = make(map[string]bool, len(data.Policies))
+	for _, policy := range data.Policies {
+		data.LoanableTypes[policy.Code] = policy.Loanable
+	}
+	data.Categories = userModel.Categories
+
+	setCurrentUser(c, &data)
+	data.CSRFToken = wc.csrfToken(c)
 	c.HTML(http.StatusOK, "layout", data)
 }
 
-func (wc *WebController) setFlash(c *gin.Context, message string, flashType string) {
-	c.SetCookie("flash_message", message, 3600, "/", "", false, true)
-	c.SetCookie("flash_type", flashType, 3600, "/", "", false, true)
+// setCurrentUser fills in the logged-in user and what their role allows,
+// for the user menu and for hiding what they can't do.
+func setCurrentUser(c *gin.Context, data *PageData) {
+	data.Can = make(map[string]bool, len(authModel.Permissions))
+	if user := auth.CurrentUser(c); user != nil {
+		data.CurrentUser = user
+		data.UserInitials = initials(user.Name)
+		for _, permission := range authModel.Permissions {
+			data.Can[string(permission)] = authModel.Can(user.Role, permission)
+		}
+	}
+}
+
+// initials are the first letters of the first and last names, shown in
+// the user menu.
+func initials(name string) string {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return ""
+	}
+	if len(words) > 1 {
+		words = []string{words[0], words[len(words)-1]}
+	}
+
+	var result []rune
+	for _, word := range words {
+		result = append(result, unicode.ToUpper([]rune(word)[0]))
+	}
+	return string(result)
+}
+
+// authenticate identifies the user of the session cookie. Requests without
+// a valid session go on anonymously.
+func (wc *WebController) authenticate(c *gin.Context) {
+	token, err := c.Cookie(sessionCookie)
+	if err != nil || token == "" {
+		return
+	}
+
+	user, err := wc.authService.SessionUser(token)
+	if errors.Is(err, authModel.ErrUnauthenticated) {
+		wc.clearSession(c)
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	auth.SetCurrentUser(c, user)
+}
+
+// protectForms sends anonymous form posts to the login page, which returns
+// to the page the form was on.
+func (wc *WebController) protectForms(c *gin.Context) {
+	if auth.SafeMethod(c.Request.Method) || auth.CurrentUser(c) != nil {
+		return
+	}
+	wc.allow(c, authModel.ErrUnauthenticated)
+}
+
+// requireUser sends anonymous visitors to the login page.
+func (wc *WebController) requireUser(c *gin.Context) {
+	if auth.CurrentUser(c) == nil {
+		wc.allow(c, authModel.ErrUnauthenticated)
+	}
+}
+
+// require lets through the users whose role grants the permission.
+func (wc *WebController) require(permission authModel.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		wc.allow(c, auth.Check(c, permission))
+	}
+}
+
+// requireSelfOr lets users through to their own pages, whose user ID is the
+// route parameter param, and to everyone else's with the permission.
+func (wc *WebController) requireSelfOr(param string, permission authModel.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		wc.allow(c, auth.CheckSelfOr(c, auth.ParamID(c, param), permission))
+	}
+}
+
+// allow stops the request when the permission check failed: anonymous
+// visitors are sent to log in and everyone else back to their home page.
+func (wc *WebController) allow(c *gin.Context, err error) {
+	if err == nil {
+		return
+	}
+
+	if errors.Is(err, authModel.ErrUnauthenticated) {
+		// Depois do login volta à página pedida ou, num formulário, à
+		// página em que ele estava
+		next := c.Request.URL.RequestURI()
+		if !auth.SafeMethod(c.Request.Method) {
+			next = ""
+			if referer, err := url.Parse(c.Request.Referer()); err == nil && referer.Path != "" {
+				next = localPath(referer.RequestURI())
+			}
+		}
+		login := "/login"
+		if next != "" {
+			login += "?next=" + url.QueryEscape(next)
+		}
+		wc.setFlash(c, "Entre com seu email e senha para continuar", "warning")
+		c.Redirect(http.StatusFound, login)
+		c.Abort()
+		return
+	}
+
+	wc.setFlash(c, "Seu perfil não tem permissão para acessar esta página ou fazer esta operação", "error")
+	c.Redirect(http.StatusFound, home(auth.CurrentUser(c)))
+	c.Abort()
+}
+
+// localPath is path when it points to a page of this site, or "" when it
+// could send the browser elsewhere.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return ""
+	}
+	return path
+}
+
+func (wc *WebController) setSession(c *gin.Context, session *authModel.Session) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    session.Token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (wc *WebController) clearSession(c *gin.Context) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Login
+func (wc *WebController) LoginForm(c *gin.Context) {
+	next := localPath(c.Query("next"))
+	if user := auth.CurrentUser(c); user != nil {
+		if next == "" {
+			next = home(user)
+		}
+		c.Redirect(http.StatusFound, next)
+		return
+	}
+
+	message, flashType := wc.getFlash(c)
+
+	c.HTML(http.StatusOK, "login", PageData{
+		Title:        "Entrar - Sistema de Biblioteca",
+		FlashMessage: message,
+		FlashType:    flashType,
+		Next:         next,
+		CSRFToken:    wc.csrfToken(c),
+	})
 }
 
-func (wc *WebController) getFlash(c *gin.Context) (string, string) {
-	message, _ := c.Cookie("flash_message")
-	flashType, _ := c.Cookie("flash_type")
+func (wc *WebController) Login(c *gin.Context) {
+	next := localPath(c.PostForm("next"))
+
+	session, err := wc.authService.Login(c.PostForm("email"), c.PostForm("password"))
+	if err != nil {
+		if errors.Is(err, authModel.ErrInvalidCredentials) {
+			wc.setFlash(c, "Email ou senha inválidos", "error")
+		} else {
+			wc.setFlash(c, "Erro ao entrar: "+err.Error(), "error")
+		}
+		login := "/login"
+		if next != "" {
+			login += "?next=" + url.QueryEscape(next)
+		}
+		c.Redirect(http.StatusFound, login)
+		return
+	}
+
+	wc.setSession(c, session)
+	if next == "" {
+		next = "/"
+		if user, err := wc.userService.GetUser(session.UserID); err == nil {
+			next = home(user)
+		}
+	}
+	c.Redirect(http.StatusFound, next)
+}
 
-	// Limpar cookies
-	c.SetCookie("flash_message", "", -1, "/", "", false, true)
-	c.SetCookie("flash_type", "", -1, "/", "", false, true)
+func (wc *WebController) Logout(c *gin.Context) {
+	if token, err := c.Cookie(sessionCookie); err == nil {
+		if err := wc.authService.Logout(token); err != nil {
+			wc.setFlash(c, "Erro ao sair: "+err.Error(), "error")
+			c.Redirect(http.StatusFound, "/")
+			return
+		}
+	}
 
-	return message, flashType
+	wc.clearSession(c)
+	wc.setFlash(c, "Você saiu do sistema", "success")
+	c.Redirect(http.StatusFound, "/login")
 }
 
 // Dashboard
@@ -129,7 +505,7 @@ func (wc *WebController) Dashboard(c *gin.Context) {
 	}
 
 	for _, loan := range loans {
-		if loan.Status == "active" {
+		if loan.Open() {
 			stats.ActiveLoans++
 		}
 	}
@@ -150,7 +526,7 @@ func (wc *WebController) Dashboard(c *gin.Context) {
 
 	var activeLoans []*loanModel.Loan
 	for _, loan := range loans {
-		if loan.Status == "active" && len(activeLoans) < 5 {
+		if loan.Open() && len(activeLoans) < 5 {
 			activeLoans = append(activeLoans, loan)
 		}
 	}
@@ -180,43 +556,69 @@ func (wc *WebController) Dashboard(c *gin.Context) {
 
 // Books
 func (wc *WebController) BooksList(c *gin.Context) {
-	books, err := wc.bookService.GetAllBooks()
-	if err != nil {
-		books = []*bookModel.Book{}
-	}
+	page := pageRequest(c)
+	filter := bookModel.BookFilter{Query: strings.TrimSpace(c.Query("q"))}
+	filter.AuthorID, _ = strconv.ParseInt(c.Query("author_id"), 10, 64)
+	filter.SubjectID, _ = strconv.ParseInt(c.Query("subject_id"), 10, 64)
 
 	message, flashType := wc.getFlash(c)
 
+	// Navegação a partir dos autores e assuntos mostrados nos livros
+	var browseTitle string
+	if filter.AuthorID != 0 {
+		if author, err := wc.bookService.GetAuthor(filter.AuthorID); err == nil {
+			browseTitle = "Livros de " + author.Name
+		}
+	}
+	if filter.SubjectID != 0 {
+		if subject, err := wc.bookService.GetSubject(filter.SubjectID); err == nil {
+			browseTitle = "Livros sobre " + subject.Name
+		}
+	}
+
+	books, err := wc.bookService.ListBooks(filter, page)
+	if err != nil {
+		books = paging.NewPage([]*bookModel.Book{}, 0, page.Normalize())
+		message, flashType = "Erro ao listar livros: "+err.Error(), "error"
+	}
+
 	data := PageData{
 		Title:         "Livros - Sistema de Biblioteca",
 		ActiveSection: "books",
 		FlashMessage:  message,
 		FlashType:     flashType,
-		Books:         books,
+		Books:         books.Items,
+		SearchQuery:   filter.Query,
+		BrowseTitle:   browseTitle,
+		SortOrder:     page.Sort,
+		Pagination:    newPagination(c, books),
 	}
 
 	wc.renderTemplate(c, "books", data)
 }
 
+// BooksSearch lists the books matching q from the search index, most
+// relevant first. Choosing another order filters the plain list instead.
 func (wc *WebController) BooksSearch(c *gin.Context) {
-	query := strings.ToLower(c.Query("q"))
-	books, err := wc.bookService.GetAllBooks()
-	if err != nil {
-		books = []*bookModel.Book{}
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" || c.Query("sort") != "" {
+		wc.BooksList(c)
+		return
 	}
 
-	if query != "" {
-		var filtered []*bookModel.Book
-		for _, book := range books {
-			if strings.Contains(strings.ToLower(book.Title), query) ||
-				strings.Contains(strings.ToLower(book.Author), query) {
-				filtered = append(filtered, book)
-			}
-		}
-		books = filtered
+	page := pageRequest(c)
+	message, flashType := wc.getFlash(c)
+
+	results, err := wc.searchService.SearchBooks(query, page)
+	if err != nil {
+		results = paging.NewPage([]*searchModel.Result{}, 0, page.Normalize())
+		message, flashType = "Erro na busca: "+err.Error(), "error"
 	}
 
-	message, flashType := wc.getFlash(c)
+	books := make([]*bookModel.Book, 0, len(results.Items))
+	for _, result := range results.Items {
+		books = append(books, result.Book)
+	}
 
 	data := PageData{
 		Title:         "Livros - Sistema de Biblioteca",
@@ -225,6 +627,7 @@ func (wc *WebController) BooksSearch(c *gin.Context) {
 		FlashType:     flashType,
 		Books:         books,
 		SearchQuery:   query,
+		Pagination:    newPagination(c, results),
 	}
 
 	wc.renderTemplate(c, "books", data)
@@ -245,6 +648,12 @@ func (wc *WebController) BookEditForm(c *gin.Context) {
 		return
 	}
 
+	// Candidates for merging a duplicate into this book
+	books, err := wc.bookService.GetAllBooks()
+	if err != nil {
+		books = []*bookModel.Book{}
+	}
+
 	message, flashType := wc.getFlash(c)
 
 	data := PageData{
@@ -253,6 +662,7 @@ func (wc *WebController) BookEditForm(c *gin.Context) {
 		FlashMessage:  message,
 		FlashType:     flashType,
 		Book:          book,
+		Books:         books,
 		IsEdit:        true,
 	}
 
@@ -267,17 +677,23 @@ func (wc *WebController) BookUpdate(c *gin.Context) {
 		return
 	}
 
-	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
-	loanDuration, _ := strconv.Atoi(c.PostForm("loan_duration"))
-	bookType := c.PostForm("book_type")
+	existing, err := wc.bookService.GetBook(id)
+	if err != nil {
+		wc.setFlash(c, "Livro não encontrado", "error")
+		c.Redirect(http.StatusFound, "/books")
+		return
+	}
 
+	// A quantidade é gerenciada pelos exemplares; tipo e prazo vêm do cadastro
 	book := &bookModel.Book{
 		Title:        c.PostForm("title"),
 		Author:       c.PostForm("author"),
-		Quantity:     quantity,
-		BookType:     bookType,
-		LoanDuration: loanDuration,
+		ISBN:         c.PostForm("isbn"),
+		BookType:     existing.BookType,
+		LoanDuration: existing.LoanDuration,
+		CreatedAt:    existing.CreatedAt,
 	}
+	readBookDetails(c, book)
 
 	err = wc.bookService.UpdateBook(id, book)
 	if err != nil {
@@ -308,6 +724,31 @@ func (wc *WebController) BookDelete(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/books")
 }
 
+func (wc *WebController) BookMerge(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/books")
+		return
+	}
+
+	duplicateID, err := strconv.ParseInt(c.PostForm("duplicate_id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "Selecione o registro duplicado", "error")
+		c.Redirect(http.StatusFound, "/books/"+c.Param("id")+"/edit")
+		return
+	}
+
+	if _, err := wc.bookService.MergeBooks(id, duplicateID); err != nil {
+		wc.setFlash(c, "Erro ao mesclar livros: "+err.Error(), "error")
+		c.Redirect(http.StatusFound, "/books/"+c.Param("id")+"/edit")
+		return
+	}
+
+	wc.setFlash(c, "Livros mesclados com sucesso!", "success")
+	c.Redirect(http.StatusFound, "/books/"+c.Param("id")+"/edit")
+}
+
 func (wc *WebController) BookCreate(c *gin.Context) {
 	quantity, _ := strconv.Atoi(c.PostForm("quantity"))
 	loanDuration, _ := strconv.Atoi(c.PostForm("loan_duration"))
@@ -316,10 +757,12 @@ func (wc *WebController) BookCreate(c *gin.Context) {
 	book := &bookModel.Book{
 		Title:        c.PostForm("title"),
 		Author:       c.PostForm("author"),
+		ISBN:         c.PostForm("isbn"),
 		Quantity:     quantity,
 		BookType:     bookType,
 		LoanDuration: loanDuration,
 	}
+	readBookDetails(c, book)
 
 	err := wc.bookService.CreateBook(book)
 	if err != nil {
@@ -331,58 +774,188 @@ func (wc *WebController) BookCreate(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/books")
 }
 
-// Users
-func (wc *WebController) UsersList(c *gin.Context) {
-	users, err := wc.userService.GetAllUsers()
+// readBookDetails lê os dados bibliográficos comuns aos formulários de
+// cadastro e edição. Vários autores ou assuntos são separados por ";".
+func readBookDetails(c *gin.Context, book *bookModel.Book) {
+	book.PublicationYear, _ = strconv.Atoi(c.PostForm("publication_year"))
+	book.Pages, _ = strconv.Atoi(c.PostForm("pages"))
+	book.Publisher = c.PostForm("publisher")
+	book.Edition = c.PostForm("edition")
+	book.Language = c.PostForm("language")
+
+	for _, name := range strings.Split(c.PostForm("subjects"), ";") {
+		book.Subjects = append(book.Subjects, &bookModel.Subject{Name: name})
+	}
+}
+
+// Copies
+func (wc *WebController) BookCopies(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		users = []*userModel.User{}
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/books")
+		return
+	}
+
+	book, err := wc.bookService.GetBook(id)
+	if err != nil {
+		wc.setFlash(c, "Livro não encontrado", "error")
+		c.Redirect(http.StatusFound, "/books")
+		return
+	}
+
+	copies, err := wc.copyService.GetBookCopies(id)
+	if err != nil {
+		copies = []*copyModel.Copy{}
 	}
 
 	message, flashType := wc.getFlash(c)
 
 	data := PageData{
-		Title:         "Usuários - Sistema de Biblioteca",
-		ActiveSection: "users",
+		Title:         "Exemplares - Sistema de Biblioteca",
+		ActiveSection: "books",
 		FlashMessage:  message,
 		FlashType:     flashType,
-		Users:         users,
+		Book:          book,
+		Copies:        copies,
+		ShowCopies:    true,
 	}
 
-	wc.renderTemplate(c, "users", data)
+	wc.renderTemplate(c, "books", data)
 }
 
-func (wc *WebController) UsersSearch(c *gin.Context) {
-	query := strings.ToLower(c.Query("q"))
-	users, err := wc.userService.GetAllUsers()
+func (wc *WebController) CopyCreate(c *gin.Context) {
+	bookId, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		users = []*userModel.User{}
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/books")
+		return
 	}
 
-	if query != "" {
-		var filtered []*userModel.User
-		for _, user := range users {
-			if strings.Contains(strings.ToLower(user.Name), query) ||
-				strings.Contains(strings.ToLower(user.Email), query) {
-				filtered = append(filtered, user)
-			}
-		}
-		users = filtered
+	copy := &copyModel.Copy{
+		BookID:        bookId,
+		Barcode:       strings.TrimSpace(c.PostForm("barcode")),
+		Condition:     c.PostForm("condition"),
+		ShelfLocation: c.PostForm("shelf_location"),
+	}
+
+	err = wc.copyService.CreateCopy(copy)
+	if err != nil {
+		wc.setFlash(c, "Erro ao adicionar exemplar: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Exemplar adicionado com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/books/"+c.Param("id")+"/copies")
+}
+
+func (wc *WebController) CopyUpdate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/books")
+		return
+	}
+
+	existing, err := wc.copyService.GetCopy(id)
+	if err != nil {
+		wc.setFlash(c, "Exemplar não encontrado", "error")
+		c.Redirect(http.StatusFound, "/books")
+		return
+	}
+
+	copy := &copyModel.Copy{
+		Barcode:       strings.TrimSpace(c.PostForm("barcode")),
+		Condition:     c.PostForm("condition"),
+		ShelfLocation: c.PostForm("shelf_location"),
+		Status:        c.PostForm("status"),
+	}
+
+	err = wc.copyService.UpdateCopy(id, copy)
+	if err != nil {
+		wc.setFlash(c, "Erro ao atualizar exemplar: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Exemplar atualizado com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/books/"+strconv.FormatInt(existing.BookID, 10)+"/copies")
+}
+
+func (wc *WebController) CopyDelete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/books")
+		return
+	}
+
+	existing, err := wc.copyService.GetCopy(id)
+	if err != nil {
+		wc.setFlash(c, "Exemplar não encontrado", "error")
+		c.Redirect(http.StatusFound, "/books")
+		return
+	}
+
+	err = wc.copyService.DeleteCopy(id)
+	if err != nil {
+		wc.setFlash(c, "Erro ao excluir exemplar: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Exemplar excluído com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/books/"+strconv.FormatInt(existing.BookID, 10)+"/copies")
+}
+
+// membershipsExpiringDays is how far ahead the user list looks for
+// memberships about to expire.
+const membershipsExpiringDays = 30
+
+// Users
+func (wc *WebController) UsersList(c *gin.Context) {
+	page := pageRequest(c)
+	filter := userModel.UserFilter{Query: strings.TrimSpace(c.Query("q"))}
+
+	// Filtro de inscrições; "expiring" são as ativas que vencem em breve,
+	// para a renovação
+	membership := c.Query("membership")
+	switch membership {
+	case userModel.StatusActive, userModel.StatusSuspended, userModel.StatusExpired:
+		filter.Status = membership
+	case "expiring":
+		filter.Status = userModel.StatusActive
+		filter.ExpiresBy = wc.clock.Now().AddDate(0, 0, membershipsExpiringDays)
+	default:
+		membership = ""
 	}
 
 	message, flashType := wc.getFlash(c)
 
+	users, err := wc.userService.ListUsers(filter, page)
+	if err != nil {
+		users = paging.NewPage([]*userModel.User{}, 0, page.Normalize())
+		message, flashType = "Erro ao listar usuários: "+err.Error(), "error"
+	}
+
 	data := PageData{
 		Title:         "Usuários - Sistema de Biblioteca",
 		ActiveSection: "users",
 		FlashMessage:  message,
 		FlashType:     flashType,
-		Users:         users,
-		SearchQuery:   query,
+		Users:         users.Items,
+		SearchQuery:   filter.Query,
+		StatusFilter:  membership,
+		SortOrder:     page.Sort,
+		Pagination:    newPagination(c, users),
 	}
 
 	wc.renderTemplate(c, "users", data)
 }
 
+// UsersSearch is the user list filtered by the q parameter.
+func (wc *WebController) UsersSearch(c *gin.Context) {
+	wc.UsersList(c)
+}
+
 func (wc *WebController) UserEditForm(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -398,6 +971,8 @@ func (wc *WebController) UserEditForm(c *gin.Context) {
 		return
 	}
 
+	hasPassword, _ := wc.authService.HasPassword(id)
+
 	message, flashType := wc.getFlash(c)
 
 	data := PageData{
@@ -407,6 +982,7 @@ func (wc *WebController) UserEditForm(c *gin.Context) {
 		FlashType:     flashType,
 		User:          user,
 		IsEdit:        true,
+		HasPassword:   hasPassword,
 	}
 
 	wc.renderTemplate(c, "users", data)
@@ -420,9 +996,20 @@ func (wc *WebController) UserUpdate(c *gin.Context) {
 		return
 	}
 
-	user := &userModel.User{
-		Name:  c.PostForm("name"),
-		Email: c.PostForm("email"),
+	user := userFromForm(c)
+
+	if user.Role != "" {
+		existing, err := wc.userService.GetUser(id)
+		if err != nil {
+			wc.setFlash(c, "Usuário não encontrado", "error")
+			c.Redirect(http.StatusFound, "/users")
+			return
+		}
+		if user.Role != existing.Role && auth.Check(c, authModel.ManageRoles) != nil {
+			wc.setFlash(c, "Seu perfil não pode alterar o papel de usuários", "error")
+			c.Redirect(http.StatusFound, "/users/"+c.Param("id")+"/edit")
+			return
+		}
 	}
 
 	err = wc.userService.UpdateUser(id, user)
@@ -454,20 +1041,120 @@ func (wc *WebController) UserDelete(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/users")
 }
 
-func (wc *WebController) UserCreate(c *gin.Context) {
-	user := &userModel.User{
-		Name:  c.PostForm("name"),
-		Email: c.PostForm("email"),
+// UserPassword sets the password the user logs in with. Changing it ends
+// the user's sessions, so changing one's own password means logging in again.
+func (wc *WebController) UserPassword(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/users")
+		return
 	}
 
-	err := wc.userService.CreateUser(user)
-	if err != nil {
-		wc.setFlash(c, "Erro ao criar usuário: "+err.Error(), "error")
-	} else {
-		wc.setFlash(c, "Usuário criado com sucesso!", "success")
+	editURL := "/users/" + c.Param("id") + "/edit"
+	password := c.PostForm("password")
+	if password != c.PostForm("password_confirm") {
+		wc.setFlash(c, "As senhas não conferem", "error")
+		c.Redirect(http.StatusFound, editURL)
+		return
 	}
 
-	c.Redirect(http.StatusFound, "/users")
+	if err := wc.authService.SetPassword(id, password); err != nil {
+		wc.setFlash(c, "Erro ao definir senha: "+err.Error(), "error")
+		c.Redirect(http.StatusFound, editURL)
+		return
+	}
+
+	if auth.CurrentUser(c).ID == id {
+		wc.clearSession(c)
+		wc.setFlash(c, "Senha alterada. Entre novamente com a nova senha.", "success")
+		c.Redirect(http.StatusFound, "/login?next="+url.QueryEscape(editURL))
+		return
+	}
+
+	wc.setFlash(c, "Senha definida com sucesso!", "success")
+	c.Redirect(http.StatusFound, editURL)
+}
+
+// userFromForm reads the fields shared by the create and edit forms. An
+// empty expiry date keeps the current one, or on creation follows from the
+// category.
+func userFromForm(c *gin.Context) *userModel.User {
+	maxLoans, _ := strconv.Atoi(c.PostForm("max_loans"))
+	expiresAt, _ := time.Parse(time.DateOnly, c.PostForm("expires_at"))
+
+	return &userModel.User{
+		Name:       c.PostForm("name"),
+		Email:      c.PostForm("email"),
+		MaxLoans:   maxLoans,
+		Role:       c.PostForm("role"),
+		CardNumber: strings.TrimSpace(c.PostForm("card_number")),
+		Category:   c.PostForm("category"),
+		ExpiresAt:  expiresAt,
+	}
+}
+
+// MembershipRenew extends the membership by the length of the member's
+// category.
+func (wc *WebController) MembershipRenew(c *gin.Context) {
+	wc.changeMembership(c, wc.userService.RenewMembership, func(user *userModel.User) string {
+		return "Inscrição renovada até " + user.ExpiresAt.Format("02/01/2006") + "!"
+	})
+}
+
+func (wc *WebController) MembershipSuspend(c *gin.Context) {
+	wc.changeMembership(c, wc.userService.SuspendMembership, func(*userModel.User) string {
+		return "Inscrição suspensa; o usuário não pode fazer empréstimos até ser reativado"
+	})
+}
+
+func (wc *WebController) MembershipReinstate(c *gin.Context) {
+	wc.changeMembership(c, wc.userService.ReinstateMembership, func(user *userModel.User) string {
+		if user.Status == userModel.StatusExpired {
+			return "Suspensão retirada, mas a inscrição está vencida; renove-a para liberar empréstimos"
+		}
+		return "Inscrição reativada!"
+	})
+}
+
+func (wc *WebController) changeMembership(c *gin.Context, change func(id int64) (*userModel.User, error), success func(user *userModel.User) string) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/users")
+		return
+	}
+
+	user, err := change(id)
+	if err != nil {
+		wc.setFlash(c, "Erro ao alterar inscrição: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, success(user), "success")
+	}
+
+	c.Redirect(http.StatusFound, "/users/"+c.Param("id")+"/edit")
+}
+
+func (wc *WebController) UserCreate(c *gin.Context) {
+	user := userFromForm(c)
+
+	// Só quem gerencia papéis cadastra funcionários
+	if user.Role != "" && user.Role != userModel.RolePatron {
+		if err := auth.Check(c, authModel.ManageRoles); err != nil {
+			wc.setFlash(c, "Seu perfil não pode cadastrar bibliotecários ou administradores", "error")
+			c.Redirect(http.StatusFound, "/users")
+			return
+		}
+	}
+
+	err := wc.userService.CreateUser(user)
+	if err != nil {
+		wc.setFlash(c, "Erro ao criar usuário: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Usuário criado com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/users")
 }
 
 func (wc *WebController) UserLoans(c *gin.Context) {
@@ -485,50 +1172,156 @@ func (wc *WebController) UserLoans(c *gin.Context) {
 		return
 	}
 
-	// Buscar todos os empréstimos e filtrar por usuário
-	allLoans, err := wc.loanService.GetAllLoans()
+	page := pageRequest(c)
+	if page.Sort == "" {
+		page.Sort = "-borrowedAt"
+	}
+
+	message, flashType := wc.getFlash(c)
+
+	loans, err := wc.loanService.ListLoans(loanModel.LoanFilter{UserID: id}, page)
 	if err != nil {
-		allLoans = []*loanModel.Loan{}
+		loans = paging.NewPage([]*loanModel.Loan{}, 0, page.Normalize())
+		message, flashType = "Erro ao listar empréstimos: "+err.Error(), "error"
 	}
 
-	var loans []*loanModel.Loan
-	for _, loan := range allLoans {
-		if loan.UserID == id {
-			loans = append(loans, loan)
-		}
+	var bookIDs []int64
+	for _, loan := range loans.Items {
+		bookIDs = append(bookIDs, loan.BookID)
 	}
 
-	// Buscar todos os livros para criar o mapa
-	allBooks, _ := wc.bookService.GetAllBooks()
-	booksMap := make(map[int64]*bookModel.Book)
-	for _, book := range allBooks {
-		booksMap[book.ID] = book
+	// The count covers every open loan, not just the ones on this page
+	open, _ := wc.loanService.GetUserLoans(id)
+
+	block, _ := wc.loanService.BorrowingBlock(id)
+
+	data := PageData{
+		Title:           "Empréstimos do Usuário - Sistema de Biblioteca",
+		ActiveSection:   "users",
+		FlashMessage:    message,
+		FlashType:       flashType,
+		User:            user,
+		Loans:           loans.Items,
+		ShowUserLoans:   true,
+		BooksMap:        wc.booksOf(bookIDs),
+		LoanLimit:       wc.loanService.LoanLimit(user),
+		ActiveLoanCount: len(open),
+		BorrowingBlock:  block,
+		Pagination:      newPagination(c, loans),
+	}
+
+	wc.renderTemplate(c, "users", data)
+}
+
+func (wc *WebController) UserAccount(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/users")
+		return
 	}
 
+	user, err := wc.userService.GetUser(id)
+	if err != nil {
+		wc.setFlash(c, "Usuário não encontrado", "error")
+		c.Redirect(http.StatusFound, "/users")
+		return
+	}
+
+	account, err := wc.accountService.GetAccount(id)
+	if err != nil {
+		wc.setFlash(c, "Erro ao carregar conta: "+err.Error(), "error")
+		c.Redirect(http.StatusFound, "/users")
+		return
+	}
+
+	block, _ := wc.loanService.BorrowingBlock(id)
+
 	message, flashType := wc.getFlash(c)
 
 	data := PageData{
-		Title:         "Empréstimos do Usuário - Sistema de Biblioteca",
-		ActiveSection: "users",
-		FlashMessage:  message,
-		FlashType:     flashType,
-		User:          user,
-		Loans:         loans,
-		ShowUserLoans: true,
-		BooksMap:      booksMap,
+		Title:          "Conta do Usuário - Sistema de Biblioteca",
+		ActiveSection:  "users",
+		FlashMessage:   message,
+		FlashType:      flashType,
+		User:           user,
+		Account:        account,
+		ShowAccount:    true,
+		BorrowingBlock: block,
 	}
 
 	wc.renderTemplate(c, "users", data)
 }
 
+func (wc *WebController) AccountPay(c *gin.Context) {
+	wc.postTransaction(c, "Pagamento registrado com sucesso!",
+		func(userID int64, amount money.Money) error {
+			_, err := wc.accountService.Pay(userID, amount, c.PostForm("note"))
+			return err
+		})
+}
+
+func (wc *WebController) AccountWaive(c *gin.Context) {
+	wc.postTransaction(c, "Multa perdoada com sucesso!",
+		func(userID int64, amount money.Money) error {
+			_, err := wc.accountService.Waive(userID, amount, c.PostForm("note"))
+			return err
+		})
+}
+
+func (wc *WebController) AccountCharge(c *gin.Context) {
+	wc.postTransaction(c, "Cobrança lançada com sucesso!",
+		func(userID int64, amount money.Money) error {
+			_, err := wc.accountService.Charge(userID, c.PostForm("reason"), amount, c.PostForm("note"))
+			return err
+		})
+}
+
+// postTransaction reads the amount shared by the account forms and goes back
+// to the account page.
+func (wc *WebController) postTransaction(c *gin.Context, success string, record func(userID int64, amount money.Money) error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/users")
+		return
+	}
+	accountURL := "/users/" + c.Param("id") + "/account"
+
+	amount, err := money.Parse(c.PostForm("amount"))
+	if err != nil {
+		wc.setFlash(c, "Valor inválido: "+err.Error(), "error")
+		c.Redirect(http.StatusFound, accountURL)
+		return
+	}
+
+	if err := record(id, amount); err != nil {
+		wc.setFlash(c, "Erro ao registrar lançamento: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, success, "success")
+	}
+
+	c.Redirect(http.StatusFound, accountURL)
+}
+
 // Loans
 func (wc *WebController) LoansList(c *gin.Context) {
-	loans, err := wc.loanService.GetAllLoans()
+	page := pageRequest(c)
+	filter := loanModel.LoanFilter{Status: c.Query("status")}
+	filter.UserID, _ = strconv.ParseInt(c.Query("user_id"), 10, 64)
+	filter.BookID, _ = strconv.ParseInt(c.Query("book_id"), 10, 64)
+	filter.BorrowedFrom, _ = time.Parse(time.DateOnly, c.Query("borrowed_from"))
+	filter.BorrowedTo, _ = time.Parse(time.DateOnly, c.Query("borrowed_to"))
+
+	message, flashType := wc.getFlash(c)
+
+	loans, err := wc.loanService.ListLoans(filter, page)
 	if err != nil {
-		loans = []*loanModel.Loan{}
+		loans = paging.NewPage([]*loanModel.Loan{}, 0, page.Normalize())
+		message, flashType = "Erro ao listar empréstimos: "+err.Error(), "error"
 	}
 
-	// Load users and books for the modal
+	// Load users and books for the modal and the filters
 	users, err := wc.userService.GetAllUsers()
 	if err != nil {
 		users = []*userModel.User{}
@@ -539,115 +1332,394 @@ func (wc *WebController) LoansList(c *gin.Context) {
 		books = []*bookModel.Book{}
 	}
 
-	message, flashType := wc.getFlash(c)
-
 	data := PageData{
 		Title:         "Empréstimos - Sistema de Biblioteca",
 		ActiveSection: "loans",
 		FlashMessage:  message,
 		FlashType:     flashType,
-		Loans:         loans,
+		Loans:         loans.Items,
 		Users:         users,
 		Books:         books,
+		StatusFilter:  filter.Status,
+		LoanFilter:    filter,
+		SortOrder:     page.Sort,
+		Pagination:    newPagination(c, loans),
 	}
 
 	wc.renderTemplate(c, "loans", data)
 }
 
+// LoansSearch is the loan list filtered by status, member, book and the day
+// the book was borrowed.
 func (wc *WebController) LoansSearch(c *gin.Context) {
-	query := strings.ToLower(c.Query("q"))
-	statusFilter := c.Query("status")
-	loans, err := wc.loanService.GetAllLoans()
+	wc.LoansList(c)
+}
+
+func (wc *WebController) LoanReturn(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		loans = []*loanModel.Loan{}
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/loans")
+		return
 	}
 
-	var filtered []*loanModel.Loan
-	for _, loan := range loans {
-		// Filtro por status
-		if statusFilter != "" && loan.Status != statusFilter {
-			continue
-		}
+	err = wc.loanService.ReturnBook(id)
+	if err != nil {
+		wc.setFlash(c, "Erro ao devolver livro: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Livro devolvido com sucesso!", "success")
+	}
 
-		// Busca por texto (pode buscar por ID do livro ou usuário)
-		if query != "" {
-			bookIdStr := strconv.FormatInt(loan.BookID, 10)
-			userIdStr := strconv.FormatInt(loan.UserID, 10)
-			if !strings.Contains(bookIdStr, query) && !strings.Contains(userIdStr, query) {
-				continue
-			}
+	c.Redirect(http.StatusFound, "/loans")
+}
+
+func (wc *WebController) LoanRenew(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/loans")
+		return
+	}
+
+	loan, err := wc.loanService.RenewLoan(id)
+	if err != nil {
+		wc.setFlash(c, "Erro ao renovar empréstimo: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Empréstimo renovado até "+loan.DueDate.Format("02/01/2006")+"!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/loans")
+}
+
+func (wc *WebController) LoanCreate(c *gin.Context) {
+	userId, err := strconv.ParseInt(c.PostForm("user_id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID do usuário inválido", "error")
+		c.Redirect(http.StatusFound, "/loans")
+		return
+	}
+
+	// Código de barras lido no balcão tem prioridade sobre o livro selecionado
+	if barcode := strings.TrimSpace(c.PostForm("barcode")); barcode != "" {
+		_, err = wc.loanService.CreateLoanByBarcode(barcode, userId)
+	} else {
+		bookId, parseErr := strconv.ParseInt(c.PostForm("book_id"), 10, 64)
+		if parseErr != nil {
+			wc.setFlash(c, "ID do livro inválido", "error")
+			c.Redirect(http.StatusFound, "/loans")
+			return
 		}
+		_, err = wc.loanService.CreateLoan(bookId, userId)
+	}
+	if err != nil {
+		wc.setFlash(c, "Erro ao criar empréstimo: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Empréstimo criado com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/loans")
+}
+
+// Policies
+func (wc *WebController) PoliciesList(c *gin.Context) {
+	policies, err := wc.policyService.GetAllPolicies()
+	if err != nil {
+		policies = []*policyModel.LoanPolicy{}
+	}
+
+	message, flashType := wc.getFlash(c)
 
-		filtered = append(filtered, loan)
+	data := PageData{
+		Title:         "Tipos de Material - Sistema de Biblioteca",
+		ActiveSection: "policies",
+		FlashMessage:  message,
+		FlashType:     flashType,
+		Policies:      policies,
 	}
 
-	// Load users and books for the modal
+	wc.renderTemplate(c, "policies", data)
+}
+
+func (wc *WebController) PolicyEditForm(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/policies")
+		return
+	}
+
+	policy, err := wc.policyService.GetPolicy(id)
+	if err != nil {
+		wc.setFlash(c, "Tipo de material não encontrado", "error")
+		c.Redirect(http.StatusFound, "/policies")
+		return
+	}
+
+	message, flashType := wc.getFlash(c)
+
+	data := PageData{
+		Title:         "Editar Tipo de Material - Sistema de Biblioteca",
+		ActiveSection: "policies",
+		FlashMessage:  message,
+		FlashType:     flashType,
+		Policy:        policy,
+		IsEdit:        true,
+	}
+
+	wc.renderTemplate(c, "policies", data)
+}
+
+// policyFromForm reads the fields shared by the create and edit forms.
+func policyFromForm(c *gin.Context) *policyModel.LoanPolicy {
+	loanDays, _ := strconv.Atoi(c.PostForm("loan_days"))
+	maxRenewals, _ := strconv.Atoi(c.PostForm("max_renewals"))
+	graceDays, _ := strconv.Atoi(c.PostForm("renewal_grace_days"))
+	fineRate, _ := money.Parse(c.PostForm("fine_rate"))
+	fineGraceDays, _ := strconv.Atoi(c.PostForm("fine_grace_days"))
+	maxFine, _ := money.Parse(c.PostForm("max_fine"))
+
+	return &policyModel.LoanPolicy{
+		Code:             c.PostForm("code"),
+		Name:             c.PostForm("name"),
+		Loanable:         c.PostForm("loanable") == "on",
+		LoanDays:         loanDays,
+		Renewable:        c.PostForm("renewable") == "on",
+		MaxRenewals:      maxRenewals,
+		RenewalGraceDays: graceDays,
+		FineRate:         fineRate,
+		FineGraceDays:    fineGraceDays,
+		MaxFine:          maxFine,
+	}
+}
+
+func (wc *WebController) PolicyCreate(c *gin.Context) {
+	err := wc.policyService.CreatePolicy(policyFromForm(c))
+	if err != nil {
+		wc.setFlash(c, "Erro ao criar tipo de material: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Tipo de material criado com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/policies")
+}
+
+func (wc *WebController) PolicyUpdate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/policies")
+		return
+	}
+
+	err = wc.policyService.UpdatePolicy(id, policyFromForm(c))
+	if err != nil {
+		wc.setFlash(c, "Erro ao atualizar tipo de material: "+err.Error(), "error")
+		c.Redirect(http.StatusFound, "/policies/"+c.Param("id")+"/edit")
+		return
+	}
+
+	wc.setFlash(c, "Tipo de material atualizado com sucesso!", "success")
+	c.Redirect(http.StatusFound, "/policies")
+}
+
+func (wc *WebController) PolicyDelete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/policies")
+		return
+	}
+
+	err = wc.policyService.DeletePolicy(id)
+	if err != nil {
+		wc.setFlash(c, "Erro ao excluir tipo de material: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Tipo de material excluído com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/policies")
+}
+
+// Holds
+func (wc *WebController) HoldsList(c *gin.Context) {
+	statusFilter := c.Query("status")
+	allHolds, err := wc.holdService.GetAllHolds()
+	if err != nil {
+		allHolds = []*holdModel.Hold{}
+	}
+
+	var holds []*holdModel.Hold
+	for _, hold := range allHolds {
+		if statusFilter == "" || hold.Status == statusFilter {
+			holds = append(holds, hold)
+		}
+	}
+
+	// Usuários e livros para o modal e para exibir os nomes nos cartões
 	users, err := wc.userService.GetAllUsers()
 	if err != nil {
 		users = []*userModel.User{}
 	}
+	usersMap := make(map[int64]*userModel.User)
+	for _, user := range users {
+		usersMap[user.ID] = user
+	}
 
 	books, err := wc.bookService.GetAllBooks()
 	if err != nil {
 		books = []*bookModel.Book{}
 	}
+	booksMap := make(map[int64]*bookModel.Book)
+	for _, book := range books {
+		booksMap[book.ID] = book
+	}
 
 	message, flashType := wc.getFlash(c)
 
 	data := PageData{
-		Title:         "Empréstimos - Sistema de Biblioteca",
-		ActiveSection: "loans",
+		Title:         "Reservas - Sistema de Biblioteca",
+		ActiveSection: "holds",
 		FlashMessage:  message,
 		FlashType:     flashType,
-		Loans:         filtered,
+		Holds:         holds,
 		Users:         users,
 		Books:         books,
-		SearchQuery:   query,
+		UsersMap:      usersMap,
+		BooksMap:      booksMap,
 		StatusFilter:  statusFilter,
 	}
 
-	wc.renderTemplate(c, "loans", data)
+	wc.renderTemplate(c, "holds", data)
 }
 
-func (wc *WebController) LoanReturn(c *gin.Context) {
+func (wc *WebController) HoldCreate(c *gin.Context) {
+	userId, err := strconv.ParseInt(c.PostForm("user_id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID do usuário inválido", "error")
+		c.Redirect(http.StatusFound, "/holds")
+		return
+	}
+
+	bookId, err := strconv.ParseInt(c.PostForm("book_id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID do livro inválido", "error")
+		c.Redirect(http.StatusFound, "/holds")
+		return
+	}
+
+	_, err = wc.holdService.PlaceHold(bookId, userId)
+	if err != nil {
+		wc.setFlash(c, "Erro ao criar reserva: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Reserva criada com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/holds")
+}
+
+func (wc *WebController) HoldCancel(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		wc.setFlash(c, "ID inválido", "error")
-		c.Redirect(http.StatusFound, "/loans")
+		c.Redirect(http.StatusFound, "/holds")
 		return
 	}
 
-	err = wc.loanService.ReturnBook(id)
+	err = wc.holdService.CancelHold(id)
 	if err != nil {
-		wc.setFlash(c, "Erro ao devolver livro: "+err.Error(), "error")
+		wc.setFlash(c, "Erro ao cancelar reserva: "+err.Error(), "error")
 	} else {
-		wc.setFlash(c, "Livro devolvido com sucesso!", "success")
+		wc.setFlash(c, "Reserva cancelada com sucesso!", "success")
 	}
 
-	c.Redirect(http.StatusFound, "/loans")
+	c.Redirect(http.StatusFound, "/holds")
 }
 
-func (wc *WebController) LoanCreate(c *gin.Context) {
-	bookId, err := strconv.ParseInt(c.PostForm("book_id"), 10, 64)
+func (wc *WebController) HoldsProcess(c *gin.Context) {
+	processed, err := wc.holdService.ProcessHolds()
 	if err != nil {
-		wc.setFlash(c, "ID do livro inválido", "error")
-		c.Redirect(http.StatusFound, "/loans")
+		wc.setFlash(c, "Erro ao processar reservas: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Reservas processadas: "+strconv.Itoa(processed), "success")
+	}
+
+	c.Redirect(http.StatusFound, "/holds")
+}
+
+// Calendar
+var weekdayNames = []string{"Domingo", "Segunda", "Terça", "Quarta", "Quinta", "Sexta", "Sábado"}
+
+func (wc *WebController) CalendarPage(c *gin.Context) {
+	calendar, err := wc.calendarService.GetCalendar()
+	if err != nil {
+		calendar = &calendarModel.Calendar{}
+	}
+
+	message, flashType := wc.getFlash(c)
+
+	data := PageData{
+		Title:         "Calendário - Sistema de Biblioteca",
+		ActiveSection: "calendar",
+		FlashMessage:  message,
+		FlashType:     flashType,
+		Calendar:      calendar,
+		WeekdayNames:  weekdayNames,
+	}
+
+	wc.renderTemplate(c, "calendar", data)
+}
+
+func (wc *WebController) OpeningHoursUpdate(c *gin.Context) {
+	weekday, err := strconv.Atoi(c.Param("weekday"))
+	if err != nil {
+		wc.setFlash(c, "Dia da semana inválido", "error")
+		c.Redirect(http.StatusFound, "/calendar")
 		return
 	}
 
-	userId, err := strconv.ParseInt(c.PostForm("user_id"), 10, 64)
+	err = wc.calendarService.UpdateOpeningHours(&calendarModel.OpeningHours{
+		Weekday:  time.Weekday(weekday),
+		Open:     c.PostForm("open") == "on",
+		OpensAt:  c.PostForm("opens_at"),
+		ClosesAt: c.PostForm("closes_at"),
+	})
 	if err != nil {
-		wc.setFlash(c, "ID do usuário inválido", "error")
-		c.Redirect(http.StatusFound, "/loans")
+		wc.setFlash(c, "Erro ao atualizar horário: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Horário atualizado com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/calendar")
+}
+
+func (wc *WebController) ClosureCreate(c *gin.Context) {
+	err := wc.calendarService.CreateClosure(&calendarModel.Closure{
+		Date:   c.PostForm("date"),
+		Reason: c.PostForm("reason"),
+	})
+	if err != nil {
+		wc.setFlash(c, "Erro ao adicionar fechamento: "+err.Error(), "error")
+	} else {
+		wc.setFlash(c, "Fechamento adicionado com sucesso!", "success")
+	}
+
+	c.Redirect(http.StatusFound, "/calendar")
+}
+
+func (wc *WebController) ClosureDelete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		wc.setFlash(c, "ID inválido", "error")
+		c.Redirect(http.StatusFound, "/calendar")
 		return
 	}
 
-	_, err = wc.loanService.CreateLoan(bookId, userId)
+	err = wc.calendarService.DeleteClosure(id)
 	if err != nil {
-		wc.setFlash(c, "Erro ao criar empréstimo: "+err.Error(), "error")
+		wc.setFlash(c, "Erro ao remover fechamento: "+err.Error(), "error")
 	} else {
-		wc.setFlash(c, "Empréstimo criado com sucesso!", "success")
+		wc.setFlash(c, "Fechamento removido com sucesso!", "success")
 	}
 
-	c.Redirect(http.StatusFound, "/loans")
+	c.Redirect(http.StatusFound, "/calendar")
 }